@@ -20,11 +20,8 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
-	"net/http"
 	"os"
 	"path/filepath"
-	"strings"
-	"sync/atomic"
 
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/holiman/goevmlab/common"
@@ -36,12 +33,17 @@ import (
 var (
 	engineFlag = &cli.StringSliceFlag{
 		Name:  "engine",
-		Usage: "fuzzing-engine",
+		Usage: fmt.Sprintf("fuzzing-engine, %q for the coverage-guided corpus", fuzzing.CorpusEngine),
 		Value: cli.NewStringSlice(fuzzing.FactoryNames()...),
 	}
+	corpusFlag = &cli.StringFlag{
+		Name:  "corpus",
+		Usage: "Directory of the corpus, used by the corpus engine (default: <outdir>/corpus)",
+	}
 	forkFlag = &cli.StringFlag{
-		Name:  "fork",
-		Usage: fmt.Sprintf("Fork to use %v", ops.ForkNames()),
+		Name: "fork",
+		Usage: fmt.Sprintf("Fork to use %v, or a comma-separated list of forks. The tests are generated "+
+			"for the latest fork, and executed under each fork", ops.ForkNames()),
 		Value: ops.ForkNames()[len(ops.ForkNames())-1],
 	}
 	app = initApp()
@@ -58,11 +60,24 @@ func initApp() *cli.App {
 		common.ThreadFlag,
 		common.LocationFlag,
 		engineFlag,
+		corpusFlag,
 		forkFlag,
+		common.VariantsFlag,
 		common.VerbosityFlag,
 		common.NotifyFlag,
+		common.NotifyURLFlag,
+		common.NotifyEventsFlag,
 		common.RemoveFilesFlag,
 		common.RawDebugFlag,
+		common.ContinueFlag,
+		common.MaxFlawsFlag,
+		common.MaxFlawsPerClientFlag,
+		common.DedupFlag,
+		common.RegressionFlag,
+		common.MetricsAddrFlag,
+		common.SessionFlag,
+		common.ResumeFlag,
+		common.SeedFlag,
 	)
 	app.Action = startFuzzer
 	return app
@@ -76,46 +91,53 @@ func main() {
 }
 
 func startFuzzer(ctx *cli.Context) (err error) {
-	if topic := ctx.String(common.NotifyFlag.Name); topic != "" {
-		_, _ = http.Post(fmt.Sprintf("https://ntfy.sh/%v", topic), "text/plain",
-			strings.NewReader("Fuzzer starting"))
-	}
 	loglevel := slog.Level(ctx.Int(common.VerbosityFlag.Name))
 	log.SetDefault(log.NewLogger(log.NewTerminalHandlerWithLevel(os.Stderr, loglevel, true)))
 	log.Root().Write(loglevel, "Set loglevel", "level", loglevel)
-	var (
-		fNames = ctx.StringSlice(engineFlag.Name)
-		fork   = ctx.String(forkFlag.Name)
-	)
+	fNames := ctx.StringSlice(engineFlag.Name)
+	forks, err := ops.ParseForks(ctx.String(forkFlag.Name))
+	if err != nil {
+		return err
+	}
+	fork := forks[len(forks)-1]
 	if len(fNames) == 0 {
 		fmt.Printf("At least one fuzzer engine needed. ")
 		fmt.Printf("Available targets: %v\n", fuzzing.FactoryNames())
 		return errors.New("missing engine")
 	}
-	var factory common.GeneratorFn
-	if len(fNames) == 1 {
-		factory = fuzzing.Factory(fNames[0], fork)
-		if factory == nil {
-			return fmt.Errorf("unknown target %v", fNames[0])
-		}
-	} else {
-		// Need to put together a meta-factory
-		var factories []common.GeneratorFn
-		for _, fName := range fNames {
-			if f := fuzzing.Factory(fName, fork); f == nil {
-				return fmt.Errorf("unknown target %v", fName)
-			} else {
-				factories = append(factories, f)
+	seed, err := common.InitSeed(ctx)
+	if err != nil {
+		return err
+	}
+	var (
+		engines  []common.Engine
+		corpus   *fuzzing.Corpus
+		variants = ctx.Int(common.VariantsFlag.Name)
+	)
+	for _, fName := range fNames {
+		if fName == fuzzing.CorpusEngine {
+			if ctx.Bool(common.SkipTraceFlag.Name) {
+				return errors.New("the corpus engine needs the traces, and cannot be used with --skiptrace")
 			}
-			log.Info("Added factory", "name", fName)
-		}
-		var index atomic.Uint64
-		factory = func() *fuzzing.GstMaker {
-			i := int(index.Add(1))
-			i %= len(factories)
-			fn := factories[i]
-			return fn()
+			dir := ctx.String(corpusFlag.Name)
+			if dir == "" {
+				dir = filepath.Join(ctx.String(common.LocationFlag.Name), "corpus")
+			}
+			if corpus, err = fuzzing.NewCorpus(dir, fork); err != nil {
+				return err
+			}
+			engines = append(engines, common.Engine{Name: fName, Generate: fuzzing.WithVariants(fuzzing.WithForks(corpus.Generate, forks), variants)})
+		} else if g := fuzzing.NewSeededGenerator(fName, fork, seed); g == nil {
+			return fmt.Errorf("unknown target %v", fName)
+		} else {
+			engines = append(engines, common.Engine{Name: fName, Generate: fuzzing.WithVariants(fuzzing.WithForks(g.Generate, forks), variants), Seeded: g})
 		}
+		log.Info("Added factory", "name", fName)
+	}
+	log.Info("Generating tests", "seed", seed, "forks", forks, "variants", variants)
+	if corpus != nil {
+		// All tests are fed to the corpus, also the ones from other engines
+		return common.GenerateAndExecuteEngines(ctx, engines, corpus.Observe)
 	}
-	return common.GenerateAndExecute(ctx, factory, "mixed")
+	return common.GenerateAndExecuteEngines(ctx, engines, nil)
 }
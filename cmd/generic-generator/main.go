@@ -39,8 +39,9 @@ var (
 		Value: cli.NewStringSlice(fuzzing.FactoryNames()...),
 	}
 	forkFlag = &cli.StringFlag{
-		Name:  "fork",
-		Usage: fmt.Sprintf("Fork to use %v", ops.ForkNames()),
+		Name: "fork",
+		Usage: fmt.Sprintf("Fork to use %v, or a comma-separated list of forks. The tests are generated "+
+			"for the latest fork, and have a post-state for each fork", ops.ForkNames()),
 		Value: ops.ForkNames()[len(ops.ForkNames())-1],
 	}
 	app = initApp()
@@ -56,8 +57,10 @@ func initApp() *cli.App {
 		common.LocationFlag,
 		common.CountFlag,
 		common.TraceFlag,
+		common.SeedFlag,
 		engineFlag,
 		forkFlag,
+		common.VariantsFlag,
 	}
 	app.Action = generate
 	return app
@@ -72,23 +75,28 @@ func main() {
 }
 
 type config struct {
-	fork     string
+	forks    []string
 	prefix   string
 	count    int
 	location string
 	factory  func() *fuzzing.GstMaker
 	target   string
 	tracing  bool
+	seed     int64
 }
 
 func generate(ctx *cli.Context) error {
 	var (
 		fNames   = ctx.StringSlice(engineFlag.Name)
-		fork     = ctx.String(forkFlag.Name)
 		prefix   = ""
 		count    = ctx.Int(common.CountFlag.Name)
 		location = ctx.String(common.LocationFlag.Name)
 	)
+	forks, err := ops.ParseForks(ctx.String(forkFlag.Name))
+	if err != nil {
+		return err
+	}
+	fork := forks[len(forks)-1]
 	if err := os.MkdirAll(location, 0755); err != nil {
 		return fmt.Errorf("could not create %v: %v", location, err)
 	}
@@ -100,9 +108,13 @@ func generate(ctx *cli.Context) error {
 		fmt.Printf("Available targets: %v\n", fuzzing.FactoryNames())
 		return errors.New("missing engine")
 	}
+	seed, err := common.InitSeed(ctx)
+	if err != nil {
+		return err
+	}
 	var factory common.GeneratorFn
 	if len(fNames) == 1 {
-		factory = fuzzing.Factory(fNames[0], fork)
+		factory = fuzzing.SeededFactory(fNames[0], fork, seed)
 		if factory == nil {
 			return fmt.Errorf("unknown target %v", fNames[0])
 		}
@@ -110,7 +122,7 @@ func generate(ctx *cli.Context) error {
 		// Need to put together a meta-factory
 		var factories []common.GeneratorFn
 		for _, fName := range fNames {
-			if f := fuzzing.Factory(fName, fork); f == nil {
+			if f := fuzzing.SeededFactory(fName, fork, seed); f == nil {
 				return fmt.Errorf("unknown target %v", fName)
 			} else {
 				factories = append(factories, f)
@@ -125,13 +137,14 @@ func generate(ctx *cli.Context) error {
 		}
 	}
 	return createTests(&config{
-		fork:     fork,
+		forks:    forks,
 		prefix:   prefix,
 		count:    count,
 		location: location,
-		factory:  factory,
+		factory:  fuzzing.WithVariants(fuzzing.WithForks(factory, forks), ctx.Int(common.VariantsFlag.Name)),
 		target:   fNames[0],
 		tracing:  ctx.Bool(common.TraceFlag.Name),
+		seed:     seed,
 	})
 }
 
@@ -139,9 +152,10 @@ func createTests(conf *config) error {
 	log.Info("Generating tests",
 		"location", conf.location,
 		"prefix", conf.prefix,
-		"fork", conf.fork,
+		"forks", conf.forks,
 		"limit", conf.count,
-		"tracing", conf.tracing)
+		"tracing", conf.tracing,
+		"seed", conf.seed)
 	for i := 0; i < conf.count; i++ {
 		testName := fmt.Sprintf("%v%v-%04d", conf.prefix, conf.target, i)
 		p := path.Join(conf.location, fmt.Sprintf("%v.json", testName))
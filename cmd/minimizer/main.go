@@ -28,6 +28,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/holiman/goevmlab/common"
+	"github.com/holiman/goevmlab/evms"
 	"github.com/holiman/goevmlab/fuzzing"
 	"github.com/urfave/cli/v2"
 )
@@ -82,11 +83,14 @@ func startFuzzer(c *cli.Context) error {
 	if c.NArg() != 1 {
 		return fmt.Errorf("input state test file needed")
 	}
+	vms, err := common.InitVMs(c)
+	if err != nil {
+		return err
+	}
 	var (
 		testPath  = c.Args().First()
 		compareFn func(path string, c *cli.Context) (bool, error)
 		patience  = c.Int(patienceFlag.Name)
-		vms       = common.InitVMs(c)
 		outdir    = c.String(common.LocationFlag.Name)
 	)
 	compareFn = func(path string, c *cli.Context) (bool, error) {
@@ -105,6 +109,8 @@ func startFuzzer(c *cli.Context) error {
 		// In this mode, we just execute one VM which crashes.
 		evm := vms[0]
 		compareFn = func(path string, c *cli.Context) (bool, error) {
+			evms.RegisterTest(path)
+			defer evms.ForgetTest(path)
 			if _, err := evm.RunStateTest(path, io.Discard, false); err != nil {
 				log.Info("EVM crash occurred", "err", err)
 				return false, nil
@@ -114,11 +120,15 @@ func startFuzzer(c *cli.Context) error {
 		}
 	} else if c.Bool(fullTraceFlag.Name) {
 		compareFn = func(path string, c *cli.Context) (bool, error) {
-			agree, err := common.RunSingleTest(path, outdir, vms)
-			if !agree {
+			div, err := common.DiffTest(path, outdir, vms)
+			if err != nil {
+				return true, err
+			}
+			if div != nil {
+				log.Info("Consensus failure", "divergence", div)
 				return false, nil
 			}
-			return true, err
+			return true, nil
 		}
 	}
 	if consensus, err := compareFn(testPath, c); err != nil {
@@ -254,5 +264,21 @@ func startFuzzer(c *cli.Context) error {
 	}
 
 	log.Info("Done", "result", good)
+	if len(vms) > 1 {
+		// Write out the traces of the minimized test
+		div, err := common.DiffTest(good, outdir, vms)
+		if err != nil {
+			return err
+		}
+		if div != nil {
+			fmt.Print(div.Report())
+		}
+		for _, vm := range vms {
+			fmt.Printf("- %v: %v\n", vm.Name(), filepath.Join(outdir, fmt.Sprintf("%v-output.jsonl", vm.Name())))
+		}
+	}
+	if report := common.PostStateReport(good, vms); report != "" {
+		fmt.Print(report)
+	}
 	return nil
 }
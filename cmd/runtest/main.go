@@ -37,10 +37,15 @@ func initApp() *cli.App {
 	app.Authors = []*cli.Author{{Name: "Martin Holst Swende"}}
 	app.Usage = "Executes one test against several vms"
 	app.Flags = append(app.Flags, common.VMFlags...)
+	app.Flags = append(app.Flags, common.BlockVMFlags...)
+	app.Flags = append(app.Flags, common.T8nVMFlags...)
 	app.Flags = append(app.Flags, common.SkipTraceFlag)
 	app.Flags = append(app.Flags, common.ThreadFlag)
 	app.Flags = append(app.Flags, common.LocationFlag)
 	app.Flags = append(app.Flags, common.VerbosityFlag)
+	app.Flags = append(app.Flags, common.ContinueFlag, common.MaxFlawsFlag, common.MaxFlawsPerClientFlag, common.DedupFlag)
+	app.Flags = append(app.Flags, common.RegressionFlag, common.MetricsAddrFlag)
+	app.Flags = append(app.Flags, common.NotifyFlag, common.NotifyURLFlag, common.NotifyEventsFlag)
 	app.Action = startFuzzer
 	return app
 }
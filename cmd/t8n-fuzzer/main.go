@@ -0,0 +1,48 @@
+// Copyright 2026 Martin Holst Swende
+// This file is part of the go-evmlab library.
+//
+// The library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the goevmlab library. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/holiman/goevmlab/common"
+	"github.com/holiman/goevmlab/fuzzing"
+	"github.com/urfave/cli/v2"
+)
+
+var app = (&common.SequenceFuzzer{
+	Usage:   "Fuzzer of multi-transaction tests, executed by the t8n tools of the clients",
+	VMFlags: common.T8nVMFlags,
+	MinFork: "London",
+	Engines: fuzzing.SequenceFactoryNames(),
+	Execute: func(c *cli.Context, names []string, fork string, seed int64) error {
+		var engines []common.Engine
+		for _, name := range names {
+			g := fuzzing.NewSeededSequenceGenerator(name, fork, seed)
+			engines = append(engines, common.Engine{Name: name, Generate: g.Generate, Seeded: g})
+		}
+		return common.GenerateAndExecuteT8nTests(c, engines)
+	},
+}).NewApp()
+
+func main() {
+	if err := app.Run(os.Args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
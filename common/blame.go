@@ -0,0 +1,127 @@
+// Copyright 2026 Martin Holst Swende
+// This file is part of the goevmlab library.
+//
+// The library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the goevmlab library. If not, see <http://www.gnu.org/licenses/>.
+
+package common
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/holiman/goevmlab/evms"
+)
+
+// verdict is the outcome of a majority vote among clients which executed the
+// same test.
+type verdict struct {
+	// groups contains the client names, grouped by identical output. The
+	// groups are ordered by size, largest first.
+	groups [][]string
+	// tie is true if there is no single largest group.
+	tie bool
+	// unreproduced is true if the flaw did not reproduce. The groups are
+	// then the clients which originally disagreed.
+	unreproduced bool
+}
+
+// majority returns the clients in the largest group, or nil if there is a tie.
+func (v *verdict) majority() []string {
+	if v.tie || v.unreproduced || len(v.groups) == 0 {
+		return nil
+	}
+	return v.groups[0]
+}
+
+// minority returns the clients outside of the largest group, which are the
+// likely faulty implementations. If there is a tie, nil is returned.
+func (v *verdict) minority() []string {
+	if v.tie || v.unreproduced {
+		return nil
+	}
+	var names []string
+	for _, g := range v.groups[1:] {
+		names = append(names, g...)
+	}
+	return names
+}
+
+func (v *verdict) String() string {
+	if v.unreproduced {
+		var names []string
+		for _, g := range v.groups {
+			names = append(names, g...)
+		}
+		return fmt.Sprintf("flaw did not reproduce (found by: %v)", strings.Join(names, ", "))
+	}
+	if len(v.groups) < 2 {
+		return "all clients agree"
+	}
+	if v.tie {
+		var parts []string
+		for _, g := range v.groups {
+			parts = append(parts, fmt.Sprintf("[%v]", strings.Join(g, ", ")))
+		}
+		return fmt.Sprintf("no majority (tie): %v", strings.Join(parts, " vs "))
+	}
+	return fmt.Sprintf("likely faulty: %v (majority: %v)",
+		strings.Join(v.minority(), ", "), strings.Join(v.majority(), ", "))
+}
+
+// blameKey returns a key identifying the set of blamed clients. If there is
+// a tie, or the flaw did not reproduce, all clients share the blame.
+func (v *verdict) blameKey() string {
+	if v.tie || v.unreproduced {
+		var names []string
+		for _, g := range v.groups {
+			names = append(names, g...)
+		}
+		sort.Strings(names)
+		return "tie:" + strings.Join(names, ",")
+	}
+	names := append([]string(nil), v.minority()...)
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+// vote groups the clients by their output hash, and determines which clients
+// are in the majority. The names and hashes are given in the order the
+// clients reported in.
+func vote(names []string, hashes [][]byte) *verdict {
+	outputs := make([]string, len(hashes))
+	for i, h := range hashes {
+		outputs[i] = string(h)
+	}
+	groups, tie := evms.Vote(outputs)
+	v := &verdict{tie: tie}
+	for _, g := range groups {
+		var group []string
+		for _, i := range g {
+			group = append(group, names[i])
+		}
+		v.groups = append(v.groups, group)
+	}
+	return v
+}
+
+// unreproduced returns the verdict for a flaw which did not reproduce when
+// re-executed: the clients which originally disagreed share the blame.
+func unreproduced(clients []string) *verdict {
+	v := &verdict{unreproduced: true}
+	for _, name := range clients {
+		v.groups = append(v.groups, []string{name})
+	}
+	return v
+}
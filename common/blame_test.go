@@ -0,0 +1,81 @@
+// Copyright 2026 Martin Holst Swende
+// This file is part of the goevmlab library.
+//
+// The library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the goevmlab library. If not, see <http://www.gnu.org/licenses/>.
+
+package common
+
+import (
+	"testing"
+)
+
+func TestVote(t *testing.T) {
+	var (
+		a = []byte{0xa}
+		b = []byte{0xb}
+		c = []byte{0xc}
+	)
+	for i, tc := range []struct {
+		names  []string
+		hashes [][]byte
+		want   string
+	}{
+		{
+			[]string{"geth-0", "besu-0"},
+			[][]byte{a, a},
+			"all clients agree",
+		},
+		{
+			[]string{"besu-0", "geth-0", "nethermind-0", "evmone-0", "revme-0"},
+			[][]byte{b, a, a, a, a},
+			"likely faulty: besu-0 (majority: geth-0, nethermind-0, evmone-0, revme-0)",
+		},
+		{
+			[]string{"besu-0", "geth-0", "nethermind-0", "evmone-0", "revme-0"},
+			[][]byte{b, a, c, a, a},
+			"likely faulty: besu-0, nethermind-0 (majority: geth-0, evmone-0, revme-0)",
+		},
+		{
+			[]string{"geth-0", "besu-0"},
+			[][]byte{a, b},
+			"no majority (tie): [geth-0] vs [besu-0]",
+		},
+		{
+			[]string{"geth-0", "besu-0", "nethermind-0", "evmone-0"},
+			[][]byte{a, b, b, a},
+			"no majority (tie): [geth-0, evmone-0] vs [besu-0, nethermind-0]",
+		},
+	} {
+		if have := vote(tc.names, tc.hashes).String(); have != tc.want {
+			t.Errorf("test %d: have %q want %q", i, have, tc.want)
+		}
+	}
+	// A flaw which does not reproduce blames the clients which found it, jointly
+	for i, tc := range []struct {
+		clients []string
+		want    string
+		key     string
+	}{
+		{[]string{"geth-0", "besu-0"}, "flaw did not reproduce (found by: geth-0, besu-0)", "tie:besu-0,geth-0"},
+		{[]string{"geth-0"}, "flaw did not reproduce (found by: geth-0)", "tie:geth-0"},
+	} {
+		v := unreproduced(tc.clients)
+		if have := v.String(); have != tc.want {
+			t.Errorf("unreproduced %d: have %q want %q", i, have, tc.want)
+		}
+		if have := v.blameKey(); have != tc.key {
+			t.Errorf("unreproduced %d: have key %q want %q", i, have, tc.key)
+		}
+	}
+}
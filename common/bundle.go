@@ -0,0 +1,225 @@
+// Copyright 2026 Martin Holst Swende
+// This file is part of the goevmlab library.
+//
+// The library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the goevmlab library. If not, see <http://www.gnu.org/licenses/>.
+
+package common
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"time"
+)
+
+// A flaw bundle is a self-contained directory, which can be attached to a bug
+// report as is: the test, the raw and canonical output of every client, the
+// report, and a script to re-execute the test. The directory is also packed
+// into a tarball.
+const (
+	bundleInfoFile   = "bundle.json"
+	bundleReportFile = "report.txt"
+	bundleDiffFile   = "diff.txt"
+	bundleReproFile  = "repro.sh"
+)
+
+// bundleClient describes the execution of the test on one client. The file
+// names are relative to the bundle directory.
+type bundleClient struct {
+	Name    string `json:"name"`
+	Kind    string `json:"kind,omitempty"`
+	Binary  string `json:"binary,omitempty"`
+	Version string `json:"version,omitempty"`
+	Command string `json:"command,omitempty"`
+	Output  string `json:"output"`        // the output in the canonical format
+	Raw     string `json:"raw,omitempty"` // the output as emitted by the client
+	Error   string `json:"error,omitempty"`
+
+	// The vm config entry, for clients which cannot be given by a flag
+	Config *vmConfig `json:"config,omitempty"`
+}
+
+// bundleInfo describes a flaw bundle, and is stored as bundle.json.
+type bundleInfo struct {
+	Title    string         `json:"title"`
+	Test     string         `json:"test"`
+	Verdict  string         `json:"verdict"`
+	Goevmlab string         `json:"goevmlab"`
+	Created  time.Time      `json:"created"`
+	Clients  []bundleClient `json:"clients"`
+}
+
+// goevmlabCommit returns the commit goevmlab was built from, as recorded by
+// the go toolchain.
+func goevmlabCommit() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	var revision, modified string
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			revision = s.Value
+		case "vcs.modified":
+			modified = s.Value
+		}
+	}
+	if revision == "" {
+		return fmt.Sprintf("unknown (%v)", info.Main.Version)
+	}
+	if modified == "true" {
+		revision += "-dirty"
+	}
+	return revision
+}
+
+// reproScript returns a shell script which re-executes the test on all the
+// clients, using runtest. The client binaries can be overridden through the
+// environment. The clients which cannot be given by a flag are given by a vm
+// config file, written by the script.
+func (b *bundleInfo) reproScript() string {
+	var (
+		script  = new(strings.Builder)
+		args    []string
+		configs []vmConfig
+	)
+	fmt.Fprintf(script, "#!/bin/sh\n")
+	fmt.Fprintf(script, "# %v: %v\n", b.Title, b.Verdict)
+	fmt.Fprintf(script, "# Found by goevmlab %v\n#\n", b.Goevmlab)
+	for _, c := range b.Clients {
+		fmt.Fprintf(script, "# %v", c.Name)
+		if c.Version != "" {
+			fmt.Fprintf(script, ": %v", c.Version)
+		}
+		fmt.Fprintf(script, "\n#   %v\n", c.Command)
+	}
+	fmt.Fprintf(script, "#\n# Re-executes the test on the clients above. The runtest binary of goevmlab\n")
+	fmt.Fprintf(script, "# is used, override with RUNTEST=<path>.\n\n")
+	fmt.Fprintf(script, "cd \"$(dirname \"$0\")\" || exit 1\n")
+	for i, c := range b.Clients {
+		if c.Config != nil {
+			configs = append(configs, *c.Config)
+			continue
+		}
+		if c.Kind == "" {
+			fmt.Fprintf(script, "# %v can not be executed by runtest, use the command above\n", c.Name)
+			continue
+		}
+		arg := "--gethembedded"
+		if c.Binary != "" {
+			variable := fmt.Sprintf("bin%d", i)
+			fmt.Fprintf(script, "%v=${%v:-%v} # %v\n", variable, strings.ToUpper(variable), shellQuote(c.Binary), c.Name)
+			arg = fmt.Sprintf("--%v \"$%v\"", c.Kind, variable)
+		}
+		if !slices.Contains(args, arg) {
+			args = append(args, arg)
+		}
+	}
+	fmt.Fprintf(script, "outdir=$(mktemp -d)\n")
+	fmt.Fprintf(script, "trap 'rm -rf \"$outdir\"' EXIT\n")
+	if len(configs) > 0 {
+		data, _ := json.MarshalIndent(vmConfigFile{VMs: configs}, "", "  ")
+		fmt.Fprintf(script, "cat > \"$outdir/vms.json\" <<'EOF'\n%s\nEOF\n", data)
+		args = append(args, "--vms \"$outdir/vms.json\"")
+	}
+	fmt.Fprintf(script, "\"${RUNTEST:-runtest}\" --outdir \"$outdir\" %v %v\n", strings.Join(args, " "), shellQuote(b.Test))
+	return script.String()
+}
+
+// writeBundle stores the report, the diff, the bundle description and the
+// reproduction script in the bundle directory, and packs the directory into
+// a tarball next to it.
+func writeBundle(dir string, info *bundleInfo, report, diff string) error {
+	data, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return err
+	}
+	for _, f := range []struct {
+		name string
+		data string
+		perm os.FileMode
+	}{
+		{bundleInfoFile, string(data) + "\n", 0644},
+		{bundleReportFile, report, 0644},
+		{bundleDiffFile, diff, 0644},
+		{bundleReproFile, info.reproScript(), 0755},
+	} {
+		if err := os.WriteFile(filepath.Join(dir, f.name), []byte(f.data), f.perm); err != nil {
+			return err
+		}
+	}
+	return tarBundle(dir)
+}
+
+// bundleTarball returns the path of the tarball of the bundle directory.
+func bundleTarball(dir string) string {
+	return filepath.Clean(dir) + ".tar.gz"
+}
+
+// tarBundle packs the bundle directory into a gzipped tarball, where the
+// files are placed within a directory of the same name as the bundle.
+func tarBundle(dir string) error {
+	f, err := os.Create(bundleTarball(dir))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var (
+		zw   = gzip.NewWriter(f)
+		tw   = tar.NewWriter(zw)
+		base = filepath.Base(dir)
+	)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		fi, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		hdr, err := tar.FileInfoHeader(fi, "")
+		if err != nil {
+			return err
+		}
+		hdr.Name = base + "/" + entry.Name()
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return err
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := tw.Write(data); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	return f.Close()
+}
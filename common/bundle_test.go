@@ -0,0 +1,193 @@
+// Copyright 2026 Martin Holst Swende
+// This file is part of the goevmlab library.
+//
+// The library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the goevmlab library. If not, see <http://www.gnu.org/licenses/>.
+
+package common
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/holiman/goevmlab/evms"
+)
+
+func TestFlawBundle(t *testing.T) {
+	meta := &testMeta{
+		outdir: t.TempDir(),
+		vms:    []evms.Evm{evms.NewEmbeddedGethVM("geth-a"), evms.NewEmbeddedGethVM("geth-b")},
+	}
+	flaw := &consensusFlaw{
+		file:    filepath.Join("..", "evms", "testdata", "cases", "negative_refund.json"),
+		clients: []string{"geth-a", "geth-b"},
+	}
+	dir, testfile, err := meta.newFlawBundle(1, flaw.file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A second flaw of the same name does not overwrite the first one
+	if other, _, err := meta.newFlawBundle(1, flaw.file); err != nil || other == dir {
+		t.Fatalf("bundle overwritten: %v %v", other, err)
+	}
+	if _, err := meta.handleConsensusFlaw(&consensusFlaw{file: testfile, clients: flaw.clients}, dir); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, bundleInfoFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var info bundleInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		t.Fatal(err)
+	}
+	if info.Test != "negative_refund.json" || info.Goevmlab == "" || len(info.Clients) != 2 {
+		t.Fatalf("wrong bundle info: %s", data)
+	}
+	for _, c := range info.Clients {
+		if c.Kind != "geth" || c.Version == "" || c.Command == "" || c.Raw == "" {
+			t.Errorf("incomplete client info: %+v", c)
+		}
+		raw, err := os.ReadFile(filepath.Join(dir, c.Raw))
+		if err != nil || !strings.Contains(string(raw), `"stateRoot"`) {
+			t.Errorf("missing raw output of %v: %v", c.Name, err)
+		}
+	}
+	// The tarball holds the whole bundle
+	f, err := os.Open(bundleTarball(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var files []string
+	for tr := tar.NewReader(zr); ; {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, hdr.Name)
+	}
+	base := filepath.Base(dir)
+	for _, want := range []string{"negative_refund.json", bundleInfoFile, bundleReportFile, bundleDiffFile,
+		bundleReproFile, "geth-a-output.jsonl", "geth-a-raw.txt", "geth-b-output.jsonl", "geth-b-raw.txt"} {
+		if !slices.Contains(files, base+"/"+want) {
+			t.Errorf("missing %v in tarball, have %v", want, files)
+		}
+	}
+	// The script re-executes the test with runtest, from within the bundle
+	if _, err := exec.LookPath("sh"); err != nil {
+		return
+	}
+	logfile := filepath.Join(t.TempDir(), "invocations")
+	runtest := filepath.Join(t.TempDir(), "runtest")
+	if err := os.WriteFile(runtest, fmt.Appendf(nil, "#!/bin/sh\necho \"$@\" > %v\n", logfile), 0755); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(filepath.Join(dir, bundleReproFile))
+	cmd.Env = append(os.Environ(), "RUNTEST="+runtest)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("script failed: %v\n%s", err, out)
+	}
+	data, err = os.ReadFile(logfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have := strings.TrimSpace(string(data)); !strings.HasSuffix(have, " --gethembedded negative_refund.json") {
+		t.Errorf("wrong runtest invocation: %v", have)
+	}
+}
+
+// TestReproScriptConfig checks that the clients which cannot be given by a
+// flag are given to runtest by a vm config file.
+func TestReproScriptConfig(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	custom, err := evms.NewCustomVM("custom", []string{"evm", "run", evms.PathPlaceholder}, nil, evms.OutputProfile{DropStops: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	custom.(evms.CommandOptions).SetCommandOptions([]string{"--fast"}, []string{"FOO=bar"})
+	docker, err := evms.NewDockerVM("docker", evms.NewGethBatchVM, evms.DockerConfig{Image: "holiman/geth", Binary: "/evm"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &bundleInfo{Test: "test.json"}
+	for _, vm := range []evms.Evm{evms.NewGethEVM("/bin/evm", "geth"), custom, docker} {
+		client := bundleClient{Name: vm.Name(), Config: configOf(vm)}
+		if info, ok := vm.(evms.ClientInfo); ok {
+			client.Kind, client.Binary = info.Kind(), info.Binary()
+		}
+		info.Clients = append(info.Clients, client)
+	}
+	dir := t.TempDir()
+	script := filepath.Join(dir, bundleReproFile)
+	if err := os.WriteFile(script, []byte(info.reproScript()), 0755); err != nil {
+		t.Fatal(err)
+	}
+	var (
+		logfile = filepath.Join(t.TempDir(), "invocations")
+		config  = filepath.Join(t.TempDir(), "vms.json")
+		runtest = filepath.Join(t.TempDir(), "runtest")
+	)
+	fake := fmt.Sprintf("#!/bin/sh\necho \"$@\" > %v\nwhile [ $# -gt 0 ]; do [ \"$1\" = --vms ] && cp \"$2\" %v; shift; done\n", logfile, config)
+	if err := os.WriteFile(runtest, []byte(fake), 0755); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(script)
+	cmd.Env = append(os.Environ(), "RUNTEST="+runtest)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("script failed: %v\n%s", err, out)
+	}
+	data, err := os.ReadFile(logfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have := string(data); !strings.Contains(have, "--geth /bin/evm --vms ") || !strings.HasSuffix(have, " test.json\n") {
+		t.Errorf("wrong runtest invocation: %v", have)
+	}
+	vms, err := loadVMConfig(config, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vms) != 2 {
+		t.Fatalf("wrong number of vms: %d", len(vms))
+	}
+	if have, want := vms[0].(*evms.CustomVM).Config(), custom.(*evms.CustomVM).Config(); fmt.Sprint(have) != fmt.Sprint(want) {
+		t.Errorf("wrong custom vm\nhave: %+v\nwant: %+v", have, want)
+	}
+	conf := vms[1].(*evms.DockerVM).Config()
+	if abs, _ := filepath.Abs("."); conf.Image != "holiman/geth" || conf.Binary != "/evm" || !slices.Equal(conf.Mounts, []string{abs}) {
+		t.Errorf("wrong docker vm: %+v", conf)
+	}
+	if vms[1].(evms.ClientInfo).Kind() != "geth" {
+		t.Errorf("wrong docker vm kind: %v", vms[1].(evms.ClientInfo).Kind())
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright 2026 Martin Holst Swende
+// This file is part of the goevmlab library.
+//
+// The library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the goevmlab library. If not, see <http://www.gnu.org/licenses/>.
+
+package common
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/holiman/goevmlab/evms"
+)
+
+// coverageFile is the name of the coverage file, stored in the output directory,
+// or the session directory.
+const coverageFile = "coverage.json"
+
+// addCoverage merges the coverage of an executed test.
+func (meta *testMeta) addCoverage(cov *evms.Coverage) {
+	meta.coverageMu.Lock()
+	defer meta.coverageMu.Unlock()
+	meta.coverage.Merge(cov)
+}
+
+// stateDir returns the directory where the state of the fuzzer is kept: the
+// session directory, or the output directory if no session is kept.
+func (meta *testMeta) stateDir() string {
+	if meta.session != nil {
+		return meta.session.dir
+	}
+	return meta.outdir
+}
+
+// loadCoverage loads the coverage of earlier runs, if any.
+func (meta *testMeta) loadCoverage() error {
+	data, err := os.ReadFile(filepath.Join(meta.stateDir(), coverageFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	meta.coverageMu.Lock()
+	defer meta.coverageMu.Unlock()
+	if err := json.Unmarshal(data, meta.coverage); err != nil {
+		return fmt.Errorf("corrupt coverage file: %w", err)
+	}
+	return nil
+}
+
+// saveCoverage writes the coverage to the output directory, if any was
+// collected.
+func (meta *testMeta) saveCoverage() {
+	meta.coverageMu.Lock()
+	if meta.coverage.Tests == 0 {
+		meta.coverageMu.Unlock()
+		return
+	}
+	data, err := json.MarshalIndent(meta.coverage, "", "  ")
+	meta.coverageMu.Unlock()
+	if err != nil {
+		log.Error("Failed encoding coverage", "err", err)
+		return
+	}
+	path := filepath.Join(meta.stateDir(), coverageFile)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		log.Error("Failed writing coverage", "path", path, "err", err)
+		return
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		log.Error("Failed writing coverage", "path", path, "err", err)
+	}
+}
+
+// coverageSummary returns a summary of the coverage, or the empty string if
+// no coverage was collected (e.g. when executing without tracing).
+func (meta *testMeta) coverageSummary() string {
+	meta.coverageMu.Lock()
+	defer meta.coverageMu.Unlock()
+	if meta.coverage.Tests == 0 {
+		return ""
+	}
+	return meta.coverage.Summary()
+}
@@ -0,0 +1,64 @@
+// Copyright 2026 Martin Holst Swende
+// This file is part of the goevmlab library.
+//
+// The library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the goevmlab library. If not, see <http://www.gnu.org/licenses/>.
+
+package common
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// storeCrash saves a test which made a client crash or hang. The test is
+// copied to crash-<name>.json (or hang-<name>.json) in the output directory,
+// and a report with the command line and the partial output of the client is
+// written to crash-<name>.<vm>.txt (or hang-<name>.<vm>.txt).
+func (meta *testMeta) storeCrash(t *task) {
+	var (
+		vmName = meta.vms[t.vmIdx].Name()
+		kind   = "crash"
+	)
+	meta.numCrashes.Add(1)
+	meta.session.countCrash(t.file)
+	if t.crash.Timeout {
+		kind = "hang"
+	}
+	name := fmt.Sprintf("%v-%v", kind, strings.TrimSuffix(filepath.Base(t.file), ".json"))
+	dst := filepath.Join(meta.outdir, name+".json")
+	if err := Copy(t.file, dst); err != nil {
+		log.Error("Error copying file", "file", t.file, "err", err)
+		return
+	}
+	report := new(strings.Builder)
+	fmt.Fprintf(report, "Client %v: %v\n", kind, vmName)
+	fmt.Fprintf(report, "Testcase: %v\n", dst)
+	fmt.Fprintf(report, "Command: %v\n", t.command)
+	fmt.Fprintf(report, "Error: %v\n", t.crash)
+	fmt.Fprintf(report, "Output lines: %d\n", t.nLines)
+	summary := report.String()
+	fmt.Fprintf(report, "\nPartial output (last %d bytes):\n", len(t.partialOutput))
+	report.Write(t.partialOutput)
+	reportFile := filepath.Join(meta.outdir, fmt.Sprintf("%v.%v.txt", name, vmName))
+	if err := os.WriteFile(reportFile, []byte(report.String()), 0644); err != nil {
+		log.Error("Failed writing crash report", "err", err)
+	}
+	log.Warn("Stored client "+kind, "vm", vmName, "file", dst, "report", reportFile,
+		"crashes", meta.numCrashes.Load())
+	meta.notifier.notify(EventCrash, fmt.Sprintf("Client %v: %v", kind, vmName), summary)
+}
@@ -0,0 +1,49 @@
+// Copyright 2026 Martin Holst Swende
+// This file is part of the goevmlab library.
+//
+// The library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the goevmlab library. If not, see <http://www.gnu.org/licenses/>.
+
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/holiman/goevmlab/evms"
+)
+
+// TestStoreCrash checks that the stored crash report includes the tail of the
+// output of the client.
+func TestStoreCrash(t *testing.T) {
+	meta := &testMeta{
+		outdir: t.TempDir(),
+		vms:    []evms.Evm{evms.NewGethEVM("evm", "geth-0")},
+	}
+	file := filepath.Join("..", "evms", "testdata", "cases", "negative_refund.json")
+	meta.storeCrash(&task{
+		file:          file,
+		command:       "evm statetest",
+		crash:         &evms.CrashError{Cmd: "evm statetest"},
+		partialOutput: []byte("panic: the last words\n"),
+	})
+	data, err := os.ReadFile(filepath.Join(meta.outdir, "crash-negative_refund.geth-0.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "Client crash: geth-0") || !strings.HasSuffix(string(data), "panic: the last words\n") {
+		t.Fatalf("wrong report:\n%s", data)
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright 2026 Martin Holst Swende
+// This file is part of the goevmlab library.
+//
+// The library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the goevmlab library. If not, see <http://www.gnu.org/licenses/>.
+
+package common
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/holiman/goevmlab/evms"
+	"github.com/holiman/goevmlab/fuzzing"
+)
+
+// flawIndexFile is the name of the flaw index, stored in the output directory.
+const flawIndexFile = "flaw-index.json"
+
+// flawSignature identifies a class of consensus flaws. Flaws with the same
+// signature are most likely caused by the same underlying bug.
+type flawSignature struct {
+	Op      string `json:"op"`      // the diverging opcode
+	Field   string `json:"field"`   // the field which differed
+	Clients string `json:"clients"` // the dissenting clients
+	Fork    string `json:"fork"`
+}
+
+func (s flawSignature) String() string {
+	return fmt.Sprintf("%v/%v/%v/%v", s.Op, s.Field, s.Clients, s.Fork)
+}
+
+// newFlawSignature creates the signature of a flaw. The divergence may be nil,
+// if the flaw could not be reproduced.
+func newFlawSignature(testfile string, v *verdict, div *evms.Divergence) flawSignature {
+	sig := flawSignature{
+		Op:      "unknown",
+		Field:   "unknown",
+		Clients: v.blameKey(),
+		Fork:    "unknown",
+	}
+	if div != nil {
+		sig.Field = div.Field
+		if div.OpName != "" {
+			sig.Op = div.OpName
+		}
+	}
+	if gst, err := fuzzing.FromGeneralStateTest(testfile); err == nil {
+		var forks []string
+		for _, st := range *gst {
+			for fork := range st.Post {
+				forks = append(forks, fork)
+			}
+		}
+		if len(forks) > 0 {
+			sort.Strings(forks)
+			sig.Fork = strings.Join(forks, ",")
+		}
+	}
+	return sig
+}
+
+// flawIndexEntry counts the occurrences of one flaw signature.
+type flawIndexEntry struct {
+	Signature flawSignature `json:"signature"`
+	Count     int           `json:"count"`
+	First     string        `json:"first"` // location of the first stored occurrence
+	FirstSeen time.Time     `json:"firstSeen"`
+	LastSeen  time.Time     `json:"lastSeen"`
+}
+
+// flawIndex is a persistent index of known flaw signatures.
+type flawIndex struct {
+	path  string
+	Flaws map[string]*flawIndexEntry `json:"flaws"`
+}
+
+// loadFlawIndex loads the flaw index from the given path. A missing file
+// yields an empty index.
+func loadFlawIndex(path string) (*flawIndex, error) {
+	index := &flawIndex{
+		path:  path,
+		Flaws: make(map[string]*flawIndexEntry),
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return index, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, index); err != nil {
+		return nil, fmt.Errorf("invalid flaw index %v: %w", path, err)
+	}
+	if index.Flaws == nil {
+		index.Flaws = make(map[string]*flawIndexEntry)
+	}
+	log.Info("Loaded flaw index", "path", path, "signatures", len(index.Flaws))
+	return index, nil
+}
+
+// known returns whether the signature has been seen before.
+func (index *flawIndex) known(sig flawSignature) bool {
+	return index.Flaws[sig.String()] != nil
+}
+
+// add records an occurrence of the signature, and returns the number of times
+// it has been seen. The location is that of the stored flaw, or empty if the
+// flaw was not stored; the first stored location is kept in the index. The
+// index is flushed to disk on every call.
+func (index *flawIndex) add(sig flawSignature, location string) int {
+	var (
+		key   = sig.String()
+		now   = time.Now()
+		entry = index.Flaws[key]
+	)
+	if entry == nil {
+		entry = &flawIndexEntry{
+			Signature: sig,
+			FirstSeen: now,
+		}
+		index.Flaws[key] = entry
+	}
+	if entry.First == "" {
+		entry.First = location
+	}
+	entry.Count++
+	entry.LastSeen = now
+	if err := index.flush(); err != nil {
+		log.Error("Failed writing flaw index", "path", index.path, "err", err)
+	}
+	return entry.Count
+}
+
+// flush writes the index to disk.
+func (index *flawIndex) flush() error {
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := index.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, index.path)
+}
@@ -0,0 +1,54 @@
+// Copyright 2026 Martin Holst Swende
+// This file is part of the goevmlab library.
+//
+// The library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the goevmlab library. If not, see <http://www.gnu.org/licenses/>.
+
+package common
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestFlawIndex(t *testing.T) {
+	path := filepath.Join(t.TempDir(), flawIndexFile)
+	index, err := loadFlawIndex(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		a = flawSignature{Op: "SSTORE", Field: "gas", Clients: "besu-0", Fork: "Prague"}
+		b = flawSignature{Op: "SSTORE", Field: "gas", Clients: "besu-0", Fork: "Osaka"}
+	)
+	if have := index.add(a, "flaw-0001"); have != 1 {
+		t.Fatalf("have %d, want %d", have, 1)
+	}
+	if have := index.add(b, "flaw-0002"); have != 1 {
+		t.Fatalf("have %d, want %d", have, 1)
+	}
+	if have := index.add(a, "flaw-0003"); have != 2 {
+		t.Fatalf("have %d, want %d", have, 2)
+	}
+	// Reload from disk
+	index, err = loadFlawIndex(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have := index.add(a, "flaw-0004"); have != 3 {
+		t.Fatalf("have %d, want %d", have, 3)
+	}
+	if have, want := index.Flaws[a.String()].First, "flaw-0001"; have != want {
+		t.Fatalf("have %v, want %v", have, want)
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright 2026 Martin Holst Swende
+// This file is part of the goevmlab library.
+//
+// The library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the goevmlab library. If not, see <http://www.gnu.org/licenses/>.
+
+package common
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/holiman/goevmlab/evms"
+)
+
+// consensusFlaw is a test which made the clients disagree. Only a few clients
+// execute each test, so no verdict is formed until the flaw is re-executed on
+// all clients.
+type consensusFlaw struct {
+	file       string
+	clients    []string         // the clients which executed the test
+	divergence *evms.Divergence // divergence of the raw outputs, only set in rawdebug-mode
+}
+
+// flawReport is the outcome of re-executing a consensus flaw on all clients.
+type flawReport struct {
+	title      string
+	text       string
+	diff       string
+	bundle     *bundleInfo
+	verdict    *verdict         // the verdict of the re-execution on all clients
+	divergence *evms.Divergence // nil if the flaw could not be reproduced
+	signature  flawSignature
+	duplicate  bool // true if the signature was already known
+}
+
+// archiveConsensusFlaw is used in continue-mode. It re-executes the flaw on
+// all clients, and stores it in a bundle of its own: the test, the output of
+// every client, and the report. The limits on the number of stored flaws are
+// applied to the verdict of the re-execution: flaws beyond the limits are
+// counted in the flaw index, but not stored, and the index only refers to the
+// bundles which are kept.
+// This method must only be called when the vms are idle.
+func (meta *testMeta) archiveConsensusFlaw(flaw *consensusFlaw) {
+	n := meta.numFlaws.Add(1)
+	meta.session.countFlaw(flaw.file)
+	dir, testfile, err := meta.newFlawBundle(n, flaw.file)
+	if err != nil {
+		log.Error("Failed creating flaw bundle", "file", flaw.file, "err", err)
+		return
+	}
+	report, err := meta.reexecuteFlaw(&consensusFlaw{file: testfile, clients: flaw.clients, divergence: flaw.divergence}, dir)
+	if err != nil {
+		log.Error("Failed re-executing consensus flaw", "file", flaw.file, "err", err)
+		_ = os.RemoveAll(dir)
+		return
+	}
+	if report.duplicate {
+		// Only the counter in the index is bumped.
+		meta.indexFlaw(report, "")
+		_ = os.RemoveAll(dir)
+		return
+	}
+	key := report.verdict.blameKey()
+	if meta.maxFlaws > 0 && meta.numStoredFlaws >= meta.maxFlaws {
+		log.Warn("Consensus flaw not stored, limit reached", "file", flaw.file,
+			"verdict", report.verdict, "limit", meta.maxFlaws)
+		meta.indexFlaw(report, "")
+		_ = os.RemoveAll(dir)
+		return
+	}
+	if meta.maxFlawsPerBlame > 0 && meta.blameCounts[key] >= meta.maxFlawsPerBlame {
+		log.Warn("Consensus flaw not stored, per-client limit reached", "file", flaw.file,
+			"verdict", report.verdict, "limit", meta.maxFlawsPerBlame)
+		meta.indexFlaw(report, "")
+		_ = os.RemoveAll(dir)
+		return
+	}
+	meta.indexFlaw(report, dir)
+	meta.publishFlaw(report, dir)
+	meta.numStoredFlaws++
+	meta.blameCounts[key]++
+	log.Warn("Stored consensus flaw", "dir", dir, "verdict", report.verdict,
+		"stored", meta.numStoredFlaws, "found", n)
+}
+
+// newFlawBundle creates the bundle directory for the n:th flaw, and copies the
+// test into it. It returns the directory and the path of the copied test.
+func (meta *testMeta) newFlawBundle(n uint64, file string) (string, string, error) {
+	name := strings.TrimSuffix(filepath.Base(file), ".json")
+	prefix := "flaw"
+	if meta.regression != nil {
+		prefix = "regression"
+	}
+	dir := filepath.Join(meta.outdir, fmt.Sprintf("%v-%04d-%v", prefix, n, name))
+	// The flaw counter restarts with the fuzzer, don't overwrite earlier flaws
+	for i := 2; ; i++ {
+		err := os.Mkdir(dir, 0755)
+		if err == nil {
+			break
+		}
+		if !os.IsExist(err) {
+			return "", "", err
+		}
+		dir = filepath.Join(meta.outdir, fmt.Sprintf("%v-%04d-%v-%d", prefix, n, name, i))
+	}
+	testfile := filepath.Join(dir, filepath.Base(file))
+	if err := Copy(file, testfile); err != nil {
+		os.RemoveAll(dir)
+		return "", "", err
+	}
+	return dir, testfile, nil
+}
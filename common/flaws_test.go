@@ -0,0 +1,133 @@
+// Copyright 2026 Martin Holst Swende
+// This file is part of the goevmlab library.
+//
+// The library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the goevmlab library. If not, see <http://www.gnu.org/licenses/>.
+
+package common
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/holiman/goevmlab/evms"
+)
+
+// rootVM returns a vm which outputs the given stateroot for any test.
+func rootVM(t *testing.T, name, root string) evms.Evm {
+	t.Helper()
+	cmd := []string{"sh", "-c", fmt.Sprintf(`echo '{"stateRoot":"%v"}'`, root), evms.PathPlaceholder}
+	vm, err := evms.NewCustomVM(name, cmd, nil, evms.OutputProfile{Stream: "stdout"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return vm
+}
+
+// TestFlawLimits checks that the limits on stored flaws apply to the verdict
+// of the re-execution on all clients, and that flaws beyond the limits are
+// still counted in the flaw index.
+func TestFlawLimits(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	var (
+		file = filepath.Join("..", "evms", "testdata", "cases", "negative_refund.json")
+		vms  = []evms.Evm{rootVM(t, "good-a", "0xaa"), rootVM(t, "good-b", "0xaa"), rootVM(t, "bad", "0xbb")}
+	)
+	// The fuzzer finds the faulty client with different partners, but the
+	// re-execution blames the same client.
+	meta := &testMeta{
+		outdir:           t.TempDir(),
+		vms:              vms,
+		maxFlawsPerBlame: 1,
+		blameCounts:      make(map[string]int),
+	}
+	meta.archiveConsensusFlaw(&consensusFlaw{file: file, clients: []string{"good-a", "bad"}})
+	meta.archiveConsensusFlaw(&consensusFlaw{file: file, clients: []string{"good-b", "bad"}})
+	if meta.numStoredFlaws != 1 || meta.blameCounts["bad"] != 1 {
+		t.Fatalf("wrong flaws stored: %d, blame counts %v", meta.numStoredFlaws, meta.blameCounts)
+	}
+	// Duplicates of a stored flaw are counted, also beyond the limit
+	index, err := loadFlawIndex(filepath.Join(t.TempDir(), flawIndexFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	meta = &testMeta{
+		outdir:      t.TempDir(),
+		vms:         vms,
+		maxFlaws:    1,
+		blameCounts: make(map[string]int),
+		flawIndex:   index,
+	}
+	for range 3 {
+		meta.archiveConsensusFlaw(&consensusFlaw{file: file, clients: []string{"good-a", "bad"}})
+	}
+	if meta.numStoredFlaws != 1 || meta.numFlaws.Load() != 3 {
+		t.Fatalf("wrong flaws stored: %d of %d", meta.numStoredFlaws, meta.numFlaws.Load())
+	}
+	for _, entry := range index.Flaws {
+		if entry.Count != 3 || entry.Signature.Clients != "bad" {
+			t.Errorf("wrong index entry: %+v", entry)
+		}
+		if _, err := os.Stat(entry.First); err != nil {
+			t.Errorf("index refers to a missing bundle: %v", err)
+		}
+	}
+	// A flaw beyond the limit is counted, but the index doesn't refer to the
+	// removed bundle
+	index, err = loadFlawIndex(filepath.Join(t.TempDir(), flawIndexFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	meta = &testMeta{
+		outdir:         t.TempDir(),
+		vms:            vms,
+		maxFlaws:       1,
+		numStoredFlaws: 1,
+		blameCounts:    make(map[string]int),
+		flawIndex:      index,
+	}
+	meta.archiveConsensusFlaw(&consensusFlaw{file: file, clients: []string{"good-a", "bad"}})
+	for _, entry := range index.Flaws {
+		if entry.Count != 1 || entry.First != "" {
+			t.Errorf("wrong index entry: %+v", entry)
+		}
+	}
+	if entries, _ := os.ReadDir(meta.outdir); len(entries) != 0 {
+		t.Errorf("flaw stored beyond the limit: %v", entries)
+	}
+}
+
+// TestFlawBundleError checks that a bundle which can't be written is reported
+// as an error.
+func TestFlawBundleError(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	var (
+		file = filepath.Join("..", "evms", "testdata", "cases", "negative_refund.json")
+		meta = &testMeta{
+			outdir:      t.TempDir(),
+			vms:         []evms.Evm{rootVM(t, "a", "0xaa"), rootVM(t, "b", "0xbb")},
+			blameCounts: make(map[string]int),
+		}
+		missing = filepath.Join(meta.outdir, "missing")
+	)
+	if _, err := meta.reexecuteFlaw(&consensusFlaw{file: file, clients: []string{"a", "b"}}, missing); err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2026 Martin Holst Swende
+// This file is part of the goevmlab library.
+//
+// The library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the goevmlab library. If not, see <http://www.gnu.org/licenses/>.
+
+package common
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// vmMetrics maps the keys of the vm stats to metric names and help texts.
+var vmMetrics = map[string][2]string{
+	"execSpeed": {"goevmlab_vm_exec_seconds_avg", "Average execution time of a test, per vm"},
+	"longest":   {"goevmlab_vm_exec_seconds_max", "Longest execution time of a test, per vm"},
+	"count":     {"goevmlab_vm_execs_total", "Number of tests executed, per vm"},
+}
+
+// labelEscaper escapes label values, as per the Prometheus text format.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// writeMetric writes a metric without labels.
+func writeMetric(w io.Writer, name, kind, help string, value float64) {
+	fmt.Fprintf(w, "# HELP %v %v\n# TYPE %v %v\n%v %v\n", name, help, name, kind, name, value)
+}
+
+// writeMetrics writes the fuzzer statistics in the Prometheus text format.
+func (meta *testMeta) writeMetrics(w io.Writer, elapsed time.Duration) {
+	n := meta.numTests.Load()
+	writeMetric(w, "goevmlab_tests_total", "counter", "Number of tests executed", float64(n))
+	writeMetric(w, "goevmlab_tests_per_second", "gauge", "Average number of tests executed per second",
+		float64(n)/max(elapsed.Seconds(), 1e-9))
+	writeMetric(w, "goevmlab_uptime_seconds", "gauge", "Time since the fuzzer started", elapsed.Seconds())
+	writeMetric(w, "goevmlab_slow_tests_total", "counter", "Number of tests deemed slow", float64(meta.numSlow.Load()))
+	writeMetric(w, "goevmlab_consensus_flaws_total", "counter", "Number of consensus flaws found", float64(meta.numFlaws.Load()))
+	writeMetric(w, "goevmlab_crashes_total", "counter", "Number of client crashes and timeouts", float64(meta.numCrashes.Load()))
+	writeMetric(w, "goevmlab_trace_length_avg", "gauge", "Average number of lines in a trace", traceLengthSA.Avg())
+
+	// The vm stats are key/value pairs, as used for logging
+	values := make(map[string][]string)
+	for _, vm := range meta.vms {
+		stats := vm.Stats()
+		for i := 0; i+1 < len(stats); i += 2 {
+			key, _ := stats[i].(string)
+			if _, ok := vmMetrics[key]; !ok {
+				continue
+			}
+			var v float64
+			switch val := stats[i+1].(type) {
+			case time.Duration:
+				v = val.Seconds()
+			case uint64:
+				v = float64(val)
+			default:
+				continue
+			}
+			values[key] = append(values[key], fmt.Sprintf("%v{vm=\"%v\"} %v", vmMetrics[key][0], labelEscaper.Replace(vm.Name()), v))
+		}
+	}
+	for _, key := range []string{"execSpeed", "longest", "count"} {
+		if len(values[key]) == 0 {
+			continue
+		}
+		name, help := vmMetrics[key][0], vmMetrics[key][1]
+		kind := "gauge"
+		if strings.HasSuffix(name, "_total") {
+			kind = "counter"
+		}
+		fmt.Fprintf(w, "# HELP %v %v\n# TYPE %v %v\n%v\n", name, help, name, kind, strings.Join(values[key], "\n"))
+	}
+}
+
+// serveMetrics starts an HTTP listener on the given address, which serves the
+// fuzzer statistics at /metrics. It returns the address listened on, and a
+// function which shuts the listener down.
+func (meta *testMeta) serveMetrics(addr string, start time.Time) (net.Addr, func(), error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, nil, err
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		meta.writeMetrics(w, time.Since(start))
+	})
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error("Metrics listener failed", "err", err)
+		}
+	}()
+	log.Info("Serving metrics", "url", fmt.Sprintf("http://%v/metrics", listener.Addr()))
+	return listener.Addr(), func() { server.Close() }, nil
+}
@@ -0,0 +1,74 @@
+// Copyright 2026 Martin Holst Swende
+// This file is part of the goevmlab library.
+//
+// The library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the goevmlab library. If not, see <http://www.gnu.org/licenses/>.
+
+package common
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/holiman/goevmlab/evms"
+)
+
+func TestMetrics(t *testing.T) {
+	vm := evms.NewEmbeddedGethVM(`geth "embedded"`)
+	if _, err := vm.RunStateTest(filepath.Join("..", "evms", "testdata", "cases", "negative_refund.json"), io.Discard, false); err != nil {
+		t.Fatal(err)
+	}
+	meta := &testMeta{vms: []evms.Evm{vm}}
+	meta.numTests.Store(20)
+	meta.numFlaws.Store(2)
+	meta.numSlow.Store(1)
+
+	out := new(strings.Builder)
+	meta.writeMetrics(out, 10*time.Second)
+	for _, want := range []string{
+		"# TYPE goevmlab_tests_total counter\ngoevmlab_tests_total 20\n",
+		"goevmlab_tests_per_second 2\n",
+		"goevmlab_slow_tests_total 1\n",
+		"goevmlab_consensus_flaws_total 2\n",
+		"# TYPE goevmlab_vm_execs_total counter\ngoevmlab_vm_execs_total{vm=\"geth \\\"embedded\\\"\"} 1\n",
+		"goevmlab_vm_exec_seconds_avg{vm=",
+		"goevmlab_vm_exec_seconds_max{vm=",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("missing %q in metrics:\n%v", want, out)
+		}
+	}
+	// The same is served over HTTP
+	addr, stop, err := meta.serveMetrics("127.0.0.1:0", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+	res, err := http.Get(fmt.Sprintf("http://%v/metrics", addr))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	body, _ := io.ReadAll(res.Body)
+	if ct := res.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("wrong content type: %v", ct)
+	}
+	if !strings.Contains(string(body), "goevmlab_tests_total 20\n") {
+		t.Errorf("wrong metrics served:\n%s", body)
+	}
+}
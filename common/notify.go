@@ -0,0 +1,363 @@
+// Copyright 2026 Martin Holst Swende
+// This file is part of the goevmlab library.
+//
+// The library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the goevmlab library. If not, see <http://www.gnu.org/licenses/>.
+
+package common
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/urfave/cli/v2"
+)
+
+// Event is the kind of event a notification is sent for.
+type Event string
+
+const (
+	EventStart    Event = "start"    // the fuzzer started
+	EventFlaw     Event = "flaw"     // a consensus flaw was found
+	EventCrash    Event = "crash"    // a client crashed or timed out
+	EventShutdown Event = "shutdown" // the fuzzer is exiting
+)
+
+// Notification is the message sent to a notifier.
+type Notification struct {
+	Event   Event     `json:"event"`
+	Title   string    `json:"title"`
+	Message string    `json:"message"`
+	Host    string    `json:"host"`
+	Time    time.Time `json:"time"`
+}
+
+// Notifier is a backend which delivers notifications, e.g. to a chat or mail.
+type Notifier interface {
+	Notify(n *Notification) error
+}
+
+var notifyClient = &http.Client{Timeout: 10 * time.Second}
+
+const (
+	notifyQueueSize = 16               // notifications queued for delivery before dropping
+	notifyDrainWait = 45 * time.Second // time allowed at shutdown to deliver the queue
+)
+
+// NewNotifier creates a notifier from a URL, where the scheme selects the
+// backend:
+//
+//	ntfy://<topic>                                 ntfy.sh (or ntfy+https://<server>/<topic>)
+//	webhook+https://<host>/<path>                  POST of the notification as JSON
+//	slack+https://hooks.slack.com/services/<id>    Slack-compatible incoming webhook
+//	matrix+https://<homeserver>/<room>?access_token=<token>
+//	smtp://[user:pass@]<host>:<port>?from=<addr>&to=<addr>[,<addr>]
+//	exec:<command>                                 command, with the notification as JSON on stdin
+func NewNotifier(rawurl string) (Notifier, error) {
+	scheme, rest, ok := strings.Cut(rawurl, ":")
+	if !ok {
+		return nil, fmt.Errorf("invalid notification url %q", rawurl)
+	}
+	kind, transport, _ := strings.Cut(scheme, "+")
+	if kind == "exec" {
+		command := strings.TrimPrefix(rest, "//")
+		if command == "" {
+			return nil, fmt.Errorf("missing command in %q", rawurl)
+		}
+		return &commandNotifier{command: command}, nil
+	}
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	if transport != "" {
+		if transport != "http" && transport != "https" {
+			return nil, fmt.Errorf("unsupported transport %q in %q", transport, rawurl)
+		}
+		u.Scheme = transport
+	}
+	switch kind {
+	case "ntfy":
+		if transport == "" {
+			// ntfy://<topic>
+			return &ntfyNotifier{url: "https://ntfy.sh/" + u.Host + u.Path}, nil
+		}
+		return &ntfyNotifier{url: u.String()}, nil
+	case "webhook", "slack", "matrix":
+		if transport == "" {
+			return nil, fmt.Errorf("missing transport in %q, e.g. %v+https://", rawurl, kind)
+		}
+		switch kind {
+		case "webhook":
+			return &webhookNotifier{url: u.String()}, nil
+		case "slack":
+			return &slackNotifier{url: u.String()}, nil
+		}
+		token := u.Query().Get("access_token")
+		room := strings.TrimPrefix(u.Path, "/")
+		if token == "" || room == "" {
+			return nil, fmt.Errorf("matrix notifier needs a room and an access_token")
+		}
+		return &matrixNotifier{
+			server: fmt.Sprintf("%v://%v", u.Scheme, u.Host),
+			room:   room,
+			token:  token,
+		}, nil
+	case "smtp":
+		query := u.Query()
+		n := &smtpNotifier{addr: u.Host, from: query.Get("from")}
+		for _, to := range strings.Split(query.Get("to"), ",") {
+			if to = strings.TrimSpace(to); to != "" {
+				n.to = append(n.to, to)
+			}
+		}
+		if n.from == "" || len(n.to) == 0 {
+			return nil, fmt.Errorf("smtp notifier needs from and to addresses")
+		}
+		if u.User != nil {
+			pass, _ := u.User.Password()
+			n.auth = smtp.PlainAuth("", u.User.Username(), pass, u.Hostname())
+		}
+		return n, nil
+	}
+	return nil, fmt.Errorf("unknown notification backend %q", kind)
+}
+
+// post sends the body to the url, and checks the response status.
+func post(method, endpoint, contentType string, body []byte, header http.Header) error {
+	req, err := http.NewRequest(method, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	res, err := notifyClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return fmt.Errorf("notification failed: %v: %s", res.Status, msg)
+	}
+	return nil
+}
+
+// text returns the notification as plain text.
+func (n *Notification) text() string {
+	return fmt.Sprintf("%v (%v)\n\n%v", n.Title, n.Host, n.Message)
+}
+
+type ntfyNotifier struct{ url string }
+
+func (n *ntfyNotifier) Notify(msg *Notification) error {
+	return post(http.MethodPost, n.url, "text/plain", []byte(msg.text()), nil)
+}
+
+type webhookNotifier struct{ url string }
+
+func (n *webhookNotifier) Notify(msg *Notification) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return post(http.MethodPost, n.url, "application/json", data, nil)
+}
+
+type slackNotifier struct{ url string }
+
+func (n *slackNotifier) Notify(msg *Notification) error {
+	text := fmt.Sprintf("*%v* (%v)\n```\n%v\n```", msg.Title, msg.Host, msg.Message)
+	data, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return err
+	}
+	return post(http.MethodPost, n.url, "application/json", data, nil)
+}
+
+type matrixNotifier struct {
+	server string
+	room   string
+	token  string
+}
+
+func (n *matrixNotifier) Notify(msg *Notification) error {
+	data, err := json.Marshal(map[string]string{"msgtype": "m.text", "body": msg.text()})
+	if err != nil {
+		return err
+	}
+	endpoint := fmt.Sprintf("%v/_matrix/client/v3/rooms/%v/send/m.room.message/goevmlab-%d",
+		n.server, url.PathEscape(n.room), time.Now().UnixNano())
+	return post(http.MethodPut, endpoint, "application/json", data,
+		http.Header{"Authorization": {"Bearer " + n.token}})
+}
+
+type smtpNotifier struct {
+	addr string
+	auth smtp.Auth
+	from string
+	to   []string
+}
+
+func (n *smtpNotifier) Notify(msg *Notification) error {
+	mail := new(strings.Builder)
+	fmt.Fprintf(mail, "From: %v\r\n", n.from)
+	fmt.Fprintf(mail, "To: %v\r\n", strings.Join(n.to, ", "))
+	fmt.Fprintf(mail, "Subject: [goevmlab] %v\r\n", msg.Title)
+	fmt.Fprintf(mail, "Date: %v\r\n", msg.Time.Format(time.RFC1123Z))
+	fmt.Fprintf(mail, "Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	fmt.Fprintf(mail, "%v\r\n", strings.ReplaceAll(msg.text(), "\n", "\r\n"))
+	return smtp.SendMail(n.addr, n.auth, n.from, n.to, []byte(mail.String()))
+}
+
+// commandNotifier executes a command through the shell, with the notification
+// as JSON on stdin. The event and title are also given in the environment.
+type commandNotifier struct{ command string }
+
+func (n *commandNotifier) Notify(msg *Notification) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "sh", "-c", n.command)
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Env = append(os.Environ(),
+		"GOEVMLAB_EVENT="+string(msg.Event),
+		"GOEVMLAB_TITLE="+msg.Title)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("notification command failed: %w: %s", err, out)
+	}
+	return nil
+}
+
+// notifiers delivers the notifications of the subscribed events to a set of
+// notifiers. Delivery happens on a background goroutine, so that a slow
+// backend does not hold up the fuzzer.
+type notifiers struct {
+	backends []Notifier
+	urls     []string
+	events   map[Event]bool
+	host     string
+
+	start sync.Once
+	queue chan *Notification
+	done  chan struct{}
+}
+
+// newNotifiers creates the notifiers given on the command line. It returns
+// nil if there are none.
+func newNotifiers(c *cli.Context) (*notifiers, error) {
+	urls := c.StringSlice(NotifyURLFlag.Name)
+	if topic := c.String(NotifyFlag.Name); topic != "" {
+		urls = append(urls, "ntfy://"+topic)
+	}
+	if len(urls) == 0 {
+		return nil, nil
+	}
+	n := &notifiers{urls: urls, events: make(map[Event]bool)}
+	n.host, _ = os.Hostname()
+	for _, u := range urls {
+		backend, err := NewNotifier(u)
+		if err != nil {
+			return nil, err
+		}
+		n.backends = append(n.backends, backend)
+	}
+	for _, ev := range c.StringSlice(NotifyEventsFlag.Name) {
+		switch e := Event(strings.TrimSpace(ev)); e {
+		case EventStart, EventFlaw, EventCrash, EventShutdown:
+			n.events[e] = true
+		default:
+			return nil, fmt.Errorf("unknown notification event %q", ev)
+		}
+	}
+	return n, nil
+}
+
+// notify queues a notification for delivery, if the event is subscribed to.
+// If the queue is full, the notification is dropped.
+func (n *notifiers) notify(event Event, title, message string) {
+	if n == nil || !n.events[event] {
+		return
+	}
+	n.start.Do(func() {
+		n.queue = make(chan *Notification, notifyQueueSize)
+		n.done = make(chan struct{})
+		go n.loop()
+	})
+	msg := &Notification{Event: event, Title: title, Message: message, Host: n.host, Time: time.Now()}
+	select {
+	case n.queue <- msg:
+	default:
+		log.Warn("Dropping notification, queue full", "event", event, "title", title)
+	}
+}
+
+// loop delivers the queued notifications. Failures are logged, but otherwise
+// ignored.
+func (n *notifiers) loop() {
+	defer close(n.done)
+	for msg := range n.queue {
+		for i, backend := range n.backends {
+			if err := backend.Notify(msg); err != nil {
+				log.Warn("Failed sending notification", "event", msg.Event, "notifier", redactURL(n.urls[i]), "err", err)
+			}
+		}
+	}
+}
+
+// close delivers the queued notifications, waiting at most notifyDrainWait.
+// No notifications may be sent afterwards.
+func (n *notifiers) close() {
+	if n == nil {
+		return
+	}
+	n.start.Do(func() {}) // nothing was ever queued
+	if n.queue == nil {
+		return
+	}
+	close(n.queue)
+	select {
+	case <-n.done:
+	case <-time.After(notifyDrainWait):
+		log.Warn("Timed out delivering notifications", "pending", len(n.queue))
+	}
+}
+
+// redactURL removes credentials and the query from a notification url, for
+// logging.
+func redactURL(rawurl string) string {
+	u, err := url.Parse(rawurl)
+	if err != nil || u.Opaque != "" {
+		scheme, _, _ := strings.Cut(rawurl, ":")
+		return scheme + ":..."
+	}
+	u.User, u.RawQuery = nil, ""
+	return u.String()
+}
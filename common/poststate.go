@@ -0,0 +1,78 @@
+// Copyright 2026 Martin Holst Swende
+// This file is part of the goevmlab library.
+//
+// The library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the goevmlab library. If not, see <http://www.gnu.org/licenses/>.
+
+package common
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/holiman/goevmlab/evms"
+)
+
+// maxStateDiffs is the maximum number of post-state differences to report.
+const maxStateDiffs = 50
+
+// PostStateReport executes the test on the vms which can dump their post-state,
+// and reports where the post-states differ from the one of the first such vm:
+// the accounts and storage slots which disagree. The vms which cannot dump
+// their post-state, or fail doing so, are listed after the differences.
+func PostStateReport(path string, vms []evms.Evm) string {
+	evms.RegisterTest(path)
+	defer evms.ForgetTest(path)
+	var (
+		names []string
+		dumps []*state.Dump
+		notes []string
+	)
+	for _, vm := range vms {
+		dumper, ok := vm.(evms.StateDumper)
+		if !ok {
+			notes = append(notes, fmt.Sprintf("Post-state of %v: client cannot dump state", vm.Name()))
+			continue
+		}
+		dump, cmd, err := dumper.DumpState(path)
+		if err != nil {
+			log.Warn("Failed dumping post-state", "vm", vm.Name(), "cmd", cmd, "err", err)
+			notes = append(notes, fmt.Sprintf("Post-state of %v: dump failed: %v", vm.Name(), err))
+			continue
+		}
+		names = append(names, vm.Name())
+		dumps = append(dumps, dump)
+	}
+	out := new(strings.Builder)
+	for i := 1; i < len(dumps); i++ {
+		diffs := evms.DiffStateDumps(dumps[0], dumps[i])
+		if len(diffs) == 0 {
+			fmt.Fprintf(out, "Post-state of %v and %v: identical\n", names[0], names[i])
+			continue
+		}
+		fmt.Fprintf(out, "Post-state of %v and %v: %d differences\n", names[0], names[i], len(diffs))
+		for j, d := range diffs {
+			if j == maxStateDiffs {
+				fmt.Fprintf(out, "  ... (%d more)\n", len(diffs)-j)
+				break
+			}
+			fmt.Fprintf(out, "  %v\n", d)
+		}
+	}
+	for _, note := range notes {
+		fmt.Fprintln(out, note)
+	}
+	return out.String()
+}
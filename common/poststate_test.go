@@ -0,0 +1,56 @@
+// Copyright 2026 Martin Holst Swende
+// This file is part of the goevmlab library.
+//
+// The library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the goevmlab library. If not, see <http://www.gnu.org/licenses/>.
+
+package common
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/holiman/goevmlab/evms"
+)
+
+func TestPostStateReport(t *testing.T) {
+	var (
+		testfile = filepath.Join("..", "evms", "testdata", "cases", "negative_refund.json")
+		dumpfile = filepath.Join("..", "evms", "testdata", "dumps", "negative_refund.json.geth.poststate.json")
+		vms      = []evms.Evm{evms.NewEmbeddedGethVM("a"), evms.NewBesuVM("besu", "besu"), evms.NewEmbeddedGethVM("b")}
+	)
+	if have, want := PostStateReport(testfile, vms[:2]), "Post-state of besu: client cannot dump state\n"; have != want {
+		t.Fatalf("wrong report with a single dumper\nhave: %v\nwant: %v", have, want)
+	}
+	if have, want := PostStateReport(testfile, vms), "Post-state of a and b: identical\nPost-state of besu: client cannot dump state\n"; have != want {
+		t.Fatalf("wrong report\nhave: %v\nwant: %v", have, want)
+	}
+	if _, err := exec.LookPath("sh"); err != nil {
+		return
+	}
+	// The recorded dump stems from an older geth, which credited the coinbase.
+	abs, _ := filepath.Abs(dumpfile)
+	bin := filepath.Join(t.TempDir(), "evm")
+	if err := os.WriteFile(bin, fmt.Appendf(nil, "#!/bin/sh\ncat %v\n", abs), 0755); err != nil {
+		t.Fatal(err)
+	}
+	have := PostStateReport(testfile, []evms.Evm{vms[0], evms.NewGethEVM(bin, "geth")})
+	want := "0x0000000000000000000000000000000000000000 account: <missing> != present"
+	if !strings.HasPrefix(have, "Post-state of a and geth: ") || !strings.Contains(have, want) {
+		t.Errorf("wrong report: %v", have)
+	}
+}
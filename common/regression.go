@@ -0,0 +1,137 @@
+// Copyright 2026 Martin Holst Swende
+// This file is part of the goevmlab library.
+//
+// The library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the goevmlab library. If not, see <http://www.gnu.org/licenses/>.
+
+package common
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/holiman/goevmlab/evms"
+)
+
+// regression holds the setup of regression-mode: two versions of the same
+// client, where the first one is the reference (e.g. the last release), and
+// the second one is the candidate (e.g. master).
+type regression struct {
+	clients   []evms.ClientInfo
+	versions  []string // the version labels of the clients
+	skipTrace bool
+}
+
+// newRegression checks that the vms are two versions of the same client, and
+// labels them by their version.
+func newRegression(vms []evms.Evm, skipTrace bool) (*regression, error) {
+	if len(vms) != 2 {
+		return nil, fmt.Errorf("regression mode needs exactly two vms, have %d", len(vms))
+	}
+	r := &regression{skipTrace: skipTrace}
+	for _, vm := range vms {
+		// The repro script gives the clients by flags
+		client, ok := vm.(evms.ClientInfo)
+		if !ok || configOf(vm) != nil {
+			return nil, fmt.Errorf("vm %v is not supported in regression mode", vm.Name())
+		}
+		version, err := client.Version()
+		if err != nil {
+			log.Warn("Failed obtaining client version", "vm", vm.Name(), "err", err)
+			version = "unknown version"
+		}
+		r.clients = append(r.clients, client)
+		r.versions = append(r.versions, version)
+		log.Info("Regression mode client", "vm", vm.Name(), "kind", client.Kind(), "version", version)
+	}
+	if a, b := r.clients[0].Kind(), r.clients[1].Kind(); a != b {
+		return nil, fmt.Errorf("regression mode needs two vms of the same kind, have %v and %v", a, b)
+	}
+	return r, nil
+}
+
+// errRegression is returned by the fuzzer if a regression was found.
+var errRegression = errors.New("regression found")
+
+// shellQuote quotes the string for use in a shell script.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// runtestArgs returns the runtest arguments for executing the client, with
+// the binary given by the shell variable.
+func runtestArgs(client evms.ClientInfo, variable string) string {
+	if client.Binary() == "" {
+		return "--gethembedded"
+	}
+	return fmt.Sprintf("--%v \"$%v\"", client.Kind(), variable)
+}
+
+// reproScript returns a shell script which re-executes the test on the
+// reference and the candidate client, using runtest. The candidate binary can
+// be given as the first argument, and the script exits with a non-zero code if
+// the candidate disagrees with the reference, so it can be used with
+// 'git bisect run'.
+func (r *regression) reproScript(path, testfile, verdict string, commands []string) string {
+	var (
+		script    = new(strings.Builder)
+		reference = r.clients[0]
+		candidate = r.clients[1]
+	)
+	fmt.Fprintf(script, "#!/bin/sh\n")
+	fmt.Fprintf(script, "# Regression: %v\n#\n", verdict)
+	for i, role := range []string{"Reference", "Candidate"} {
+		fmt.Fprintf(script, "# %v: %v\n", role, r.versions[i])
+		fmt.Fprintf(script, "#   %v\n", commands[i])
+	}
+	fmt.Fprintf(script, "#\n# Usage: %v [candidate binary]\n", path)
+	fmt.Fprintf(script, "#\n# Exits with 0 if the candidate agrees with the reference, and non-zero\n")
+	fmt.Fprintf(script, "# otherwise. To bisect, build the candidate at each step, e.g.:\n")
+	fmt.Fprintf(script, "#   git bisect run sh -c '<build command> && %v <binary>'\n", path)
+	fmt.Fprintf(script, "# The runtest binary of goevmlab is used, override with RUNTEST=<path>.\n\n")
+	fmt.Fprintf(script, "testfile=%v\n", shellQuote(testfile))
+	if bin := reference.Binary(); bin != "" {
+		fmt.Fprintf(script, "reference=%v\n", shellQuote(bin))
+	}
+	if bin := candidate.Binary(); bin != "" {
+		fmt.Fprintf(script, "candidate=%v\n", shellQuote(bin))
+		fmt.Fprintf(script, "[ $# -gt 0 ] && candidate=\"$1\"\n")
+	}
+	fmt.Fprintf(script, "outdir=$(mktemp -d)\n")
+	fmt.Fprintf(script, "trap 'rm -rf \"$outdir\"' EXIT\n")
+	skipTrace := ""
+	if r.skipTrace {
+		skipTrace = " --skiptrace"
+	}
+	fmt.Fprintf(script, "\"${RUNTEST:-runtest}\" --regression%v --outdir \"$outdir\" %v %v \"$testfile\"\n",
+		skipTrace, runtestArgs(reference, "reference"), runtestArgs(candidate, "candidate"))
+	return script.String()
+}
+
+// writeReproScript stores the reproduction script for the test in the given
+// directory, and returns the path.
+func (r *regression) writeReproScript(dir, testfile, verdict string, commands []string) (string, error) {
+	name := fmt.Sprintf("repro-%v.sh", strings.TrimSuffix(filepath.Base(testfile), ".json"))
+	path := filepath.Join(dir, name)
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	if abs, err := filepath.Abs(testfile); err == nil {
+		testfile = abs
+	}
+	return path, os.WriteFile(path, []byte(r.reproScript(path, testfile, verdict, commands)), 0755)
+}
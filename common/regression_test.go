@@ -0,0 +1,87 @@
+// Copyright 2026 Martin Holst Swende
+// This file is part of the goevmlab library.
+//
+// The library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the goevmlab library. If not, see <http://www.gnu.org/licenses/>.
+
+package common
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/holiman/goevmlab/evms"
+)
+
+// fakeVersioned prints a version, and otherwise records its arguments.
+const fakeVersioned = `#!/bin/sh
+if [ "$1" = "--version" ]; then echo "evm version %v"; exit 0; fi
+echo "$@" >> %v
+`
+
+func TestRegression(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	var (
+		dir     = t.TempDir()
+		logfile = filepath.Join(dir, "invocations")
+		bins    []string
+	)
+	for _, version := range []string{"1.0.0-stable", "1.1.0-unstable"} {
+		bin := filepath.Join(dir, "evm-"+version)
+		if err := os.WriteFile(bin, fmt.Appendf(nil, fakeVersioned, version, logfile), 0755); err != nil {
+			t.Fatal(err)
+		}
+		bins = append(bins, bin)
+	}
+	if _, err := newRegression([]evms.Evm{evms.NewGethEVM(bins[0], "geth-0"), evms.NewBesuVM(bins[1], "besu-0")}, false); err == nil {
+		t.Fatal("expected error for different kinds")
+	}
+	if _, err := newRegression([]evms.Evm{evms.NewGethEVM(bins[0], "geth-0")}, false); err == nil {
+		t.Fatal("expected error for a single vm")
+	}
+	r, err := newRegression([]evms.Evm{evms.NewGethEVM(bins[0], "geth-0"), evms.NewGethBatchVM(bins[1], "gethbatch-0")}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have, want := strings.Join(r.versions, ","), "evm version 1.0.0-stable,evm version 1.1.0-unstable"; have != want {
+		t.Fatalf("wrong versions, have %v, want %v", have, want)
+	}
+	// The script invokes runtest with both binaries, the candidate can be
+	// replaced by an argument.
+	script, err := r.writeReproScript(dir, "test.json", "no majority", []string{"evm-a statetest", "evm-b statetest"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(script, "other-evm")
+	cmd.Env = append(os.Environ(), "RUNTEST="+bins[0])
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("script failed: %v\n%s", err, out)
+	}
+	data, err := os.ReadFile(logfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have, want := strings.TrimSpace(string(data)), "--regression --skiptrace --outdir"; !strings.HasPrefix(have, want) {
+		t.Errorf("wrong runtest invocation: %v", have)
+	}
+	testfile, _ := filepath.Abs("test.json")
+	if have, want := strings.TrimSpace(string(data)), fmt.Sprintf("--geth %v --geth other-evm %v", bins[0], testfile); !strings.HasSuffix(have, want) {
+		t.Errorf("wrong runtest invocation\nhave: %v\nwant suffix: %v", have, want)
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright 2026 Martin Holst Swende
+// This file is part of the goevmlab library.
+//
+// The library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the goevmlab library. If not, see <http://www.gnu.org/licenses/>.
+
+package common
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/tests"
+	"github.com/holiman/goevmlab/ops"
+	"github.com/urfave/cli/v2"
+)
+
+// SequenceFuzzer describes a fuzzer of tests with several transactions, which
+// the clients execute with a dedicated tool: blockchain tests or t8n tests.
+type SequenceFuzzer struct {
+	Usage   string     // the usage of the app
+	VMFlags []cli.Flag // the flags of the vms which execute the tests
+	MinFork string     // the earliest fork supported
+	Engines []string   // the names of the available engines
+
+	// Execute generates tests with the given engines, and executes them.
+	Execute func(c *cli.Context, engines []string, fork string, seed int64) error
+}
+
+// NewApp returns the command-line app of the fuzzer.
+func (f *SequenceFuzzer) NewApp() *cli.App {
+	var (
+		engineFlag = &cli.StringSliceFlag{
+			Name:  "engine",
+			Usage: "fuzzing-engine",
+			Value: cli.NewStringSlice(f.Engines...),
+		}
+		forkFlag = &cli.StringFlag{
+			Name:  "fork",
+			Usage: fmt.Sprintf("Fork to use, from %v onwards", f.MinFork),
+			Value: "Osaka",
+		}
+	)
+	app := cli.NewApp()
+	app.Name = filepath.Base(os.Args[0])
+	app.Authors = []*cli.Author{{Name: "Martin Holst Swende"}}
+	app.Usage = f.Usage
+	app.Flags = append(app.Flags, f.VMFlags...)
+	app.Flags = append(app.Flags,
+		VMConfigFlag,
+		TimeoutFlag,
+		ThreadFlag,
+		LocationFlag,
+		engineFlag,
+		forkFlag,
+		VerbosityFlag,
+		NotifyFlag,
+		NotifyURLFlag,
+		NotifyEventsFlag,
+		RemoveFilesFlag,
+		RawDebugFlag,
+		ContinueFlag,
+		MaxFlawsFlag,
+		MaxFlawsPerClientFlag,
+		DedupFlag,
+		MetricsAddrFlag,
+		SessionFlag,
+		ResumeFlag,
+		SeedFlag,
+	)
+	app.Action = func(c *cli.Context) error {
+		loglevel := slog.Level(c.Int(VerbosityFlag.Name))
+		log.SetDefault(log.NewLogger(log.NewTerminalHandlerWithLevel(os.Stderr, loglevel, true)))
+		log.Root().Write(loglevel, "Set loglevel", "level", loglevel)
+		engines := c.StringSlice(engineFlag.Name)
+		if len(engines) == 0 {
+			return fmt.Errorf("missing engine, available: %v", f.Engines)
+		}
+		for _, name := range engines {
+			if !slices.Contains(f.Engines, name) {
+				return fmt.Errorf("unknown target %v, available: %v", name, f.Engines)
+			}
+			log.Info("Added factory", "name", name)
+		}
+		fork, err := checkFork(c.String(forkFlag.Name), f.MinFork)
+		if err != nil {
+			return err
+		}
+		seed, err := InitSeed(c)
+		if err != nil {
+			return err
+		}
+		log.Info("Generating tests", "seed", seed, "fork", fork)
+		return f.Execute(c, engines, fork, seed)
+	}
+	return app
+}
+
+// checkFork returns the canonical name of the given fork, or an error if the
+// fork is unknown, earlier than the minimum fork, or not supported by the
+// go-ethereum filler of the tests.
+func checkFork(fork, minFork string) (string, error) {
+	forks, err := ops.ParseForks(fork)
+	if err != nil {
+		return "", err
+	}
+	if len(forks) != 1 {
+		return "", fmt.Errorf("expected a single fork, have %v", forks)
+	}
+	if _, ok := tests.Forks[forks[0]]; !ok {
+		return "", fmt.Errorf("fork %v not supported by the filler", forks[0])
+	}
+	names := ops.ForkNames()
+	if slices.Index(names, forks[0]) < slices.Index(names, minFork) {
+		return "", fmt.Errorf("fork %v not supported, the earliest supported fork is %v", forks[0], minFork)
+	}
+	return forks[0], nil
+}
@@ -0,0 +1,66 @@
+// Copyright 2026 Martin Holst Swende
+// This file is part of the goevmlab library.
+//
+// The library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the goevmlab library. If not, see <http://www.gnu.org/licenses/>.
+
+package common
+
+import (
+	"testing"
+
+	"github.com/urfave/cli/v2"
+)
+
+// runSequenceApp runs the app of a sequence fuzzer with the given fork, and
+// returns the fork passed on to the fuzzer.
+func runSequenceApp(t *testing.T, minFork, fork string) (string, error) {
+	t.Helper()
+	var have string
+	app := (&SequenceFuzzer{
+		MinFork: minFork,
+		Engines: []string{"engine"},
+		Execute: func(c *cli.Context, engines []string, fork string, seed int64) error {
+			have = fork
+			return nil
+		},
+	}).NewApp()
+	err := app.Run([]string{"fuzzer", "--fork", fork, "--outdir", t.TempDir()})
+	return have, err
+}
+
+func TestSequenceFuzzerFork(t *testing.T) {
+	// The t8n fuzzer
+	if _, err := runSequenceApp(t, "London", "Berlin"); err == nil {
+		t.Error("fork before London accepted")
+	}
+	if _, err := runSequenceApp(t, "London", "Frontier"); err == nil {
+		t.Error("unknown fork accepted")
+	}
+	if _, err := runSequenceApp(t, "London", "Prague,Osaka"); err == nil {
+		t.Error("several forks accepted")
+	}
+	if fork, err := runSequenceApp(t, "London", " London"); err != nil || fork != "London" {
+		t.Errorf("fork not accepted: %v %v", fork, err)
+	}
+	// The block fuzzer
+	if _, err := runSequenceApp(t, "Shanghai", "Merge"); err == nil {
+		t.Error("fork before Shanghai accepted")
+	}
+	if _, err := runSequenceApp(t, "Shanghai", "Amsterdam"); err == nil {
+		t.Error("fork without a filler accepted")
+	}
+	if fork, err := runSequenceApp(t, "Shanghai", "Shanghai"); err != nil || fork != "Shanghai" {
+		t.Errorf("fork not accepted: %v %v", fork, err)
+	}
+}
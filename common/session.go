@@ -0,0 +1,333 @@
+// Copyright 2026 Martin Holst Swende
+// This file is part of the goevmlab library.
+//
+// The library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the goevmlab library. If not, see <http://www.gnu.org/licenses/>.
+
+package common
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/gofrs/flock"
+	"github.com/urfave/cli/v2"
+)
+
+// A session is a fuzzing campaign, which may span several executions of the
+// fuzzer. The session directory holds the state of the campaign: the
+// cumulative counters, the statistics per engine, the flaw index and the
+// coverage. The directory is locked while a fuzzer is using it.
+const (
+	sessionStateFile = "session.json"
+	sessionLockFile  = "session.lock"
+	sessionPrefix    = "session-"
+)
+
+// engineStats counts the tests, flaws and crashes of a fuzzing engine.
+type engineStats struct {
+	Tests     uint64 `json:"tests"`
+	Flaws     uint64 `json:"flaws"`
+	Crashes   uint64 `json:"crashes"`
+	Generated uint64 `json:"generated,omitempty"` // the position in the sequence of a seeded engine
+}
+
+// Resumable is a seeded engine, which can continue the sequence of tests
+// where an earlier run of the session left off.
+type Resumable interface {
+	Resume(generated uint64)
+	Generated() uint64
+}
+
+// sessionRun describes one execution of the fuzzer within a session.
+type sessionRun struct {
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end,omitzero"`
+	Args     []string  `json:"args"`
+	VMs      []string  `json:"vms"`
+	Goevmlab string    `json:"goevmlab"`
+	Seed     int64     `json:"seed,omitempty"` // the seed of the test generators
+	Tests    uint64    `json:"tests"`
+}
+
+// sessionState is the persistent state of a session.
+type sessionState struct {
+	Created time.Time               `json:"created"`
+	Tests   uint64                  `json:"tests"`
+	Flaws   uint64                  `json:"flaws"`
+	Crashes uint64                  `json:"crashes"`
+	Slow    uint64                  `json:"slow"`
+	Elapsed time.Duration           `json:"elapsed"`
+	Engines map[string]*engineStats `json:"engines"`
+	Runs    []*sessionRun           `json:"runs"`
+}
+
+// session is an open fuzzing session.
+type session struct {
+	dir     string
+	lock    *flock.Flock
+	engines map[string]Resumable // the seeded engines, by name
+
+	mu    sync.Mutex
+	state sessionState
+	base  sessionState // the counters when the session was opened
+	run   *sessionRun  // the current run
+}
+
+// openSession opens the session given on the command line, or creates a new
+// one in the output directory. In resume-mode, an existing session is
+// continued: the latest one in the output directory, unless a session
+// directory is given. The seeded engines continue where the session left off.
+func openSession(c *cli.Context, vms []string, engines map[string]Resumable) (*session, error) {
+	var (
+		dir    = c.String(SessionFlag.Name)
+		resume = c.Bool(ResumeFlag.Name)
+		outdir = c.String(LocationFlag.Name)
+		seed   = c.Int64(SeedFlag.Name)
+	)
+	if dir == "" && resume {
+		// Resume the latest session which is not in use
+		dirs, _ := filepath.Glob(filepath.Join(outdir, sessionPrefix+"*"))
+		slices.Sort(dirs)
+		slices.Reverse(dirs)
+		for _, d := range dirs {
+			if _, err := os.Stat(filepath.Join(d, sessionStateFile)); err != nil {
+				continue
+			}
+			if s, err := lockSession(d, engines); err == nil {
+				return s.resume(vms, seed)
+			}
+		}
+		return nil, fmt.Errorf("no session to resume in %v", outdir)
+	}
+	if dir == "" {
+		var err error
+		if dir, err = newSessionDir(outdir); err != nil {
+			return nil, err
+		}
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	s, err := lockSession(dir, engines)
+	if err != nil {
+		return nil, err
+	}
+	_, err = os.Stat(filepath.Join(dir, sessionStateFile))
+	switch {
+	case err == nil && !resume:
+		s.lock.Unlock()
+		return nil, fmt.Errorf("session %v already exists, use --%v to continue it", dir, ResumeFlag.Name)
+	case err == nil:
+		return s.resume(vms, seed)
+	case !errors.Is(err, os.ErrNotExist):
+		s.lock.Unlock()
+		return nil, err
+	case resume:
+		s.lock.Unlock()
+		return nil, fmt.Errorf("no session to resume in %v", dir)
+	}
+	s.state = sessionState{Created: time.Now(), Engines: make(map[string]*engineStats)}
+	s.startRun(vms, seed)
+	log.Info("Started fuzzing session", "dir", dir)
+	return s, nil
+}
+
+// newSessionDir creates a directory for a new session in the output directory.
+func newSessionDir(outdir string) (string, error) {
+	name := sessionPrefix + time.Now().Format("20060102-150405")
+	dir := filepath.Join(outdir, name)
+	for i := 2; ; i++ {
+		err := os.Mkdir(dir, 0755)
+		if err == nil {
+			return dir, nil
+		}
+		if !os.IsExist(err) {
+			return "", err
+		}
+		dir = filepath.Join(outdir, fmt.Sprintf("%v-%d", name, i))
+	}
+}
+
+// lockSession acquires the lock of the session directory. The lock is held by
+// the process, so it is released if the fuzzer dies.
+func lockSession(dir string, engines map[string]Resumable) (*session, error) {
+	lock := flock.New(filepath.Join(dir, sessionLockFile))
+	locked, err := lock.TryLock()
+	if err != nil {
+		return nil, err
+	}
+	if !locked {
+		return nil, fmt.Errorf("session %v is in use by another fuzzer", dir)
+	}
+	return &session{dir: dir, lock: lock, engines: engines}, nil
+}
+
+// resume loads the state of the session, and starts a new run.
+func (s *session) resume(vms []string, seed int64) (*session, error) {
+	data, err := os.ReadFile(filepath.Join(s.dir, sessionStateFile))
+	if err == nil {
+		err = json.Unmarshal(data, &s.state)
+	}
+	if err != nil {
+		s.lock.Unlock()
+		return nil, fmt.Errorf("failed loading session %v: %w", s.dir, err)
+	}
+	if s.state.Engines == nil {
+		s.state.Engines = make(map[string]*engineStats)
+	}
+	if n := len(s.state.Runs); n > 0 && !slices.Equal(s.state.Runs[n-1].VMs, vms) {
+		log.Warn("Resumed session with other vms", "previous", s.state.Runs[n-1].VMs, "now", vms)
+	}
+	for name, engine := range s.engines {
+		if stats, ok := s.state.Engines[name]; ok {
+			engine.Resume(stats.Generated)
+		}
+	}
+	s.startRun(vms, seed)
+	log.Info("Resumed fuzzing session", "dir", s.dir, "runs", len(s.state.Runs),
+		"tests", s.state.Tests, "flaws", s.state.Flaws, "crashes", s.state.Crashes)
+	return s, nil
+}
+
+func (s *session) startRun(vms []string, seed int64) {
+	s.base = s.state
+	s.run = &sessionRun{
+		Start:    time.Now(),
+		Args:     os.Args,
+		VMs:      vms,
+		Goevmlab: goevmlabCommit(),
+		Seed:     seed,
+	}
+	s.state.Runs = append(s.state.Runs, s.run)
+}
+
+// generated returns the number of tests generated in the session.
+func (s *session) generated() uint64 {
+	if s == nil {
+		return 0
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var n uint64
+	for _, e := range s.state.Engines {
+		n += e.Tests
+	}
+	return n
+}
+
+// count bumps a counter of the engine which generated the test.
+func (s *session) count(testfile string, fn func(*engineStats)) {
+	if s == nil {
+		return
+	}
+	engine := testEngine(testfile)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats, ok := s.state.Engines[engine]
+	if !ok {
+		stats = new(engineStats)
+		s.state.Engines[engine] = stats
+	}
+	fn(stats)
+}
+
+func (s *session) countTest(testfile string) { s.count(testfile, func(e *engineStats) { e.Tests++ }) }
+func (s *session) countFlaw(testfile string) { s.count(testfile, func(e *engineStats) { e.Flaws++ }) }
+func (s *session) countCrash(testfile string) {
+	s.count(testfile, func(e *engineStats) { e.Crashes++ })
+}
+
+// testEngine returns the name of the engine which generated the test, from
+// the name of the test: <index>-<engine>-<thread>.json.
+func testEngine(testfile string) string {
+	name := strings.TrimSuffix(filepath.Base(testfile), ".json")
+	if _, rest, ok := strings.Cut(name, "-"); ok {
+		if i := strings.LastIndex(rest, "-"); i > 0 {
+			return rest[:i]
+		}
+	}
+	return "unknown"
+}
+
+// tests returns the number of tests executed in the session.
+func (s *session) tests(meta *testMeta) uint64 {
+	return s.base.Tests + meta.numTests.Load()
+}
+
+// save updates the cumulative counters with the counters of the current run,
+// and writes the state of the session.
+func (s *session) save(meta *testMeta, elapsed time.Duration) error {
+	s.mu.Lock()
+	s.run.Tests = meta.numTests.Load()
+	s.state.Tests = s.base.Tests + s.run.Tests
+	s.state.Flaws = s.base.Flaws + meta.numFlaws.Load()
+	s.state.Crashes = s.base.Crashes + meta.numCrashes.Load()
+	s.state.Slow = s.base.Slow + meta.numSlow.Load()
+	s.state.Elapsed = s.base.Elapsed + elapsed
+	for name, engine := range s.engines {
+		stats, ok := s.state.Engines[name]
+		if !ok {
+			stats = new(engineStats)
+			s.state.Engines[name] = stats
+		}
+		stats.Generated = engine.Generated()
+	}
+	data, err := json.MarshalIndent(&s.state, "", "  ")
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(s.dir, sessionStateFile)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// close ends the current run, saves the state and releases the session.
+func (s *session) close(meta *testMeta, elapsed time.Duration) {
+	s.run.End = time.Now()
+	if err := s.save(meta, elapsed); err != nil {
+		log.Error("Failed saving session", "dir", s.dir, "err", err)
+	}
+	s.lock.Unlock()
+}
+
+// summary returns the cumulative statistics of the session, per engine.
+func (s *session) summary() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := new(strings.Builder)
+	fmt.Fprintf(out, "Session %v: %d runs, %d tests, %d flaws, %d crashes, %v\n", s.dir, len(s.state.Runs),
+		s.state.Tests, s.state.Flaws, s.state.Crashes, s.state.Elapsed.Round(time.Second))
+	var engines []string
+	for name := range s.state.Engines {
+		engines = append(engines, name)
+	}
+	slices.Sort(engines)
+	for _, name := range engines {
+		e := s.state.Engines[name]
+		fmt.Fprintf(out, "  %-14v tests %d, flaws %d, crashes %d\n", name, e.Tests, e.Flaws, e.Crashes)
+	}
+	return out.String()
+}
@@ -0,0 +1,112 @@
+// Copyright 2026 Martin Holst Swende
+// This file is part of the goevmlab library.
+//
+// The library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the goevmlab library. If not, see <http://www.gnu.org/licenses/>.
+
+package common
+
+import (
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/holiman/goevmlab/fuzzing"
+	"github.com/urfave/cli/v2"
+)
+
+func sessionContext(outdir, dir string, resume bool) *cli.Context {
+	set := flag.NewFlagSet("test", flag.ContinueOnError)
+	set.String(LocationFlag.Name, outdir, "")
+	set.String(SessionFlag.Name, dir, "")
+	set.Bool(ResumeFlag.Name, resume, "")
+	set.Int64(SeedFlag.Name, 7, "")
+	return cli.NewContext(cli.NewApp(), set, nil)
+}
+
+func TestSession(t *testing.T) {
+	var (
+		outdir  = t.TempDir()
+		vms     = []string{"geth-0", "besu-0"}
+		naive   = fuzzing.NewSeededGenerator("naive", "Prague", 7)
+		engines = map[string]Resumable{"naive": naive}
+	)
+	if _, err := openSession(sessionContext(outdir, "", true), vms, engines); err == nil {
+		t.Fatal("expected error, no session to resume")
+	}
+	s, err := openSession(sessionContext(outdir, "", false), vms, engines)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The session is locked while in use
+	if _, err := openSession(sessionContext(outdir, s.dir, true), vms, engines); err == nil ||
+		!strings.Contains(err.Error(), "in use") {
+		t.Fatalf("expected locked session, have %v", err)
+	}
+	meta := &testMeta{session: s}
+	for range 2 {
+		naive.Generate()
+	}
+	for _, name := range []string{"00000000-naive-0", "00000001-naive-1", "00000000-sstore_sload-1"} {
+		s.countTest("/tmp/" + name + ".json")
+	}
+	s.countFlaw("/tmp/00000001-naive-1.json")
+	meta.numTests.Store(3)
+	meta.numFlaws.Store(1)
+	s.close(meta, time.Minute)
+
+	// A new session is started unless resumed
+	if _, err := openSession(sessionContext(outdir, s.dir, false), vms, engines); err == nil {
+		t.Fatal("expected error, session exists")
+	}
+	naive = fuzzing.NewSeededGenerator("naive", "Prague", 7)
+	engines["naive"] = naive
+	s, err = openSession(sessionContext(outdir, "", true), vms, engines)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have := s.generated(); have != 3 {
+		t.Fatalf("wrong number of generated tests: %d", have)
+	}
+	if have := s.state.Runs[1].Seed; have != 7 {
+		t.Fatalf("wrong seed recorded: %d", have)
+	}
+	// The seeded engine continues where the session left off
+	if have := naive.Generated(); have != 2 {
+		t.Fatalf("engine not resumed, generated %d", have)
+	}
+	if have, want := naive.Generate().ToGeneralStateTest("a"), fuzzing.Generate("naive", "Prague", 9).ToGeneralStateTest("a"); !reflect.DeepEqual(have, want) {
+		t.Fatal("resumed engine generated the wrong test")
+	}
+	meta = &testMeta{session: s}
+	meta.numTests.Store(2)
+	meta.numCrashes.Store(1)
+	s.countCrash("/tmp/00000003-sstore_sload-0.json")
+	s.close(meta, time.Minute)
+
+	summary := s.summary()
+	for _, want := range []string{
+		"2 runs, 5 tests, 1 flaws, 1 crashes, 2m0s",
+		"naive          tests 2, flaws 1, crashes 0",
+		"sstore_sload   tests 1, flaws 0, crashes 1",
+	} {
+		if !strings.Contains(summary, want) {
+			t.Errorf("missing %q in summary:\n%v", want, summary)
+		}
+	}
+	if have := testEngine("garbage.json"); have != "unknown" {
+		t.Errorf("wrong engine: %v", have)
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright 2026 Martin Holst Swende
+// This file is part of the goevmlab library.
+//
+// The library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the goevmlab library. If not, see <http://www.gnu.org/licenses/>.
+
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/tests"
+	"github.com/holiman/goevmlab/evms"
+	"github.com/holiman/goevmlab/fuzzing"
+)
+
+// TestMultiForkProvider checks that a test for several forks is provided as
+// one test, with a post-state for each fork.
+func TestMultiForkProvider(t *testing.T) {
+	forks := []string{"Cancun", "Prague", "Osaka"}
+	engines := []Engine{{
+		Name:     "naive",
+		Generate: fuzzing.WithForks(fuzzing.SeededFactory("naive", "Osaka", 1), forks),
+	}}
+	dir := t.TempDir()
+	providerFn := testFnFromEngines(engines, dir)
+	for i := range 2 {
+		file, err := providerFn(i, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := fmt.Sprintf("%08d-naive-0.json", i); filepath.Base(file) != want {
+			t.Fatalf("wrong test file: have %v, want %v", filepath.Base(file), want)
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var gst map[string]tests.StateTest
+		if err := json.Unmarshal(data, &gst); err != nil {
+			t.Fatal(err)
+		}
+		var have []string
+		test := gst[fmt.Sprintf("%08d-naive-0", i)]
+		for _, st := range test.Subtests() {
+			have = append(have, st.Fork)
+		}
+		slices.Sort(have)
+		if want := []string{"Cancun", "Osaka", "Prague"}; !slices.Equal(have, want) {
+			t.Errorf("wrong subtests: have %v, want %v", have, want)
+		}
+		if have := testEngine(file); have != "naive" {
+			t.Errorf("wrong engine: %v", have)
+		}
+	}
+}
+
+// TestDiffTestSubtests checks that DiffTest registers the test, so the vms
+// report the stateroots of all of its subtests.
+func TestDiffTestSubtests(t *testing.T) {
+	gen := fuzzing.WithForks(fuzzing.SeededFactory("naive", "Osaka", 1), []string{"Cancun", "Prague"})
+	file, err := testFnFromEngines([]Engine{{Name: "naive", Generate: gen}}, t.TempDir())(0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outdir := t.TempDir()
+	vms := []evms.Evm{evms.NewEmbeddedGethVM("a"), evms.NewEmbeddedGethVM("b")}
+	div, err := DiffTest(file, outdir, vms)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if div != nil {
+		t.Fatalf("unexpected divergence: %v", div.Report())
+	}
+	data, err := os.ReadFile(filepath.Join(outdir, "a-output.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have := bytes.Count(data, []byte(`{"stateRoot":"0x`)); have != 3 {
+		t.Errorf("wrong number of stateroots: have %d, want 3", have)
+	}
+}
+
+// instanceVM is a vm which delivers a distinct instance for each thread.
+type instanceVM struct {
+	evms.Evm
+	instances []*instanceVM
+	closed    bool
+}
+
+func (vm *instanceVM) Instance(id int) evms.Evm {
+	inst := &instanceVM{Evm: evms.NewEmbeddedGethVM(fmt.Sprintf("%v-%d", vm.Name(), id))}
+	vm.instances = append(vm.instances, inst)
+	return inst
+}
+
+func (vm *instanceVM) Close() {
+	vm.closed = true
+}
+
+// executions returns the number of tests the vm executed.
+func (vm *instanceVM) executions() any {
+	return vm.Stats()[5]
+}
+
+// TestVMLoopInstance checks that the tests are executed on an instance of the
+// vm, which is closed when the loop exits.
+func TestVMLoopInstance(t *testing.T) {
+	gen := fuzzing.SeededFactory("naive", "Osaka", 1)
+	file, err := testFnFromEngines([]Engine{{Name: "naive", Generate: gen}}, t.TempDir())(0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		vm       = &instanceVM{Evm: evms.NewEmbeddedGethVM("a")}
+		meta     = &testMeta{}
+		taskCh   = make(chan *task)
+		resultCh = make(chan *task)
+	)
+	meta.wg.Add(1)
+	go meta.vmLoop(vm, 0, taskCh, resultCh)
+	taskCh <- &task{file: file}
+	if res := <-resultCh; res.err != nil {
+		t.Fatal(res.err)
+	}
+	close(taskCh)
+	meta.wg.Wait()
+	if len(vm.instances) != 1 {
+		t.Fatalf("wrong number of instances: %d", len(vm.instances))
+	}
+	if inst := vm.instances[0]; inst.executions() != uint64(1) || !inst.closed {
+		t.Errorf("instance not used: executions %v, closed %v", inst.executions(), inst.closed)
+	}
+	if vm.executions() != uint64(0) || vm.closed {
+		t.Errorf("vm used: executions %v, closed %v", vm.executions(), vm.closed)
+	}
+}
@@ -0,0 +1,215 @@
+// Copyright 2026 Martin Holst Swende
+// This file is part of the goevmlab library.
+//
+// The library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the goevmlab library. If not, see <http://www.gnu.org/licenses/>.
+
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/holiman/goevmlab/evms"
+)
+
+// vmKind holds the constructors for one kind of client.
+type vmKind struct {
+	single func(path, name string) evms.Evm
+	batch  func(path, name string) evms.Evm // nil if batch-mode is not supported
+}
+
+var vmKinds = map[string]vmKind{
+	"geth":       {evms.NewGethEVM, evms.NewGethBatchVM},
+	"eels":       {evms.NewEelsEVM, evms.NewEelsBatchVM},
+	"nethermind": {evms.NewNethermindVM, evms.NewNethermindBatchVM},
+	"besu":       {evms.NewBesuVM, evms.NewBesuBatchVM},
+	"erigon":     {evms.NewErigonVM, evms.NewErigonBatchVM},
+	"nimbus":     {evms.NewNimbusEVM, evms.NewNimbusBatchVM},
+	"evmone":     {evms.NewEvmoneVM, evms.NewEvmoneBatchVM},
+	"revme":      {evms.NewRethVM, evms.NewRethBatchVM},
+	"gethembedded": {func(_, name string) evms.Evm {
+		return evms.NewEmbeddedGethVM(name)
+	}, nil},
+	// Runners of blockchain tests
+	"gethblock":       {evms.NewGethBlockVM, nil},
+	"besublock":       {evms.NewBesuBlockVM, nil},
+	"nethermindblock": {evms.NewNethermindBlockVM, nil},
+	// Runners of t8n tests
+	"getht8n":       {evms.NewGethT8nVM, nil},
+	"besut8n":       {evms.NewBesuT8nVM, nil},
+	"nethermindt8n": {evms.NewNethermindT8nVM, nil},
+	"evmonet8n":     {evms.NewEvmoneT8nVM, nil},
+	"eelst8n":       {evms.NewEelsT8nVM, nil},
+}
+
+// vmConfig describes one client in a vm configuration file.
+type vmConfig struct {
+	Name   string            `json:"name"`             // defaults to <kind>-<index>
+	Kind   string            `json:"kind"`             // e.g. geth, besu, nethermind, or custom
+	Batch  bool              `json:"batch,omitempty"`  // whether to use the batch-mode vm
+	Path   string            `json:"path,omitempty"`   // the client binary
+	Args   []string          `json:"args,omitempty"`   // extra arguments, placed directly after the binary
+	Env    map[string]string `json:"env,omitempty"`    // extra environment variables
+	Weight int               `json:"weight,omitempty"` // number of instances, defaults to 1
+
+	// Fields for custom clients, see evms.CustomVM
+	Command     []string           `json:"command,omitempty"`     // command template, with a {path} placeholder
+	RootCommand []string           `json:"rootCommand,omitempty"` // command template for executing without tracing
+	Profile     evms.OutputProfile `json:"profile"`
+
+	// Fields for clients running in a container, see evms.DockerVM. If an
+	// image is given, the path is the client binary within the image.
+	Image   string   `json:"image,omitempty"`
+	Runtime string   `json:"runtime,omitempty"` // defaults to docker
+	Mounts  []string `json:"mounts,omitempty"`  // directories to bind-mount, defaults to the output directory
+}
+
+// vmConfigFile is the format of a vm configuration file. Only JSON is
+// supported, like the other files of goevmlab: it needs no dependency besides
+// the standard library, which also rejects misspelled fields.
+type vmConfigFile struct {
+	VMs []vmConfig `json:"vms"`
+}
+
+// configOf returns the vm config entry which instantiates the vm, for the vms
+// which cannot be given by a flag: custom clients and clients running in a
+// container. Containers mount the current directory. For other vms, nil is
+// returned.
+func configOf(vm evms.Evm) *vmConfig {
+	conf := &vmConfig{Name: vm.Name()}
+	var env []string
+	switch vm := vm.(type) {
+	case *evms.CustomVM:
+		c := vm.Config()
+		conf.Kind, conf.Args, env = "custom", c.Args, c.Env
+		conf.Command, conf.RootCommand, conf.Profile = c.Command, c.RootCommand, c.Profile
+	case *evms.DockerVM:
+		c := vm.Config()
+		conf.Kind, conf.Batch, conf.Path, conf.Args, env = vm.Kind(), true, c.Binary, c.Args, c.Env
+		conf.Image, conf.Runtime, conf.Mounts = c.Image, c.Runtime, []string{"."}
+	default:
+		return nil
+	}
+	for _, kv := range env {
+		if conf.Env == nil {
+			conf.Env = make(map[string]string)
+		}
+		k, v, _ := strings.Cut(kv, "=")
+		conf.Env[k] = v
+	}
+	return conf
+}
+
+// loadVMConfig reads a vm configuration file, and instantiates the vms in it.
+// A client with a weight of N is instantiated N times, which is the same as
+// giving the corresponding command-line flag N times.
+// The mounts are bind-mounted into containers, unless configured otherwise.
+func loadVMConfig(path string, mounts []string) ([]evms.Evm, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var config vmConfigFile
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&config); err != nil {
+		return nil, fmt.Errorf("invalid vm config %v: %w", path, err)
+	}
+	var (
+		vms   []evms.Evm
+		names = make(map[string]bool)
+	)
+	for i, conf := range config.VMs {
+		var env []string
+		for k, v := range conf.Env {
+			env = append(env, fmt.Sprintf("%v=%v", k, v))
+		}
+		sort.Strings(env)
+		var constructor func(path, name string) (evms.Evm, error)
+		switch kind, ok := vmKinds[conf.Kind]; {
+		case conf.Kind == "custom":
+			if conf.Batch || conf.Image != "" {
+				return nil, fmt.Errorf("vm config entry %d: kind %q does not support batch-mode or containers", i, conf.Kind)
+			}
+			constructor = func(_, name string) (evms.Evm, error) {
+				return evms.NewCustomVM(name, conf.Command, conf.RootCommand, conf.Profile)
+			}
+		case !ok:
+			return nil, fmt.Errorf("vm config entry %d: unknown kind %q", i, conf.Kind)
+		case conf.Batch && kind.batch == nil:
+			return nil, fmt.Errorf("vm config entry %d: kind %q does not support batch-mode", i, conf.Kind)
+		case conf.Path == "" && conf.Kind != "gethembedded":
+			return nil, fmt.Errorf("vm config entry %d: path missing", i)
+		case conf.Image != "":
+			if !conf.Batch {
+				return nil, fmt.Errorf("vm config entry %d: containers require batch-mode", i)
+			}
+			dconf := evms.DockerConfig{
+				Runtime: conf.Runtime,
+				Image:   conf.Image,
+				Binary:  conf.Path,
+				Args:    conf.Args,
+				Env:     env,
+				Mounts:  conf.Mounts,
+			}
+			if len(dconf.Mounts) == 0 {
+				dconf.Mounts = mounts
+			}
+			constructor = func(_, name string) (evms.Evm, error) {
+				return evms.NewDockerVM(name, kind.batch, dconf)
+			}
+		default:
+			newVM := kind.single
+			if conf.Batch {
+				newVM = kind.batch
+			}
+			constructor = func(path, name string) (evms.Evm, error) {
+				return newVM(path, name), nil
+			}
+		}
+		if conf.Weight < 0 {
+			return nil, fmt.Errorf("vm config entry %d: negative weight", i)
+		}
+		if conf.Name == "" {
+			conf.Name = fmt.Sprintf("%v-%d", conf.Kind, i)
+		}
+		for n := range max(conf.Weight, 1) {
+			name := conf.Name
+			if conf.Weight > 1 {
+				name = fmt.Sprintf("%v-%d", conf.Name, n)
+			}
+			if names[name] {
+				return nil, fmt.Errorf("vm config entry %d: duplicate name %q", i, name)
+			}
+			names[name] = true
+			vm, err := constructor(conf.Path, name)
+			if err != nil {
+				return nil, fmt.Errorf("vm config entry %d: %w", i, err)
+			}
+			if conf.Image == "" && (len(conf.Args) > 0 || len(env) > 0) {
+				opts, ok := vm.(evms.CommandOptions)
+				if !ok || conf.Kind == "gethembedded" {
+					return nil, fmt.Errorf("vm config entry %d: kind %q does not support args or env", i, conf.Kind)
+				}
+				opts.SetCommandOptions(conf.Args, env)
+			}
+			vms = append(vms, vm)
+		}
+	}
+	return vms, nil
+}
@@ -0,0 +1,153 @@
+// Copyright 2026 Martin Holst Swende
+// This file is part of the goevmlab library.
+//
+// The library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the goevmlab library. If not, see <http://www.gnu.org/licenses/>.
+
+package common
+
+import (
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/urfave/cli/v2"
+)
+
+// fakeGeth prints a stateroot taken from the environment.
+const fakeGeth = `#!/bin/sh
+echo "{\"stateRoot\": \"0x$FAKE_ROOT\"}"
+`
+
+func TestLoadVMConfig(t *testing.T) {
+	var (
+		dir  = t.TempDir()
+		bin  = filepath.Join(dir, "evm")
+		root = strings.Repeat("ab", 32)
+	)
+	if err := os.WriteFile(bin, []byte(fakeGeth), 0755); err != nil {
+		t.Fatal(err)
+	}
+	write := func(config string) string {
+		path := filepath.Join(dir, "vms.json")
+		if err := os.WriteFile(path, []byte(config), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	vms, err := loadVMConfig(write(`{"vms": [
+		{"name": "custom", "kind": "geth", "path": "`+bin+`", "args": ["--extra"], "env": {"FAKE_ROOT": "`+root+`"}},
+		{"kind": "besu", "batch": true, "path": "besu", "weight": 2},
+		{"kind": "gethembedded"},
+		{"name": "zk", "kind": "custom", "command": ["zkevm", "run", "{path}"], "profile": {"stream": "stdout", "dropStops": true}},
+		{"name": "dockerbesu", "kind": "besu", "batch": true, "path": "/opt/besu/evmtool", "image": "hyperledger/besu"}
+	]}`), []string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, vm := range vms {
+		names = append(names, vm.Name())
+	}
+	if have, want := strings.Join(names, ","), "custom,besu-1-0,besu-1-1,gethembedded-2,zk,dockerbesu"; have != want {
+		t.Fatalf("wrong vms, have %v, want %v", have, want)
+	}
+	if _, err := exec.LookPath("sh"); err == nil {
+		have, cmd, err := vms[0].GetStateRoot("test.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if have != "0x"+root {
+			t.Errorf("environment not applied, root %v", have)
+		}
+		if !strings.Contains(cmd, bin+" --extra statetest") {
+			t.Errorf("arguments not applied, command %v", cmd)
+		}
+	}
+	for i, config := range []string{
+		`{"vms": [{"kind": "foo", "path": "foo"}]}`,
+		`{"vms": [{"kind": "geth"}]}`,
+		`{"vms": [{"kind": "gethembedded", "batch": true}]}`,
+		`{"vms": [{"kind": "geth", "path": "evm", "name": "a"}, {"kind": "besu", "path": "besu", "name": "a"}]}`,
+		`{"vms": [{"kind": "geth", "path": "evm", "wieght": 2}]}`,
+		`{"vms": [{"kind": "custom", "command": ["zkevm", "run"]}]}`,
+		`{"vms": [{"kind": "geth", "path": "evm", "image": "ethereum/client-go"}]}`,
+	} {
+		if _, err := loadVMConfig(write(config), nil); err == nil {
+			t.Errorf("config %d: expected error", i)
+		}
+	}
+}
+
+// TestInitVMsNames checks that the names of configured vms may not collide
+// with the names of the vms given by flags.
+func TestInitVMsNames(t *testing.T) {
+	config := filepath.Join(t.TempDir(), "vms.json")
+	if err := os.WriteFile(config, []byte(`{"vms": [{"name": "geth-0", "kind": "geth", "path": "evm"}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	set := flag.NewFlagSet("test", flag.ContinueOnError)
+	for _, f := range []cli.Flag{GethFlag, VMConfigFlag, LocationFlag, TimeoutFlag} {
+		if err := f.Apply(set); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := set.Parse([]string{"--geth", "evm", "--vms", config}); err != nil {
+		t.Fatal(err)
+	}
+	_, err := InitVMs(cli.NewContext(cli.NewApp(), set, nil))
+	if err == nil || !strings.Contains(err.Error(), `duplicate vm name "geth-0"`) {
+		t.Fatalf("expected error for duplicate name, got %v", err)
+	}
+}
+
+// TestInitVMsKind checks that the vms must execute the kind of tests of the
+// fuzzer, also the vms of a vm config file.
+func TestInitVMsKind(t *testing.T) {
+	config := filepath.Join(t.TempDir(), "vms.json")
+	if err := os.WriteFile(config, []byte(`{"vms": [{"name": "t8n", "kind": "getht8n", "path": "evm"}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for i, tc := range []struct {
+		args []string
+		kind testKind
+		err  string
+	}{
+		{[]string{"--vms", config}, stateTests, "vm t8n cannot execute statetests"},
+		{[]string{"--vms", config}, t8nTests, ""},
+		{[]string{"--vms", config, "--geth", "evm"}, t8nTests, "vm geth-0 cannot execute t8n tests"},
+		{[]string{"--geth", "evm"}, blockTests, "vm geth-0 cannot execute blockchain tests"},
+		{[]string{"--vms", config}, anyTests, ""},
+		{[]string{"--geth", "evm", "--vms", config}, anyTests, "vm t8n cannot execute statetests"},
+	} {
+		set := flag.NewFlagSet("test", flag.ContinueOnError)
+		for _, f := range []cli.Flag{GethFlag, VMConfigFlag, LocationFlag, TimeoutFlag} {
+			if err := f.Apply(set); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := set.Parse(tc.args); err != nil {
+			t.Fatal(err)
+		}
+		_, err := initVMs(cli.NewContext(cli.NewApp(), set, nil), tc.kind)
+		if tc.err == "" && err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+		if tc.err != "" && (err == nil || err.Error() != tc.err) {
+			t.Errorf("test %d: wrong error: have %v, want %v", i, err, tc.err)
+		}
+	}
+}
@@ -0,0 +1,317 @@
+// Copyright 2026 Martin Holst Swende
+// This file is part of the goevmlab library.
+//
+// The library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the goevmlab library. If not, see <http://www.gnu.org/licenses/>.
+
+package evms
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// batchMode is the way a batchMaster executes tests.
+type batchMode int
+
+const (
+	stdinMode   batchMode = iota // the paths are fed to one client process on stdin
+	helperMode                   // the paths are fed to a helper process, which executes the client on each
+	processMode                  // one process per test, executed by the client itself
+)
+
+func (m batchMode) String() string {
+	return [...]string{"stdin", "helper", "process"}[m]
+}
+
+var (
+	// helperShell is the shell executing the helper process.
+	helperShell = "sh"
+	// probeTimeout is the time a batch-mode is given to execute the probe test.
+	probeTimeout = 30 * time.Second
+)
+
+// helperScript reads paths from stdin, and executes the command given as
+// arguments on each. Each test is followed by the helperMarker on stderr,
+// which the JsonlScanner ignores, as the line starts with '#'.
+const (
+	helperScript = `while IFS= read -r p; do "$@" "$p" </dev/null; echo '` + helperMarker + `' >&2; done`
+	helperMarker = "#goevmlab: end of test"
+)
+
+// probeTest is the statetest with which the modes are probed. Any client is
+// expected to execute it.
+const probeTest = `{"probe":{"env":{"currentCoinbase":"0x2adc25665018aa1fe0e6bc666dac8fc2697ff9ba","currentDifficulty":"0x0",` +
+	`"currentRandom":"0x0000000000000000000000000000000000000000000000000000000000020000","currentGasLimit":"0x1000000",` +
+	`"currentNumber":"0x1","currentTimestamp":"0x3e8","currentBaseFee":"0x7","currentExcessBlobGas":"0x0"},` +
+	`"pre":{"0xa94f5374fce5edbc8e2a8697c15331677e6ebf0b":{"balance":"0x3b9aca00","nonce":"0x0","code":"0x","storage":{}},` +
+	`"0x00000000000000000000000000000000000000f1":{"balance":"0x0","nonce":"0x0","code":"0x600160005500","storage":{}}},` +
+	`"transaction":{"gasPrice":"0x10","nonce":"0x0","to":"0x00000000000000000000000000000000000000f1","data":["0x"],` +
+	`"gasLimit":["0x186a0"],"value":["0x0"],"secretKey":"0x45a915e4d060149eb4365960e6a7a45f334393093061116b197e3240065ff2d8",` +
+	`"sender":"0xa94f5374fce5edbc8e2a8697c15331677e6ebf0b"},"post":{"Cancun":[{` +
+	`"hash":"0x711d063d29062d2e338d0300e23611b70c3f565aba632de6bbec66a4cc74225c",` +
+	`"logs":"0x1dcc4de8dec75d7aab85b567b6ccd41ad312451b948a7413f0a142fd40d49347",` +
+	`"indexes":{"data":0,"gas":0,"value":0}}]}}}`
+
+// batchClient is a vm which executes one test per process, and writes its
+// output to standard error.
+type batchClient interface {
+	Evm
+	Binary() string
+	execCommand(path string, args ...string) *exec.Cmd
+	tee(r io.Reader) io.Reader
+	copyUntilEnd(out io.Writer, input io.Reader, subtests int) stateRoot
+}
+
+// batchMaster executes the tests of a batchClient in a long-lived process.
+// Preferably, the paths of the tests are fed to one 'master' client process
+// via standard input, which requires a binary which reads paths from stdin
+// when no paths are given on the command line.
+// If the binary does not support that, the paths are fed to a long-lived
+// helper process instead, which executes the client on each. If that does not
+// work either, the client executes one process per test. The mode is
+// determined by executing a known-good test, before the first test.
+type batchMaster struct {
+	client    batchClient
+	stats     *VMStat
+	traceArgs []string // the arguments preceding the path(s), when tracing
+	rootArgs  []string // the arguments preceding the path(s), when not tracing
+
+	mu     sync.Mutex
+	mode   batchMode
+	probed bool // set once the mode has been determined
+
+	// The master process: the client in stdin-mode, the helper in helper-mode
+	cmd       *exec.Cmd
+	speedTest bool // whether the process was started to produce stateroots only
+	stdin     io.WriteCloser
+	stderr    io.ReadCloser
+	output    io.Reader // the output of a test
+}
+
+func newBatchMaster(client batchClient, stats *VMStat, traceArgs, rootArgs []string) *batchMaster {
+	return &batchMaster{
+		client:    client,
+		stats:     stats,
+		traceArgs: traceArgs,
+		rootArgs:  rootArgs,
+	}
+}
+
+func (m *batchMaster) args(speedTest bool) []string {
+	if speedTest {
+		return slices.Clone(m.rootArgs)
+	}
+	return slices.Clone(m.traceArgs)
+}
+
+// execute runs the test in the current mode, and copies the output to the
+// given writer. It returns false if the tests are to be executed by the client
+// itself, one process per test. This method assumes the lock is held.
+func (m *batchMaster) execute(path string, out io.Writer, speedTest bool) (stateRoot, string, bool, error) {
+	if !m.probed {
+		m.probe(speedTest)
+	}
+	if m.mode == processMode {
+		return stateRoot{}, "", false, nil
+	}
+	root, cmd, err := m.run(path, out, speedTest, true, subtestCount(path), ExecTimeout)
+	return root, cmd, true, err
+}
+
+// probe determines the mode, by executing the probe test in each mode until
+// one yields a stateroot in time. A crash or hang on a later test only
+// restarts the process. This method assumes the lock is held.
+func (m *batchMaster) probe(speedTest bool) {
+	m.probed = true
+	dir, err := os.MkdirTemp("", "probe-")
+	if err != nil {
+		log.Warn("Failed writing probe test", "vm", m.client.Name(), "err", err)
+		m.mode = processMode
+		return
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "probe.json")
+	if err := os.WriteFile(path, []byte(probeTest), 0644); err != nil {
+		log.Warn("Failed writing probe test", "vm", m.client.Name(), "err", err)
+		m.mode = processMode
+		return
+	}
+	timeout := probeTimeout
+	if ExecTimeout > 0 && ExecTimeout < timeout {
+		timeout = ExecTimeout
+	}
+	for ; m.mode != processMode; m.mode++ {
+		_, cmd, err := m.run(path, io.Discard, speedTest, false, 1, timeout)
+		if err == nil {
+			log.Debug("Batch-mode probed", "vm", m.client.Name(), "mode", m.mode)
+			return
+		}
+		log.Warn("Batch-mode not supported, falling back", "vm", m.client.Name(), "mode", m.mode, "cmd", cmd, "err", err)
+		m.stop()
+	}
+}
+
+// run executes the test with the master process, which is started if needed.
+// The raw output is captured only if tee is set.
+func (m *batchMaster) run(path string, out io.Writer, speedTest, tee bool, subtests int, timeout time.Duration) (stateRoot, string, error) {
+	if m.cmd != nil && m.speedTest != speedTest {
+		m.stop()
+	}
+	if m.cmd == nil {
+		if cmd, err := m.start(speedTest); err != nil {
+			return stateRoot{}, cmd.String(), err
+		}
+	}
+	cmd := m.cmd
+	root, err := execBatched(cmd, m.stdin, m.stderr, path, timeout, func() stateRoot {
+		input := m.output
+		if tee {
+			input = m.client.tee(input)
+		}
+		root := m.client.copyUntilEnd(out, input, subtests)
+		if r, ok := m.output.(*testReader); ok {
+			r.next()
+		}
+		return root
+	})
+	if err != nil {
+		m.cmd = nil // restart on next test
+	}
+	return root, cmd.String(), err
+}
+
+// start starts the master process for the current mode.
+func (m *batchMaster) start(speedTest bool) (*exec.Cmd, error) {
+	cmd := m.client.execCommand(m.client.Binary(), m.args(speedTest)...)
+	if m.mode == helperMode {
+		helper := exec.Command(helperShell, append([]string{"-c", helperScript, "goevmlab-helper", cmd.Path}, cmd.Args[1:]...)...)
+		helper.Env = cmd.Env
+		cmd = helper
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return cmd, err
+	}
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return cmd, err
+	}
+	if err = cmd.Start(); err != nil {
+		return cmd, err
+	}
+	m.cmd, m.speedTest, m.stdin, m.stderr = cmd, speedTest, stdin, stderr
+	m.output = stderr
+	if m.mode == helperMode {
+		m.output = &testReader{r: bufio.NewReader(stderr)}
+	}
+	return cmd, nil
+}
+
+// stop kills the master process, if any.
+func (m *batchMaster) stop() {
+	if m.cmd == nil {
+		return
+	}
+	m.stdin.Close()
+	_ = m.cmd.Process.Kill()
+	_ = m.cmd.Wait()
+	m.cmd = nil
+}
+
+// runStateTest implements Evm.RunStateTest for batch-mode vms.
+func (m *batchMaster) runStateTest(path string, out io.Writer, speedTest bool) (*tracingResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t0 := time.Now()
+	_, cmd, ok, err := m.execute(path, out, speedTest)
+	if !ok {
+		return m.client.RunStateTest(path, out, speedTest)
+	}
+	if err != nil {
+		return &tracingResult{Cmd: cmd}, err
+	}
+	duration, slow := m.stats.TraceDone(t0)
+	return &tracingResult{
+		Slow:     slow,
+		ExecTime: duration,
+		Cmd:      cmd,
+	}, nil
+}
+
+// getStateRoot implements Evm.GetStateRoot for batch-mode vms.
+func (m *batchMaster) getStateRoot(path string) (root, command string, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	sRoot, cmd, ok, err := m.execute(path, io.Discard, true)
+	if !ok {
+		return m.client.GetStateRoot(path)
+	}
+	return sRoot.StateRoot, cmd, err
+}
+
+// close stops the master process, after it has finished the pending tests.
+func (m *batchMaster) close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.cmd != nil {
+		m.stdin.Close()
+		_ = m.cmd.Wait()
+		m.cmd = nil
+	}
+}
+
+// testReader reads the output of the helper process up to the helperMarker,
+// so that the output of each test ends like the output of a process.
+type testReader struct {
+	r    *bufio.Reader
+	line []byte // the unread rest of the current line
+	err  error
+	done bool // set when the marker has been read
+}
+
+func (t *testReader) Read(p []byte) (int, error) {
+	if len(t.line) == 0 {
+		if t.done || t.err != nil {
+			return 0, cmp.Or(t.err, io.EOF)
+		}
+		t.line, t.err = t.r.ReadBytes('\n')
+		if bytes.Equal(bytes.TrimSuffix(t.line, []byte("\n")), []byte(helperMarker)) {
+			t.line, t.done = nil, true
+			return 0, io.EOF
+		}
+		if len(t.line) == 0 {
+			return 0, cmp.Or(t.err, io.EOF)
+		}
+	}
+	n := copy(p, t.line)
+	t.line = t.line[n:]
+	return n, nil
+}
+
+// next discards the rest of the output of the current test, and prepares for
+// the next one.
+func (t *testReader) next() {
+	_, _ = io.Copy(io.Discard, t)
+	t.line, t.done = nil, false
+}
@@ -0,0 +1,196 @@
+// Copyright 2026 Martin Holst Swende
+// This file is part of the goevmlab library.
+//
+// The library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the goevmlab library. If not, see <http://www.gnu.org/licenses/>.
+
+package evms
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// fakeBatchClient is a client binary which 'executes' a test by printing the
+// recording next to it, or a stateroot if there is none, as for the probe
+// test. A test named crash.json makes it exit without output. It takes the
+// last argument as the path; if that is not a test, it executes the given
+// command.
+const fakeBatchClient = `#!/bin/sh
+out() {
+	case "$1" in *crash.json) exit 1 ;; esac
+	if [ -f "$1.rec" ]; then cat "$1.rec" >&2; else echo '{"stateRoot":"0x01"}' >&2; fi
+}
+for p; do :; done
+case "$p" in
+*.json) out "$p" ;;
+*) %v ;;
+esac
+`
+
+// batchRecordings returns the recorded outputs of the client for the cases,
+// keyed by case. If a recorded batch-mode output exists in testdata/batch,
+// that is split into the outputs of the cases.
+func batchRecordings(t *testing.T, client string) ([]string, map[string][]byte) {
+	t.Helper()
+	finfos, err := os.ReadDir(filepath.Join("testdata", "cases"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		names      []string
+		recordings = make(map[string][]byte)
+	)
+	for _, finfo := range finfos {
+		if finfo.Name() == "eofcode.json" {
+			continue // see TestBatchStream
+		}
+		data, err := os.ReadFile(filepath.Join("testdata", "traces", fmt.Sprintf("%v.%v.stderr.txt", finfo.Name(), client)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, finfo.Name())
+		recordings[finfo.Name()] = data
+	}
+	data, err := os.ReadFile(filepath.Join("testdata", "batch", client+".stderr.txt"))
+	if err != nil {
+		t.Logf("%v: no batch-mode recording, using the single recordings", client)
+		return names, recordings
+	}
+	for _, name := range names {
+		end := bytes.Index(data, []byte(`"stateRoot"`))
+		if end == -1 {
+			t.Fatalf("%v: batch-mode recording ends before %v", client, name)
+		}
+		if nl := bytes.IndexByte(data[end:], '\n'); nl != -1 {
+			end += nl + 1
+		} else {
+			end = len(data)
+		}
+		recordings[name], data = data[:end], data[end:]
+	}
+	return names, recordings
+}
+
+// TestBatchMaster executes the recorded outputs of evmone and revme with fake
+// binaries, which support the different modes of the batch master.
+func TestBatchMaster(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil || runtime.GOOS == "windows" {
+		t.Skip("sh not available")
+	}
+	defer func(timeout time.Duration) { probeTimeout = timeout }(probeTimeout)
+	probeTimeout = 500 * time.Millisecond
+
+	for _, client := range []struct {
+		name  string
+		newVM func(path, name string) Evm
+	}{
+		{"evmone", NewEvmoneBatchVM},
+		{"revm", NewRethBatchVM},
+	} {
+		names, recordings := batchRecordings(t, client.name)
+		dir := t.TempDir()
+		var tests []string
+		for _, name := range append(names, "crash.json") {
+			path := filepath.Join(dir, name)
+			data, _ := os.ReadFile(filepath.Join("testdata", "cases", name))
+			if err := os.WriteFile(path, data, 0644); err != nil {
+				t.Fatal(err)
+			}
+			if rec, ok := recordings[name]; ok {
+				if err := os.WriteFile(path+".rec", rec, 0644); err != nil {
+					t.Fatal(err)
+				}
+				tests = append(tests, path)
+			}
+			RegisterTest(path)
+			defer ForgetTest(path)
+		}
+		for _, tc := range []struct {
+			noPath string // what the binary does without a path
+			shell  string
+			mode   batchMode
+		}{
+			// reads paths from stdin
+			{`while IFS= read -r p; do out "$p"; done`, "sh", stdinMode},
+			// hangs, which the probe must survive
+			{`sleep 10`, "sh", helperMode},
+			{`sleep 10`, "/nonexistent/sh", processMode},
+		} {
+			helperShell = tc.shell
+			bin := filepath.Join(t.TempDir(), client.name)
+			if err := os.WriteFile(bin, fmt.Appendf(nil, fakeBatchClient, tc.noPath), 0755); err != nil {
+				t.Fatal(err)
+			}
+			vm := client.newVM(bin, client.name).Instance(0)
+			for i := 0; i < 2; i++ {
+				var master *exec.Cmd // the long-lived process, if any
+				for _, path := range tests {
+					want := new(bytes.Buffer)
+					vm.Copy(want, bytes.NewReader(recordings[filepath.Base(path)]))
+					have := new(bytes.Buffer)
+					if _, err := vm.RunStateTest(path, have, false); err != nil {
+						t.Fatalf("%v, mode %v: %v", client.name, tc.mode, err)
+					}
+					if !bytes.Equal(have.Bytes(), want.Bytes()) {
+						t.Fatalf("%v, mode %v: output differs\nhave:\n%s\nwant:\n%s", client.name, tc.mode, have, want)
+					}
+					if cmd := batchMasterOf(vm).cmd; master == nil {
+						master = cmd
+					} else if cmd != master {
+						t.Fatalf("%v, mode %v: process not reused", client.name, tc.mode)
+					}
+				}
+				for _, path := range tests {
+					root, _, err := vm.GetStateRoot(path)
+					if err != nil {
+						t.Fatalf("%v, mode %v: %v", client.name, tc.mode, err)
+					}
+					if wantRoot, _ := vm.ParseStateRoot(recordings[filepath.Base(path)]); root != wantRoot {
+						t.Fatalf("%v, mode %v: wrong root %v, want %v", client.name, tc.mode, root, wantRoot)
+					}
+				}
+				// A crashing test does not change the mode
+				if tc.mode != processMode {
+					crash := filepath.Join(dir, "crash.json")
+					var crashErr *CrashError
+					if _, err := vm.RunStateTest(crash, new(bytes.Buffer), false); !errors.As(err, &crashErr) {
+						t.Fatalf("%v, mode %v: crash not reported: %v", client.name, tc.mode, err)
+					}
+				}
+			}
+			if mode := batchMasterOf(vm).mode; mode != tc.mode {
+				t.Errorf("%v: wrong mode: have %v, want %v", client.name, mode, tc.mode)
+			}
+			vm.Close()
+		}
+		helperShell = "sh"
+	}
+}
+
+func batchMasterOf(vm Evm) *batchMaster {
+	switch vm := vm.(type) {
+	case *EvmoneBatchVM:
+		return vm.batch
+	case *RethBatchVM:
+		return vm.batch
+	}
+	return nil
+}
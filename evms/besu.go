@@ -18,7 +18,6 @@
 package evms
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -26,8 +25,6 @@ import (
 	"os/exec"
 	"strings"
 	"time"
-
-	"github.com/ethereum/go-ethereum/log"
 )
 
 // BesuVM is s Evm-interface wrapper around the `evmtool` binary, based on Besu.
@@ -36,6 +33,8 @@ type BesuVM struct {
 	name string // in case multiple instances are used
 	// Some metrics
 	stats *VMStat
+
+	cmdOpts // extra arguments and environment for the binary
 }
 
 func NewBesuVM(path, name string) Evm {
@@ -54,6 +53,21 @@ func (evm *BesuVM) Name() string {
 	return evm.name
 }
 
+// Kind implements ClientInfo.
+func (evm *BesuVM) Kind() string {
+	return "besu"
+}
+
+// Binary implements ClientInfo.
+func (evm *BesuVM) Binary() string {
+	return evm.path
+}
+
+// Version implements ClientInfo.
+func (evm *BesuVM) Version() (string, error) {
+	return binaryVersion(evm.execCommand(evm.path, "--version"))
+}
+
 // RunStateTest implements the Evm interface
 func (evm *BesuVM) RunStateTest(path string, out io.Writer, speedTest bool) (*tracingResult, error) {
 	var (
@@ -63,9 +77,9 @@ func (evm *BesuVM) RunStateTest(path string, out io.Writer, speedTest bool) (*tr
 		cmd    *exec.Cmd
 	)
 	if speedTest {
-		cmd = exec.Command(evm.path, "--nomemory", "--notime", "state-test", path)
+		cmd = evm.execCommand(evm.path, "--nomemory", "--notime", "state-test", path)
 	} else {
-		cmd = exec.Command(evm.path, "--nomemory", "--notime", "--json", "state-test", path) // exclude memory
+		cmd = evm.execCommand(evm.path, "--nomemory", "--notime", "--json", "state-test", path) // exclude memory
 	}
 	if stdout, err = cmd.StdoutPipe(); err != nil {
 		return &tracingResult{Cmd: cmd.String()}, err
@@ -73,10 +87,13 @@ func (evm *BesuVM) RunStateTest(path string, out io.Writer, speedTest bool) (*tr
 	if err = cmd.Start(); err != nil {
 		return &tracingResult{Cmd: cmd.String()}, err
 	}
+	var root stateRoot
 	// copy everything to the given writer
-	evm.Copy(out, stdout)
-	_, _ = io.ReadAll(stdout)
-	err = cmd.Wait()
+	err = waitTimed(cmd, stdout, ExecTimeout, func() {
+		root = evm.copyUntilEnd(out, evm.tee(stdout), subtestCount(path))
+		_, _ = io.ReadAll(stdout)
+	})
+	err = checkExit(cmd, root, err)
 	// release resources
 	duration, slow := evm.stats.TraceDone(t0)
 
@@ -91,17 +108,10 @@ func (evm *BesuVM) Close() {}
 
 func (evm *BesuVM) GetStateRoot(path string) (root, command string, err error) {
 	// Run without tracing
-	cmd := exec.Command(evm.path, "--nomemory", "--notime", "state-test", path)
+	cmd := evm.execCommand(evm.path, "--nomemory", "--notime", "state-test", path)
 
-	data, err := cmd.Output()
-	if err != nil {
-		return "", cmd.String(), err
-	}
-	root, err = evm.ParseStateRoot(data)
-	if err != nil {
-		log.Error("Failed to find stateroot", "vm", evm.Name(), "cmd", cmd.String())
-		return "", cmd.String(), err
-	}
+	data, err := outputTimed(cmd, false, ExecTimeout)
+	root, err = checkRoot(cmd, data, err, evm.ParseStateRoot)
 	return root, cmd.String(), err
 }
 
@@ -125,23 +135,31 @@ func (evm *BesuVM) ParseStateRoot(data []byte) (string, error) {
 // Copy feed reads from the reader, does some geth-specific filtering and
 // outputs items onto the channel
 func (evm *BesuVM) Copy(out io.Writer, input io.Reader) {
-	evm.copyUntilEnd(out, input)
+	evm.copyUntilEnd(out, input, 1)
 }
 
-func (evm *BesuVM) copyUntilEnd(out io.Writer, input io.Reader) stateRoot {
+func (evm *BesuVM) copyUntilEnd(out io.Writer, input io.Reader, subtests int) stateRoot {
 	scanner := NewJsonlScanner("besu", input, os.Stderr)
 	defer scanner.Release()
-	var stateRoot stateRoot
+	roots := &stateRoots{want: subtests}
 	var elem opLog
 	for scanner.Next(&elem) == nil {
-		// If we have a stateroot, we're done
+		// If we have a stateroot, the subtest is done
 		if len(elem.StateRoot1) != 0 {
-			stateRoot.StateRoot = elem.StateRoot1
-			break
+			root := elem.StateRoot1
+			elem = opLog{}
+			if roots.add(out, root) {
+				break
+			}
+			continue
 		}
 		if len(elem.StateRoot2) != 0 {
-			stateRoot.StateRoot = elem.StateRoot2
-			break
+			root := elem.StateRoot2
+			elem = opLog{}
+			if roots.add(out, root) {
+				break
+			}
+			continue
 		}
 		// When geth encounters end of code, it continues anyway, on a 'virtual' STOP.
 		// In order to handle that, we need to drop all STOP opcodes.
@@ -151,16 +169,12 @@ func (evm *BesuVM) copyUntilEnd(out io.Writer, input io.Reader) stateRoot {
 		outp := CustomMarshal(&elem)
 		if _, err := out.Write(append(outp, '\n')); err != nil {
 			fmt.Fprintf(os.Stderr, "Error writing to out: %v\n", err)
-			return stateRoot
+			return stateRoot{}
 		}
 		elem.FunctionDepth = 0 // function depth is optional and "gets dirty" if not set
 		elem.Section = 0
 	}
-	root, _ := json.Marshal(stateRoot)
-	if _, err := out.Write(append(root, '\n')); err != nil {
-		fmt.Fprintf(os.Stderr, "Error writing to out: %v\n", err)
-	}
-	return stateRoot
+	return roots.finish(out)
 }
 
 func (evm *BesuVM) Stats() []any {
@@ -47,9 +47,10 @@ func NewBesuBatchVM(path, name string) Evm {
 func (evm *BesuBatchVM) Instance(threadID int) Evm {
 	return &BesuBatchVM{
 		BesuVM: BesuVM{
-			path:  evm.path,
-			name:  fmt.Sprintf("%v-%d", evm.name, threadID),
-			stats: evm.stats,
+			path:    evm.path,
+			name:    fmt.Sprintf("%v-%d", evm.name, threadID),
+			stats:   evm.stats,
+			cmdOpts: evm.cmdOpts,
 		},
 	}
 }
@@ -63,11 +64,13 @@ func (evm *BesuBatchVM) RunStateTest(path string, out io.Writer, speedTest bool)
 		stdout io.ReadCloser
 		stdin  io.WriteCloser
 	)
+	evm.mu.Lock()
+	defer evm.mu.Unlock()
 	if evm.cmd == nil {
 		if speedTest {
-			cmd = exec.Command(evm.path, "--nomemory", "--notime", "state-test")
+			cmd = evm.execCommand(evm.path, "--nomemory", "--notime", "state-test")
 		} else {
-			cmd = exec.Command(evm.path, "--nomemory", "--notime", "--json", "state-test")
+			cmd = evm.execCommand(evm.path, "--nomemory", "--notime", "--json", "state-test")
 		}
 		if stdout, err = cmd.StdoutPipe(); err != nil {
 			return &tracingResult{Cmd: cmd.String()}, err
@@ -82,12 +85,14 @@ func (evm *BesuBatchVM) RunStateTest(path string, out io.Writer, speedTest bool)
 		evm.stdout = stdout
 		evm.stdin = stdin
 	}
-	evm.mu.Lock()
-	defer evm.mu.Unlock()
-	_, _ = fmt.Fprintf(evm.stdin, "%v\n", path)
-
 	// copy everything for the _current_ statetest to the given writer
-	evm.copyUntilEnd(out, evm.stdout)
+	_, err = execBatched(evm.cmd, evm.stdin, evm.stdout, path, ExecTimeout, func() stateRoot {
+		return evm.copyUntilEnd(out, evm.tee(evm.stdout), subtestCount(path))
+	})
+	if err != nil {
+		cmd, evm.cmd = evm.cmd, nil // restart on next test
+		return &tracingResult{Cmd: cmd.String()}, err
+	}
 	duration, slow := evm.stats.TraceDone(t0)
 	return &tracingResult{
 		Slow:     slow,
@@ -106,8 +111,10 @@ func (evm *BesuBatchVM) Close() {
 }
 
 func (evm *BesuBatchVM) GetStateRoot(path string) (root, command string, err error) {
+	evm.mu.Lock()
+	defer evm.mu.Unlock()
 	if evm.cmd == nil {
-		evm.cmd = exec.Command(evm.path, "--nomemory", "--notime", "state-test")
+		evm.cmd = evm.execCommand(evm.path, "--nomemory", "--notime", "state-test")
 		// The stateroot is delivered on stdout
 		if evm.stdout, err = evm.cmd.StdoutPipe(); err != nil {
 			return "", evm.cmd.String(), err
@@ -119,9 +126,12 @@ func (evm *BesuBatchVM) GetStateRoot(path string) (root, command string, err err
 			return "", evm.cmd.String(), err
 		}
 	}
-	evm.mu.Lock()
-	defer evm.mu.Unlock()
-	_, _ = fmt.Fprintf(evm.stdin, "%v\n", path)
-	sRoot := evm.copyUntilEnd(io.Discard, evm.stdout)
-	return sRoot.StateRoot, evm.cmd.String(), nil
+	command = evm.cmd.String()
+	sRoot, err := execBatched(evm.cmd, evm.stdin, evm.stdout, path, ExecTimeout, func() stateRoot {
+		return evm.copyUntilEnd(io.Discard, evm.stdout, subtestCount(path))
+	})
+	if err != nil {
+		evm.cmd = nil // restart on next test
+	}
+	return sRoot.StateRoot, command, err
 }
@@ -0,0 +1,239 @@
+// Copyright 2026 Martin Holst Swende
+// This file is part of the goevmlab library.
+//
+// The library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the goevmlab library. If not, see <http://www.gnu.org/licenses/>.
+
+package evms
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// blockRunner describes how a client executes blockchain tests.
+type blockRunner struct {
+	kind string
+	args []string // the arguments preceding the path of the test
+	// parse reads the outcome of the test from the output. The root is empty
+	// if the client does not report it.
+	parse func(data []byte) (pass bool, root string)
+}
+
+var (
+	// geth: evm blocktest <path>
+	gethBlockRunner = blockRunner{"gethblock", []string{"blocktest"}, parseResultList}
+	// besu: evmtool block-test <path>
+	besuBlockRunner = blockRunner{"besublock", []string{"block-test"}, parseBesuBlockTest}
+	// nethermind: nethtest --blockTest --input <path>
+	nethermindBlockRunner = blockRunner{"nethermindblock", []string{"--blockTest", "--input"}, parseResultList}
+)
+
+// BlockTestVM is an Evm-interface wrapper around the blocktest runner of a
+// client. It executes blockchain tests instead of statetests (see
+// fuzzing.BtMaker). Block-level execution is not traced: the canonical
+// output is the post-stateroot of the chain if the client accepts it, and an
+// empty stateroot if it rejects the chain.
+type BlockTestVM struct {
+	path   string
+	name   string
+	runner blockRunner
+	// Some metrics
+	stats *VMStat
+
+	cmdOpts // extra arguments and environment for the binary
+}
+
+// NewGethBlockVM returns a vm executing blockchain tests with the geth `evm` binary.
+func NewGethBlockVM(path, name string) Evm {
+	return &BlockTestVM{path: path, name: name, runner: gethBlockRunner, stats: new(VMStat)}
+}
+
+// NewBesuBlockVM returns a vm executing blockchain tests with the besu `evmtool` binary.
+func NewBesuBlockVM(path, name string) Evm {
+	return &BlockTestVM{path: path, name: name, runner: besuBlockRunner, stats: new(VMStat)}
+}
+
+// NewNethermindBlockVM returns a vm executing blockchain tests with the nethermind `nethtest` binary.
+func NewNethermindBlockVM(path, name string) Evm {
+	return &BlockTestVM{path: path, name: name, runner: nethermindBlockRunner, stats: new(VMStat)}
+}
+
+func (evm *BlockTestVM) Instance(int) Evm {
+	return evm
+}
+
+func (evm *BlockTestVM) Name() string {
+	return evm.name
+}
+
+// Kind implements ClientInfo.
+func (evm *BlockTestVM) Kind() string {
+	return evm.runner.kind
+}
+
+// Binary implements ClientInfo.
+func (evm *BlockTestVM) Binary() string {
+	return evm.path
+}
+
+// Version implements ClientInfo.
+func (evm *BlockTestVM) Version() (string, error) {
+	return binaryVersion(evm.execCommand(evm.path, "--version"))
+}
+
+func (evm *BlockTestVM) command(path string) []string {
+	return append(append([]string{}, evm.runner.args...), path)
+}
+
+// RunStateTest implements the Evm interface. The test at the path must be a
+// blockchain test.
+func (evm *BlockTestVM) RunStateTest(path string, out io.Writer, _ bool) (*tracingResult, error) {
+	var (
+		t0     = time.Now()
+		stdout io.ReadCloser
+		err    error
+		cmd    = evm.execCommand(evm.path, evm.command(path)...)
+	)
+	if stdout, err = cmd.StdoutPipe(); err != nil {
+		return &tracingResult{Cmd: cmd.String()}, err
+	}
+	if err = cmd.Start(); err != nil {
+		return &tracingResult{Cmd: cmd.String()}, err
+	}
+	err = waitTimed(cmd, stdout, ExecTimeout, func() {
+		data, _ := io.ReadAll(evm.tee(stdout))
+		writeStateRoot(out, evm.result(data, path))
+	})
+	if _, ok := err.(*CrashError); !ok {
+		err = nil // a rejected chain may give a non-zero exit code
+	}
+	duration, slow := evm.stats.TraceDone(t0)
+	return &tracingResult{
+		Slow:     slow,
+		ExecTime: duration,
+		Cmd:      cmd.String()}, err
+}
+
+// result returns the post-stateroot of the test at the path, or an empty
+// stateroot if the test failed. If the client does not report the stateroot,
+// the one expected by the test is used.
+func (evm *BlockTestVM) result(data []byte, path string) string {
+	pass, root := evm.runner.parse(data)
+	if !pass {
+		return ""
+	}
+	if root == "" {
+		root = expectedRoot(path)
+	}
+	return root
+}
+
+// GetStateRoot runs the test and returns the stateroot.
+func (evm *BlockTestVM) GetStateRoot(path string) (root, command string, err error) {
+	cmd := evm.execCommand(evm.path, evm.command(path)...)
+	data, err := outputTimed(cmd, false, ExecTimeout)
+	if _, ok := err.(*CrashError); ok {
+		return "", cmd.String(), err
+	}
+	if root = evm.result(data, path); root == "" {
+		return "", cmd.String(), fmt.Errorf("%v: test failed", evm.Name())
+	}
+	return root, cmd.String(), nil
+}
+
+// ParseStateRoot reads the stateroot from the output.
+func (evm *BlockTestVM) ParseStateRoot(data []byte) (string, error) {
+	pass, root := evm.runner.parse(data)
+	if !pass || root == "" {
+		return "", fmt.Errorf("%v: no stateroot found", evm.Name())
+	}
+	return root, nil
+}
+
+// Copy reads the raw output and writes the canonical output. Since the test
+// is not known, a client which does not report the stateroot yields an
+// empty stateroot.
+func (evm *BlockTestVM) Copy(out io.Writer, input io.Reader) {
+	data, _ := io.ReadAll(input)
+	root := ""
+	if pass, r := evm.runner.parse(data); pass {
+		root = r
+	}
+	writeStateRoot(out, root)
+}
+
+func (evm *BlockTestVM) Close() {}
+
+func (evm *BlockTestVM) Stats() []any {
+	return evm.stats.Stats()
+}
+
+// parseResultList parses the list of test results, as output by geth and
+// nethermind: [{"name": ..., "pass": true, "stateRoot": "0x..."}].
+func parseResultList(data []byte) (bool, string) {
+	for i := bytes.IndexByte(data, '['); i >= 0; {
+		var results []struct {
+			Pass      bool   `json:"pass"`
+			StateRoot string `json:"stateRoot"`
+		}
+		if err := json.NewDecoder(bytes.NewReader(data[i:])).Decode(&results); err == nil {
+			if len(results) == 0 {
+				return false, ""
+			}
+			for _, r := range results {
+				if !r.Pass {
+					return false, ""
+				}
+			}
+			return true, results[0].StateRoot
+		}
+		next := bytes.IndexByte(data[i+1:], '[')
+		if next < 0 {
+			break
+		}
+		i += next + 1
+	}
+	return false, ""
+}
+
+// parseBesuBlockTest parses the output of besu, which reports the import of
+// the chain, but not the stateroot.
+func parseBesuBlockTest(data []byte) (bool, string) {
+	pass := bytes.Contains(data, []byte("Chain import successful")) &&
+		!bytes.Contains(data, []byte("Chain import failed"))
+	return pass, ""
+}
+
+// expectedRoot returns the post-stateroot of the blockchain test at the
+// path, or an empty stateroot if it cannot be read.
+func expectedRoot(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	var testsByName map[string]struct {
+		PostHash string `json:"postStateHash"`
+	}
+	if err := json.Unmarshal(data, &testsByName); err != nil {
+		return ""
+	}
+	for _, test := range testsByName {
+		return test.PostHash
+	}
+	return ""
+}
@@ -0,0 +1,113 @@
+// Copyright 2026 Martin Holst Swende
+// This file is part of the goevmlab library.
+//
+// The library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the goevmlab library. If not, see <http://www.gnu.org/licenses/>.
+
+package evms
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// Output of `evm blocktest`, which prints the results to stdout (and logs to
+// stderr).
+const (
+	gethBlockPass = `[
+  {
+    "name": "t",
+    "pass": true,
+    "stateRoot": "0xd2776313ccdd35e79914efdbc5dcfc3908531f9d0c11f4702a2c2fc87b799080",
+    "fork": "Cancun"
+  }
+]
+`
+	gethBlockFail = `[
+  {
+    "name": "t",
+    "pass": false,
+    "fork": "Cancun",
+    "error": "last block hash validation mismatch: want: f565a3f155a8994bb185d96227702177b7d7103a567ffb45e056ce2e38daaf21, have: 1565a3f155a8994bb185d96227702177b7d7103a567ffb45e056ce2e38daaf21"
+  }
+]
+`
+)
+
+func TestParseBlockTestOutput(t *testing.T) {
+	for i, tc := range []struct {
+		parse func([]byte) (bool, string)
+		data  string
+		pass  bool
+		root  string
+	}{
+		{parseResultList, gethBlockPass, true, "0xd2776313ccdd35e79914efdbc5dcfc3908531f9d0c11f4702a2c2fc87b799080"},
+		{parseResultList, gethBlockFail, false, ""},
+		{parseResultList, "Running tests [1/1]\n" + gethBlockPass, true, "0xd2776313ccdd35e79914efdbc5dcfc3908531f9d0c11f4702a2c2fc87b799080"},
+		{parseResultList, "[]", false, ""},
+		{parseResultList, "", false, ""},
+		{parseBesuBlockTest, "Considering t\nChain import successful - t\n", true, ""},
+		{parseBesuBlockTest, "Considering t\nChain import failed - t\n", false, ""},
+	} {
+		pass, root := tc.parse([]byte(tc.data))
+		if pass != tc.pass || root != tc.root {
+			t.Errorf("test %d: have %v %q, want %v %q", i, pass, root, tc.pass, tc.root)
+		}
+	}
+}
+
+// fakeBlockRunner prints the output of the given file, after checking the
+// arguments.
+const fakeBlockRunner = `#!/bin/sh
+[ "$1" = "block-test" ] || exit 1
+cat "$(dirname "$0")/output"
+exit 1
+`
+
+func TestBlockTestVM(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	dir := t.TempDir()
+	bin := filepath.Join(dir, "evmtool")
+	if err := os.WriteFile(bin, []byte(fakeBlockRunner), 0755); err != nil {
+		t.Fatal(err)
+	}
+	test := filepath.Join(dir, "test.json")
+	if err := os.WriteFile(test, []byte(`{"t":{"postStateHash":"0x0102"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	vm := NewBesuBlockVM(bin, "besublock-0")
+	for _, tc := range []struct {
+		output string
+		want   string
+	}{
+		// besu does not report the stateroot, so the expected one is used
+		{"Chain import successful - t\n", `{"stateRoot":"0x0102"}` + "\n"},
+		{"Chain import failed - t\n", `{"stateRoot":""}` + "\n"},
+	} {
+		if err := os.WriteFile(filepath.Join(dir, "output"), []byte(tc.output), 0644); err != nil {
+			t.Fatal(err)
+		}
+		out := new(bytes.Buffer)
+		if _, err := vm.RunStateTest(test, out, false); err != nil {
+			t.Fatal(err)
+		}
+		if have := out.String(); have != tc.want {
+			t.Errorf("wrong output\nhave: %v\nwant: %v", have, tc.want)
+		}
+	}
+}
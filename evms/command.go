@@ -0,0 +1,83 @@
+// Copyright 2026 Martin Holst Swende
+// This file is part of the goevmlab library.
+//
+// The library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the goevmlab library. If not, see <http://www.gnu.org/licenses/>.
+
+package evms
+
+import (
+	"io"
+	"os"
+	"os/exec"
+)
+
+// CommandOptions is implemented by vms which execute an external binary. It
+// allows passing extra arguments and environment variables to the binary.
+type CommandOptions interface {
+	// SetCommandOptions sets the extra arguments, which are placed directly
+	// after the binary, and the environment variables, in the form "key=value",
+	// which are added to the environment of the process.
+	SetCommandOptions(args []string, env []string)
+}
+
+// RawCapturer is implemented by vms which can capture the raw output of the
+// client, as it is before being converted into the canonical format.
+type RawCapturer interface {
+	// CaptureRaw makes the subsequent executions also write the raw output
+	// to the writer. A nil writer stops the capturing. It must not be called
+	// while the vm is executing.
+	CaptureRaw(w io.Writer)
+}
+
+// rawTee implements RawCapturer.
+type rawTee struct {
+	raw io.Writer
+}
+
+// CaptureRaw implements RawCapturer.
+func (t *rawTee) CaptureRaw(w io.Writer) {
+	t.raw = w
+}
+
+// tee returns a reader which also writes everything read to the raw output,
+// if it is being captured.
+func (t *rawTee) tee(r io.Reader) io.Reader {
+	if t.raw == nil {
+		return r
+	}
+	return io.TeeReader(r, t.raw)
+}
+
+// cmdOpts holds the extra arguments and environment variables for a vm binary,
+// and the capturing of the raw output.
+type cmdOpts struct {
+	rawTee
+	args []string
+	env  []string
+}
+
+// SetCommandOptions implements CommandOptions.
+func (o *cmdOpts) SetCommandOptions(args []string, env []string) {
+	o.args, o.env = args, env
+}
+
+// execCommand returns the command to execute the binary with the given
+// arguments, with the extra arguments and environment applied.
+func (o *cmdOpts) execCommand(path string, args ...string) *exec.Cmd {
+	cmd := exec.Command(path, append(append([]string{}, o.args...), args...)...)
+	if len(o.env) > 0 {
+		cmd.Env = append(os.Environ(), o.env...)
+	}
+	return cmd
+}
@@ -0,0 +1,334 @@
+// Copyright 2026 Martin Holst Swende
+// This file is part of the goevmlab library.
+//
+// The library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the goevmlab library. If not, see <http://www.gnu.org/licenses/>.
+
+package evms
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"math/bits"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// The canonical trace carries no error messages, so errors are inferred from
+// the way a frame ends.
+const (
+	CoverageRevert = "revert" // the frame ended with REVERT
+	CoverageHalt   = "halt"   // the frame ended with an exceptional halt
+)
+
+// CoverageKey is an (opcode, depth, error) tuple. The error is set on the last
+// operation of a frame, if the frame did not end successfully.
+type CoverageKey struct {
+	Op    string `json:"op"`
+	Depth int    `json:"depth"`
+	Error string `json:"error,omitempty"`
+}
+
+// Coverage holds the counts of what a set of canonical traces hit.
+type Coverage struct {
+	Tests       uint64
+	Steps       uint64
+	Ops         map[CoverageKey]uint64
+	Precompiles map[common.Address]uint64 // calls into precompiles
+
+	// GasBuckets holds, per tuple, a bitmask of the gas buckets it was hit
+	// in. The bucket of a step is the bit-length of the remaining gas.
+	GasBuckets map[CoverageKey]uint64
+}
+
+func NewCoverage() *Coverage {
+	return &Coverage{
+		Ops:         make(map[CoverageKey]uint64),
+		Precompiles: make(map[common.Address]uint64),
+		GasBuckets:  make(map[CoverageKey]uint64),
+	}
+}
+
+// GasBucket returns the gas bucket of a step with the given remaining gas.
+func GasBucket(gas uint64) int {
+	return min(bits.Len64(gas), 63)
+}
+
+// Merge adds the counts of other to c, and returns the number of tuples and
+// precompiles which were not previously covered.
+func (c *Coverage) Merge(other *Coverage) int {
+	var added int
+	c.Tests += other.Tests
+	c.Steps += other.Steps
+	for k, v := range other.Ops {
+		if _, ok := c.Ops[k]; !ok {
+			added++
+		}
+		c.Ops[k] += v
+	}
+	for k, v := range other.Precompiles {
+		if _, ok := c.Precompiles[k]; !ok {
+			added++
+		}
+		c.Precompiles[k] += v
+	}
+	for k, v := range other.GasBuckets {
+		c.GasBuckets[k] |= v
+	}
+	return added
+}
+
+type coverageOpJSON struct {
+	CoverageKey
+	Count      uint64 `json:"count"`
+	GasBuckets uint64 `json:"gasBuckets,omitempty"`
+}
+
+type coverageJSON struct {
+	Tests       uint64                    `json:"tests"`
+	Steps       uint64                    `json:"steps"`
+	Ops         []coverageOpJSON          `json:"ops"`
+	Precompiles map[common.Address]uint64 `json:"precompiles"`
+}
+
+// MarshalJSON encodes the tuples as a list, sorted by opcode, depth and error.
+func (c *Coverage) MarshalJSON() ([]byte, error) {
+	enc := coverageJSON{Tests: c.Tests, Steps: c.Steps, Precompiles: c.Precompiles, Ops: []coverageOpJSON{}}
+	for k, v := range c.Ops {
+		enc.Ops = append(enc.Ops, coverageOpJSON{k, v, c.GasBuckets[k]})
+	}
+	slices.SortFunc(enc.Ops, func(a, b coverageOpJSON) int {
+		return cmp.Or(cmp.Compare(a.Op, b.Op), cmp.Compare(a.Depth, b.Depth), cmp.Compare(a.Error, b.Error))
+	})
+	return json.Marshal(enc)
+}
+
+func (c *Coverage) UnmarshalJSON(data []byte) error {
+	var dec coverageJSON
+	if err := json.Unmarshal(data, &dec); err != nil {
+		return err
+	}
+	*c = *NewCoverage()
+	c.Tests, c.Steps = dec.Tests, dec.Steps
+	for _, op := range dec.Ops {
+		c.Ops[op.CoverageKey] = op.Count
+		if op.GasBuckets != 0 {
+			c.GasBuckets[op.CoverageKey] = op.GasBuckets
+		}
+	}
+	for k, v := range dec.Precompiles {
+		c.Precompiles[k] = v
+	}
+	return nil
+}
+
+// Summary returns a human-readable summary of the coverage.
+func (c *Coverage) Summary() string {
+	var (
+		ops      = make(map[string]bool)
+		errs     = make(map[string]uint64)
+		maxDepth int
+		missing  []string
+	)
+	for k, v := range c.Ops {
+		ops[k.Op] = true
+		if k.Error != "" {
+			errs[k.Error] += v
+		}
+		maxDepth = max(maxDepth, k.Depth)
+	}
+	for i := range 256 {
+		name := vm.OpCode(i).String()
+		if !strings.Contains(name, "not defined") && !ops[name] {
+			missing = append(missing, name)
+		}
+	}
+	var hit []string
+	for _, addr := range vm.PrecompiledAddressesOsaka {
+		if n := c.Precompiles[addr]; n > 0 {
+			hit = append(hit, fmt.Sprintf("%#x: %d", addr.Big(), n))
+		}
+	}
+	out := new(strings.Builder)
+	fmt.Fprintf(out, "Coverage: %d tests, %d steps\n", c.Tests, c.Steps)
+	fmt.Fprintf(out, "  tuples:      %d (opcode, depth, error)\n", len(c.Ops))
+	fmt.Fprintf(out, "  opcodes:     %d\n", len(ops))
+	if len(missing) > 0 {
+		fmt.Fprintf(out, "  not hit:     %v\n", strings.Join(missing, ", "))
+	}
+	fmt.Fprintf(out, "  max depth:   %d\n", maxDepth)
+	fmt.Fprintf(out, "  errors:      %v: %d, %v: %d\n", CoverageRevert, errs[CoverageRevert], CoverageHalt, errs[CoverageHalt])
+	fmt.Fprintf(out, "  precompiles: %d of %d [%v]\n", len(hit), len(vm.PrecompiledAddressesOsaka), strings.Join(hit, ", "))
+	return out.String()
+}
+
+// isPrecompile returns true if the address is a precompile in any fork.
+func isPrecompile(addr common.Address) bool {
+	return slices.Contains(vm.PrecompiledAddressesOsaka, addr)
+}
+
+// coverageStep is the part of a canonical trace line needed for coverage.
+type coverageStep struct {
+	depth  int
+	gas    uint64
+	op     string
+	stack0 string // the top stack item
+	stack1 string // the second stack item
+}
+
+// parseCoverageStep extracts the depth, remaining gas, opcode name and the top
+// two stack items from a line of canonical output, as produced by CustomMarshal. The
+// line is not fully decoded, as this is executed on every step of the trace.
+func parseCoverageStep(line []byte, step *coverageStep) bool {
+	if !bytes.HasPrefix(line, []byte(`{"depth":`)) {
+		return false
+	}
+	line = line[len(`{"depth":`):]
+	end := bytes.IndexByte(line, ',')
+	if end < 0 {
+		return false
+	}
+	depth, err := strconv.Atoi(string(line[:end]))
+	if err != nil {
+		return false
+	}
+	step.depth = depth
+	start := bytes.Index(line, []byte(`"gas":`))
+	if start < 0 {
+		return false
+	}
+	line = line[start+len(`"gas":`):]
+	if end = bytes.IndexByte(line, ','); end < 0 {
+		return false
+	}
+	if step.gas, err = strconv.ParseUint(string(line[:end]), 10, 64); err != nil {
+		return false
+	}
+	start = bytes.Index(line, []byte(`"opName":"`))
+	if start < 0 {
+		return false
+	}
+	line = line[start+len(`"opName":"`):]
+	if end = bytes.IndexByte(line, '"'); end < 0 {
+		return false
+	}
+	step.op = string(line[:end])
+	step.stack0, step.stack1 = "", ""
+	start = bytes.Index(line, []byte(`"stack":[`))
+	if start < 0 {
+		return true
+	}
+	line = line[start+len(`"stack":[`):]
+	if end = bytes.IndexByte(line, ']'); end <= 0 {
+		return true
+	}
+	items := bytes.Split(line[:end], []byte{','})
+	step.stack0 = string(bytes.Trim(items[len(items)-1], `"`))
+	if len(items) > 1 {
+		step.stack1 = string(bytes.Trim(items[len(items)-2], `"`))
+	}
+	return true
+}
+
+// CoverageCollector is a writer which collects the coverage of a canonical
+// trace. The error of a frame is inferred from the success-flag which the
+// caller receives on the stack: the frame failed if the flag is zero.
+type CoverageCollector struct {
+	cov     *Coverage
+	partial []byte // incomplete line from a previous write
+	prev    coverageStep
+	hasPrev bool
+}
+
+func NewCoverageCollector() *CoverageCollector {
+	return &CoverageCollector{cov: NewCoverage()}
+}
+
+// Write implements io.Writer.
+func (c *CoverageCollector) Write(p []byte) (int, error) {
+	data := p
+	if len(c.partial) > 0 {
+		data = append(c.partial, p...)
+	}
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			break
+		}
+		c.addLine(data[:i])
+		data = data[i+1:]
+	}
+	c.partial = append(c.partial[:0], data...)
+	return len(p), nil
+}
+
+func (c *CoverageCollector) addLine(line []byte) {
+	var step coverageStep
+	if !parseCoverageStep(line, &step) {
+		return
+	}
+	c.cov.Steps++
+	if c.hasPrev {
+		c.addStep(&c.prev, &step)
+	}
+	c.prev, c.hasPrev = step, true
+}
+
+// addStep counts the step, given the step following it, if any.
+func (c *CoverageCollector) addStep(step, next *coverageStep) {
+	key := CoverageKey{Op: step.op, Depth: step.depth}
+	switch {
+	case next == nil || next.depth < step.depth:
+		// The last step of a frame. Unless it's the outermost frame, the
+		// caller has the success-flag (or the created address) on the stack.
+		failed := next != nil && next.stack0 == "0x0"
+		if step.op == "REVERT" {
+			key.Error = CoverageRevert
+		} else if failed || step.op == "INVALID" {
+			key.Error = CoverageHalt
+		}
+	case next.depth == step.depth:
+		// A call which did not enter a new frame, e.g. into a precompile.
+		switch step.op {
+		case "CALL", "CALLCODE", "DELEGATECALL", "STATICCALL":
+			if addr := common.HexToAddress(step.stack1); step.stack1 != "" && isPrecompile(addr) {
+				c.cov.Precompiles[addr]++
+			}
+		}
+	}
+	c.cov.Ops[key]++
+	c.cov.GasBuckets[key] |= 1 << GasBucket(step.gas)
+}
+
+// Finish counts the final step, and returns the coverage of the trace. The
+// collector is reset, to be used for the next trace.
+func (c *CoverageCollector) Finish() *Coverage {
+	if len(c.partial) > 0 {
+		c.addLine(c.partial)
+	}
+	if c.hasPrev {
+		c.addStep(&c.prev, nil)
+	}
+	cov := c.cov
+	if cov.Steps > 0 {
+		cov.Tests = 1
+	}
+	c.cov, c.partial, c.hasPrev = NewCoverage(), c.partial[:0], false
+	return cov
+}
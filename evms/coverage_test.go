@@ -0,0 +1,115 @@
+// Copyright 2026 Martin Holst Swende
+// This file is part of the goevmlab library.
+//
+// The library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the goevmlab library. If not, see <http://www.gnu.org/licenses/>.
+
+package evms
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestCoverageCollector(t *testing.T) {
+	// A call into the ecrecover precompile, a call into a frame which
+	// reverts, a call into a frame which runs out of gas, and a final STOP.
+	trace := []string{
+		`{"depth":1,"pc":0,"gas":100,"op":"0xf1","opName":"CALL","stack":["0x0","0x0","0x0","0x0","0x1","0xff"]}`,
+		`{"depth":1,"pc":1,"gas":90,"op":"0xf1","opName":"CALL","stack":["0x1","0x0","0x0","0x0","0xaa","0xff"]}`,
+		`{"depth":2,"pc":0,"gas":50,"op":"0xfd","opName":"REVERT","stack":["0x0","0x0"]}`,
+		`{"depth":1,"pc":2,"gas":80,"op":"0xf4","opName":"DELEGATECALL","stack":["0x0","0x0","0x0","0x0","0xbb","0xff"]}`,
+		`{"depth":2,"pc":0,"gas":1,"op":"0x55","opName":"SSTORE","stack":["0x1","0x1"]}`,
+		`{"depth":1,"pc":3,"gas":70,"op":"0x0","opName":"STOP","stack":["0x0"]}`,
+		`{"stateRoot":"0x0102"}`,
+	}
+	c := NewCoverageCollector()
+	// Write in pieces, to check that lines are reassembled
+	for _, line := range trace {
+		mid := len(line) / 2
+		c.Write([]byte(line[:mid]))
+		c.Write([]byte(line[mid:] + "\n"))
+	}
+	have := c.Finish()
+	want := NewCoverage()
+	want.Tests, want.Steps = 1, 6
+	want.Ops[CoverageKey{Op: "CALL", Depth: 1}] = 2
+	want.Ops[CoverageKey{Op: "REVERT", Depth: 2, Error: CoverageRevert}] = 1
+	want.Ops[CoverageKey{Op: "DELEGATECALL", Depth: 1}] = 1
+	want.Ops[CoverageKey{Op: "SSTORE", Depth: 2, Error: CoverageHalt}] = 1
+	want.Ops[CoverageKey{Op: "STOP", Depth: 1}] = 1
+	want.Precompiles[common.BytesToAddress([]byte{1})] = 1
+	want.GasBuckets[CoverageKey{Op: "CALL", Depth: 1}] = 1 << 7
+	want.GasBuckets[CoverageKey{Op: "REVERT", Depth: 2, Error: CoverageRevert}] = 1 << 6
+	want.GasBuckets[CoverageKey{Op: "DELEGATECALL", Depth: 1}] = 1 << 7
+	want.GasBuckets[CoverageKey{Op: "SSTORE", Depth: 2, Error: CoverageHalt}] = 1 << 1
+	want.GasBuckets[CoverageKey{Op: "STOP", Depth: 1}] = 1 << 7
+	if !reflect.DeepEqual(have, want) {
+		t.Fatalf("wrong coverage\nhave: %+v\nwant: %+v", have, want)
+	}
+	// The collector is reset
+	if cov := c.Finish(); cov.Tests != 0 || len(cov.Ops) != 0 {
+		t.Fatalf("collector not reset: %+v", cov)
+	}
+	// Merging reports the new tuples
+	total := NewCoverage()
+	if n := total.Merge(have); n != 6 {
+		t.Errorf("wrong number of new tuples: %d", n)
+	}
+	if n := total.Merge(have); n != 0 {
+		t.Errorf("wrong number of new tuples: %d", n)
+	}
+	data, err := json.Marshal(total)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dec := new(Coverage)
+	if err := json.Unmarshal(data, dec); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(dec, total) {
+		t.Errorf("json roundtrip failed\nhave: %+v\nwant: %+v", dec, total)
+	}
+}
+
+// TestCoverageGethTrace collects the coverage of a recorded trace.
+func TestCoverageGethTrace(t *testing.T) {
+	raw, err := os.Open(filepath.Join("testdata", "traces", "negative_refund.json.geth.stderr.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer raw.Close()
+	var (
+		canon = new(bytes.Buffer)
+		c     = NewCoverageCollector()
+	)
+	NewGethEVM("", "geth").Copy(canon, raw)
+	c.Write(canon.Bytes())
+	cov := c.Finish()
+	if have, want := cov.Steps, uint64(bytes.Count(canon.Bytes(), []byte("\n"))-1); have != want {
+		t.Errorf("wrong number of steps, have %d, want %d", have, want)
+	}
+	var sum uint64
+	for _, n := range cov.Ops {
+		sum += n
+	}
+	if sum != cov.Steps {
+		t.Errorf("wrong op counts, have %d, want %d", sum, cov.Steps)
+	}
+}
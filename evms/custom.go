@@ -0,0 +1,307 @@
+// Copyright 2026 Martin Holst Swende
+// This file is part of the goevmlab library.
+//
+// The library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the goevmlab library. If not, see <http://www.gnu.org/licenses/>.
+
+package evms
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// PathPlaceholder is replaced by the path of the test in command templates.
+const PathPlaceholder = "{path}"
+
+// OutputProfile describes the output of a custom client.
+type OutputProfile struct {
+	// Stream is the stream which the trace is read from, "stdout" or "stderr".
+	// Defaults to "stderr".
+	Stream string `json:"stream,omitempty"`
+	// RootStream is the stream which the stateroot is read from, when
+	// executing without tracing. Defaults to Stream.
+	RootStream string `json:"rootStream,omitempty"`
+	// StateRootField is the json field holding the stateroot. Defaults to
+	// "stateRoot".
+	StateRootField string `json:"stateRootField,omitempty"`
+	// DropStops drops all STOP operations, as done for geth, which executes
+	// a 'virtual' STOP at the end of code.
+	DropStops bool `json:"dropStops,omitempty"`
+	// MergeErrors merges two consecutive lines for the same operation into
+	// one, as done for geth, which may emit an erroring operation twice.
+	MergeErrors bool `json:"mergeErrors,omitempty"`
+}
+
+// CustomVM is an Evm-interface wrapper around an arbitrary binary. The binary
+// is invoked using a command template, and the output is interpreted according
+// to an OutputProfile.
+type CustomVM struct {
+	command     []string // the command template for tracing execution
+	rootCommand []string // the command template for non-tracing execution
+	profile     OutputProfile
+	name        string
+
+	// Some metrics
+	stats *VMStat
+
+	cmdOpts // extra arguments and environment for the binary
+}
+
+// NewCustomVM creates a custom vm. The command templates must contain the
+// PathPlaceholder. The rootCommand is used for executing without tracing,
+// and may be nil, in which case the command is used.
+func NewCustomVM(name string, command, rootCommand []string, profile OutputProfile) (Evm, error) {
+	if rootCommand == nil {
+		rootCommand = command
+	}
+	for _, tmpl := range [][]string{command, rootCommand} {
+		if len(tmpl) == 0 {
+			return nil, errors.New("empty command template")
+		}
+		if !strings.Contains(strings.Join(tmpl, " "), PathPlaceholder) {
+			return nil, fmt.Errorf("command template %q lacks %v placeholder", strings.Join(tmpl, " "), PathPlaceholder)
+		}
+	}
+	for _, stream := range []string{profile.Stream, profile.RootStream} {
+		if stream != "" && stream != "stdout" && stream != "stderr" {
+			return nil, fmt.Errorf("invalid stream %q", stream)
+		}
+	}
+	if profile.Stream == "" {
+		profile.Stream = "stderr"
+	}
+	if profile.RootStream == "" {
+		profile.RootStream = profile.Stream
+	}
+	if profile.StateRootField == "" {
+		profile.StateRootField = "stateRoot"
+	}
+	return &CustomVM{
+		command:     command,
+		rootCommand: rootCommand,
+		profile:     profile,
+		name:        name,
+		stats:       &VMStat{},
+	}, nil
+}
+
+// CustomConfig describes a custom vm, as given to NewCustomVM and
+// SetCommandOptions.
+type CustomConfig struct {
+	Command     []string
+	RootCommand []string
+	Profile     OutputProfile
+	Args        []string // extra arguments for the binary
+	Env         []string // extra environment variables, in the form "key=value"
+}
+
+// Config returns the configuration of the vm.
+func (evm *CustomVM) Config() CustomConfig {
+	return CustomConfig{
+		Command:     evm.command,
+		RootCommand: evm.rootCommand,
+		Profile:     evm.profile,
+		Args:        evm.args,
+		Env:         evm.env,
+	}
+}
+
+func (evm *CustomVM) Instance(int) Evm {
+	return evm
+}
+
+func (evm *CustomVM) Name() string {
+	return evm.name
+}
+
+func (evm *CustomVM) Stats() []any {
+	return evm.stats.Stats()
+}
+
+func (evm *CustomVM) Close() {}
+
+// instantiate fills in the path in the command template.
+func instantiate(tmpl []string, path string) []string {
+	args := make([]string, len(tmpl))
+	for i, arg := range tmpl {
+		args[i] = strings.ReplaceAll(arg, PathPlaceholder, path)
+	}
+	return args
+}
+
+// GetStateRoot runs the test and returns the stateroot.
+func (evm *CustomVM) GetStateRoot(path string) (root, command string, err error) {
+	args := instantiate(evm.rootCommand, path)
+	cmd := evm.execCommand(args[0], args[1:]...)
+	var data []byte
+	if evm.profile.RootStream == "stdout" {
+		data, err = outputTimed(cmd, false, ExecTimeout)
+	} else {
+		data, err = StdErrOutput(cmd)
+	}
+	root, err = checkRoot(cmd, data, err, evm.ParseStateRoot)
+	return root, cmd.String(), err
+}
+
+// ParseStateRoot reads the stateroot from the combined output.
+func (evm *CustomVM) ParseStateRoot(data []byte) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, 32*1024*1024)
+	for scanner.Scan() {
+		if root := evm.stateRoot(scanner.Bytes()); root != "" {
+			return root, nil
+		}
+	}
+	return "", fmt.Errorf("%v: no stateroot found", evm.Name())
+}
+
+// stateRoot returns the stateroot if the line contains it.
+func (evm *CustomVM) stateRoot(line []byte) string {
+	if !bytes.Contains(line, []byte(`"`+evm.profile.StateRootField+`"`)) {
+		return ""
+	}
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(line, &fields) != nil {
+		return ""
+	}
+	var root string
+	if json.Unmarshal(fields[evm.profile.StateRootField], &root) != nil {
+		return ""
+	}
+	return root
+}
+
+// RunStateTest implements the Evm interface
+func (evm *CustomVM) RunStateTest(path string, out io.Writer, speedTest bool) (*tracingResult, error) {
+	var (
+		t0      = time.Now()
+		procOut io.ReadCloser
+		err     error
+		args    = instantiate(evm.command, path)
+		stream  = evm.profile.Stream
+	)
+	if speedTest {
+		args = instantiate(evm.rootCommand, path)
+		stream = evm.profile.RootStream
+	}
+	cmd := evm.execCommand(args[0], args[1:]...)
+	if stream == "stdout" {
+		procOut, err = cmd.StdoutPipe()
+	} else {
+		procOut, err = cmd.StderrPipe()
+	}
+	if err != nil {
+		return &tracingResult{Cmd: cmd.String()}, err
+	}
+	if err = cmd.Start(); err != nil {
+		return &tracingResult{Cmd: cmd.String()}, err
+	}
+	var root stateRoot
+	// copy everything to the given writer
+	err = waitTimed(cmd, procOut, ExecTimeout, func() {
+		root = evm.copyUntilEnd(out, evm.tee(procOut), subtestCount(path))
+		_, _ = io.ReadAll(procOut)
+	})
+	err = checkExit(cmd, root, err)
+	duration, slow := evm.stats.TraceDone(t0)
+	return &tracingResult{
+		Slow:     slow,
+		ExecTime: duration,
+		Cmd:      cmd.String(),
+	}, err
+}
+
+func (evm *CustomVM) Copy(out io.Writer, input io.Reader) {
+	evm.copyUntilEnd(out, input, 1)
+}
+
+// customLine is an opLog, which also retains the stateroot according to the
+// output profile.
+type customLine struct {
+	opLog
+	vm   *CustomVM
+	root string
+}
+
+func (l *customLine) UnmarshalJSON(data []byte) error {
+	l.root = l.vm.stateRoot(data)
+	return json.Unmarshal(data, &l.opLog)
+}
+
+// copyUntilEnd reads from the reader until it finds the stateroots of the given
+// number of subtests (zero meaning all of the input), and writes the canonical
+// output to the writer.
+func (evm *CustomVM) copyUntilEnd(out io.Writer, input io.Reader, subtests int) stateRoot {
+	scanner := NewJsonlScanner(evm.name, input, os.Stderr)
+	defer scanner.Release()
+	var (
+		roots = &stateRoots{want: subtests}
+		prev  *opLog
+	)
+	write := func(elem *opLog) {
+		data := CustomMarshal(elem)
+		if _, err := out.Write(append(data, '\n')); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing to out: %v\n", err)
+		}
+	}
+	for {
+		elem := &customLine{vm: evm}
+		if err := scanner.Next(elem); err != nil {
+			break
+		}
+		// If we have a stateroot, the subtest is done
+		if elem.root != "" {
+			if prev != nil {
+				write(prev)
+				prev = nil
+			}
+			if roots.add(out, elem.root) {
+				break
+			}
+			continue
+		}
+		// If the output cannot be marshalled, all fields will be blanks.
+		if elem.Depth == 0 {
+			continue
+		}
+		if evm.profile.DropStops && elem.Op == 0x0 {
+			continue
+		}
+		if !evm.profile.MergeErrors {
+			write(&elem.opLog)
+			continue
+		}
+		// An erroring operation may be emitted twice, keep only the first.
+		if prev != nil && prev.Pc == elem.Pc && prev.Depth == elem.Depth && prev.FunctionDepth == elem.FunctionDepth {
+			write(prev)
+			prev = nil
+			continue
+		}
+		if prev != nil {
+			write(prev)
+		}
+		prev = &elem.opLog
+	}
+	if prev != nil {
+		write(prev)
+	}
+	return roots.finish(out)
+}
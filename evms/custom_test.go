@@ -0,0 +1,76 @@
+// Copyright 2026 Martin Holst Swende
+// This file is part of the goevmlab library.
+//
+// The library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the goevmlab library. If not, see <http://www.gnu.org/licenses/>.
+
+package evms
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeCustomVM prints a trace on stdout, with the stateroot in a non-standard
+// field. The root is only printed if the path is passed after --input.
+const fakeCustomVM = `#!/bin/sh
+[ "$1" = "--input" ] || exit 1
+echo '{"depth":1,"pc":0,"gas":100,"op":96,"stack":[]}'
+echo '{"depth":1,"pc":2,"gas":97,"op":0,"stack":["0x1"]}'
+echo '{"root":"0x0102","test":"'$2'"}'
+`
+
+func TestCustomVM(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	bin := filepath.Join(t.TempDir(), "customvm")
+	if err := os.WriteFile(bin, []byte(fakeCustomVM), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewCustomVM("custom", []string{bin, "--input"}, nil, OutputProfile{}); err == nil {
+		t.Fatal("expected error for missing placeholder")
+	}
+	if _, err := NewCustomVM("custom", []string{bin, PathPlaceholder}, nil, OutputProfile{Stream: "stdin"}); err == nil {
+		t.Fatal("expected error for invalid stream")
+	}
+	vm, err := NewCustomVM("custom", []string{bin, "--input", PathPlaceholder}, nil, OutputProfile{
+		Stream:         "stdout",
+		StateRootField: "root",
+		DropStops:      true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := new(bytes.Buffer)
+	res, err := vm.RunStateTest("test.json", out, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(res.Cmd, "--input test.json") {
+		t.Errorf("wrong command: %v", res.Cmd)
+	}
+	want := `{"depth":1,"pc":0,"gas":100,"op":"0x60","opName":"PUSH1","stack":[]}
+{"stateRoot":"0x0102"}
+`
+	if have := out.String(); have != want {
+		t.Errorf("wrong output\nhave:\n%v\nwant:\n%v", have, want)
+	}
+	if root, _, err := vm.GetStateRoot("test.json"); err != nil || root != "0x0102" {
+		t.Errorf("wrong root %v, err %v", root, err)
+	}
+}
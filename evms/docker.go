@@ -0,0 +1,177 @@
+// Copyright 2026 Martin Holst Swende
+// This file is part of the goevmlab library.
+//
+// The library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the goevmlab library. If not, see <http://www.gnu.org/licenses/>.
+
+package evms
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// DockerConfig describes how to run a client within a container.
+type DockerConfig struct {
+	Runtime string   // the container runtime, defaults to "docker"
+	Image   string   // the image to run
+	Binary  string   // the path of the client binary within the image
+	Args    []string // extra arguments for the client, placed directly after the binary
+	Env     []string // environment variables for the container, in the form "key=value"
+	Mounts  []string // directories to bind-mount (read-only) at the same location in the container
+}
+
+// DockerVM executes a batch-mode vm within a container. The container is
+// long-lived: it hosts the 'master' process of the batch-mode vm, which is fed
+// the test paths on standard input. The directories containing the tests must
+// be bind-mounted, so the paths are the same on the host as in the container.
+// If the client crashes or hangs, the container is removed, and a new one is
+// started for the next test.
+type DockerVM struct {
+	Evm       // the batch-mode vm, executing within the container
+	conf      DockerConfig
+	container string // the name of the container
+}
+
+// NewDockerVM creates a vm which executes within a container. The newVM is
+// the constructor of a batch-mode vm, e.g. NewGethBatchVM.
+func NewDockerVM(name string, newVM func(path, name string) Evm, conf DockerConfig) (Evm, error) {
+	if conf.Image == "" || conf.Binary == "" {
+		return nil, errors.New("image and binary required")
+	}
+	if conf.Runtime == "" {
+		conf.Runtime = "docker"
+	}
+	mounts := make([]string, len(conf.Mounts))
+	for i, dir := range conf.Mounts {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return nil, err
+		}
+		mounts[i] = abs
+	}
+	conf.Mounts = mounts
+	return newDockerVM(newVM(conf.Runtime, name), conf)
+}
+
+func newDockerVM(vm Evm, conf DockerConfig) (Evm, error) {
+	opts, ok := vm.(CommandOptions)
+	if !ok {
+		return nil, fmt.Errorf("vm %v does not support command options", vm.Name())
+	}
+	d := &DockerVM{
+		Evm:       vm,
+		conf:      conf,
+		container: fmt.Sprintf("goevmlab-%d-%v", os.Getpid(), vm.Name()),
+	}
+	args := []string{"run", "-i", "--rm", "--name", d.container}
+	for _, dir := range conf.Mounts {
+		args = append(args, "-v", fmt.Sprintf("%v:%v:ro", dir, dir))
+	}
+	for _, env := range conf.Env {
+		args = append(args, "-e", env)
+	}
+	args = append(args, conf.Image, conf.Binary)
+	opts.SetCommandOptions(append(args, conf.Args...), nil)
+	return d, nil
+}
+
+// Instance returns a vm using a container of its own. It is an instance of
+// the batch-mode vm, so the statistics are shared.
+func (d *DockerVM) Instance(threadID int) Evm {
+	vm, _ := newDockerVM(d.Evm.Instance(threadID), d.conf)
+	return vm
+}
+
+// Kind implements ClientInfo, the kind is the one of the batch-mode vm.
+func (d *DockerVM) Kind() string {
+	if info, ok := d.Evm.(ClientInfo); ok {
+		return info.Kind()
+	}
+	return ""
+}
+
+// Binary implements ClientInfo, the binary is the path within the image.
+func (d *DockerVM) Binary() string {
+	return d.conf.Binary
+}
+
+// Config returns the configuration of the container.
+func (d *DockerVM) Config() DockerConfig {
+	return d.conf
+}
+
+// Version implements ClientInfo. The binary is executed in a container of its
+// own, as the one of the batch-mode vm is busy.
+func (d *DockerVM) Version() (string, error) {
+	args := []string{"run", "--rm"}
+	for _, env := range d.conf.Env {
+		args = append(args, "-e", env)
+	}
+	args = append(args, d.conf.Image, d.conf.Binary)
+	args = append(args, d.conf.Args...)
+	return binaryVersion(exec.Command(d.conf.Runtime, append(args, "--version")...))
+}
+
+// removeContainer forcibly removes the container, if it still exists.
+func (d *DockerVM) removeContainer() {
+	cmd := exec.Command(d.conf.Runtime, "rm", "-f", d.container)
+	if out, err := outputTimed(cmd, true, ExecTimeout); err != nil {
+		log.Debug("Failed removing container", "container", d.container, "err", err, "out", string(out))
+	}
+}
+
+// RunStateTest implements the Evm interface
+func (d *DockerVM) RunStateTest(path string, out io.Writer, speedTest bool) (*tracingResult, error) {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	res, err := d.Evm.RunStateTest(path, out, speedTest)
+	var crash *CrashError
+	if errors.As(err, &crash) {
+		d.removeContainer()
+	}
+	return res, err
+}
+
+// GetStateRoot runs the test and returns the stateroot.
+func (d *DockerVM) GetStateRoot(path string) (root, command string, err error) {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	root, command, err = d.Evm.GetStateRoot(path)
+	var crash *CrashError
+	if errors.As(err, &crash) {
+		d.removeContainer()
+	}
+	return root, command, err
+}
+
+// CaptureRaw implements RawCapturer, if the batch-mode vm does.
+func (d *DockerVM) CaptureRaw(w io.Writer) {
+	if rc, ok := d.Evm.(RawCapturer); ok {
+		rc.CaptureRaw(w)
+	}
+}
+
+// Close stops the batch-mode vm, and removes the container.
+func (d *DockerVM) Close() {
+	d.Evm.Close()
+	d.removeContainer()
+}
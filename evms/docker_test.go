@@ -0,0 +1,145 @@
+// Copyright 2026 Martin Holst Swende
+// This file is part of the goevmlab library.
+//
+// The library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the goevmlab library. If not, see <http://www.gnu.org/licenses/>.
+
+package evms
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeRuntime is a stand-in for docker. It logs the invocations, and 'runs'
+// the image by executing the command following the image name on the host.
+const fakeRuntime = `#!/bin/sh
+echo "$@" >> %v
+[ "$1" = "rm" ] && exit 0
+while [ "$1" != "stub/image" ]; do shift; done
+shift
+exec "$@"
+`
+
+func TestDockerVM(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	var (
+		dir     = t.TempDir()
+		runtime = filepath.Join(dir, "docker")
+		client  = filepath.Join(dir, "evm")
+		logfile = filepath.Join(dir, "invocations")
+	)
+	if err := os.WriteFile(runtime, fmt.Appendf(nil, fakeRuntime, logfile), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(client, []byte(fakeMaster), 0755); err != nil {
+		t.Fatal(err)
+	}
+	vm, err := NewDockerVM("dockergeth", NewGethBatchVM, DockerConfig{
+		Runtime: runtime,
+		Image:   "stub/image",
+		Binary:  client,
+		Env:     []string{"FOO=bar"},
+		Mounts:  []string{dir},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := vm.RunStateTest(filepath.Join(dir, "ok.json"), new(bytes.Buffer), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := fmt.Sprintf("%v run -i --rm --name goevmlab-%d-dockergeth -v %v:%v:ro -e FOO=bar stub/image %v statetest",
+		runtime, os.Getpid(), dir, dir, client)
+	if !strings.HasPrefix(res.Cmd, want) {
+		t.Errorf("wrong command\nhave: %v\nwant: %v", res.Cmd, want)
+	}
+	// A crash should remove the container, and a new one should be started
+	var crash *CrashError
+	if _, err := vm.RunStateTest(filepath.Join(dir, "crash.json"), new(bytes.Buffer), false); !errors.As(err, &crash) {
+		t.Fatalf("expected crash, got %v", err)
+	}
+	if _, err := vm.RunStateTest(filepath.Join(dir, "ok.json"), new(bytes.Buffer), false); err != nil {
+		t.Fatal(err)
+	}
+	vm.Close()
+	data, err := os.ReadFile(logfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var calls []string
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		calls = append(calls, strings.Fields(line)[0])
+	}
+	if have, want := strings.Join(calls, ","), "run,rm,run,rm"; have != want {
+		t.Errorf("wrong runtime invocations, have %v, want %v", have, want)
+	}
+}
+
+// TestDockerVMInstance checks that each instance uses a container of its own,
+// and that the client info is the one of the client within the image.
+func TestDockerVMInstance(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	var (
+		dir     = t.TempDir()
+		runtime = filepath.Join(dir, "docker")
+		client  = filepath.Join(dir, "evm")
+		logfile = filepath.Join(dir, "invocations")
+	)
+	if err := os.WriteFile(runtime, fmt.Appendf(nil, fakeRuntime, logfile), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(client, []byte(fakeMaster), 0755); err != nil {
+		t.Fatal(err)
+	}
+	vm, err := NewDockerVM("dockergeth", NewGethBatchVM, DockerConfig{Runtime: runtime, Image: "stub/image", Binary: client})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range 2 {
+		inst := vm.Instance(i)
+		res, err := inst.RunStateTest(filepath.Join(dir, "ok.json"), new(bytes.Buffer), false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		inst.Close()
+		if want := fmt.Sprintf("--name goevmlab-%d-dockergeth-%d ", os.Getpid(), i); !strings.Contains(res.Cmd, want) {
+			t.Errorf("instance %d: wrong container\nhave: %v\nwant: %v", i, res.Cmd, want)
+		}
+	}
+	// The executions of the instances are counted by the vm
+	if stats := vm.Stats(); len(stats) < 6 || stats[4] != "count" || stats[5] != uint64(2) {
+		t.Errorf("statistics not shared: %v", stats)
+	}
+	info, ok := vm.(ClientInfo)
+	if !ok {
+		t.Fatal("no client info")
+	}
+	if info.Kind() != "geth" || info.Binary() != client {
+		t.Errorf("wrong client info: kind %v, binary %v", info.Kind(), info.Binary())
+	}
+	vm, _ = NewDockerVM("dockerecho", NewGethBatchVM, DockerConfig{Runtime: runtime, Image: "stub/image", Binary: "echo", Args: []string{"v1.2.3"}})
+	if version, err := vm.(ClientInfo).Version(); err != nil || version != "v1.2.3 --version" {
+		t.Errorf("wrong version: %v, %v", version, err)
+	}
+}
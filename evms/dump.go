@@ -0,0 +1,192 @@
+// Copyright 2026 Martin Holst Swende
+// This file is part of the goevmlab library.
+//
+// The library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the goevmlab library. If not, see <http://www.gnu.org/licenses/>.
+
+package evms
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+)
+
+// StateDumper is implemented by vms which can produce a dump of the post-state:
+// the accounts, with balances, nonces, code hashes and storage.
+type StateDumper interface {
+	// DumpState runs the test and returns the post-state of the first subtest,
+	// along with the command used.
+	DumpState(path string) (*state.Dump, string, error)
+}
+
+// ParseStateDump reads a post-state dump. It accepts the output of
+// 'evm statetest --dump', which is a list of test results, and returns the
+// state of the first result carrying one. A bare dump is also accepted.
+func ParseStateDump(data []byte) (*state.Dump, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '{' {
+		var dump state.Dump
+		if err := json.Unmarshal(data, &dump); err != nil {
+			return nil, err
+		}
+		return &dump, nil
+	}
+	var results []struct {
+		State *state.Dump `json:"state"`
+	}
+	if err := json.Unmarshal(data, &results); err != nil {
+		return nil, err
+	}
+	for _, res := range results {
+		if res.State != nil {
+			return res.State, nil
+		}
+	}
+	return nil, errors.New("no state in dump")
+}
+
+// StateDiff is a difference between two post-states.
+type StateDiff struct {
+	Address common.Address
+	Field   string      // "account", "balance", "nonce", "codeHash" or "storage"
+	Slot    common.Hash // the storage slot, if Field is "storage"
+	A, B    string      // the values in the two states, empty if absent
+}
+
+func (d StateDiff) String() string {
+	a, b := d.A, d.B
+	if a == "" {
+		a = "<missing>"
+	}
+	if b == "" {
+		b = "<missing>"
+	}
+	if d.Field == "storage" {
+		return fmt.Sprintf("%v storage[%v]: %v != %v", d.Address, d.Slot, a, b)
+	}
+	return fmt.Sprintf("%v %v: %v != %v", d.Address, d.Field, a, b)
+}
+
+// dumpAccounts returns the accounts of the dump, keyed by address. The keys
+// of a dump are not necessarily in canonical form.
+func dumpAccounts(dump *state.Dump) map[common.Address]state.DumpAccount {
+	accounts := make(map[common.Address]state.DumpAccount)
+	for key, acc := range dump.Accounts {
+		addr := common.HexToAddress(key)
+		if acc.Address != nil {
+			addr = *acc.Address
+		}
+		accounts[addr] = acc
+	}
+	return accounts
+}
+
+// dumpStorage returns the non-zero storage of the account, with the values
+// in canonical form. A dump holds the values as hex, without leading zeroes
+// and (usually) without 0x-prefix.
+func dumpStorage(acc state.DumpAccount) map[common.Hash]string {
+	storage := make(map[common.Hash]string)
+	for slot, val := range acc.Storage {
+		v, ok := new(big.Int).SetString(strings.TrimPrefix(val, "0x"), 16)
+		if !ok {
+			storage[slot] = val // keep as is, and let it differ
+			continue
+		}
+		if v.Sign() != 0 {
+			storage[slot] = fmt.Sprintf("%#x", v)
+		}
+	}
+	return storage
+}
+
+// dumpBalance returns the balance of the account in canonical form.
+func dumpBalance(acc state.DumpAccount) string {
+	if v, ok := new(big.Int).SetString(acc.Balance, 0); ok {
+		return v.String()
+	}
+	return acc.Balance
+}
+
+// DiffStateDumps compares two post-states, and returns the accounts and
+// storage slots where they differ, sorted by address.
+func DiffStateDumps(a, b *state.Dump) []StateDiff {
+	var (
+		accsA = dumpAccounts(a)
+		accsB = dumpAccounts(b)
+		addrs []common.Address
+		diffs []StateDiff
+	)
+	for addr := range accsA {
+		addrs = append(addrs, addr)
+	}
+	for addr := range accsB {
+		if _, ok := accsA[addr]; !ok {
+			addrs = append(addrs, addr)
+		}
+	}
+	sort.Slice(addrs, func(i, j int) bool { return bytes.Compare(addrs[i][:], addrs[j][:]) < 0 })
+	for _, addr := range addrs {
+		accA, okA := accsA[addr]
+		accB, okB := accsB[addr]
+		if !okA || !okB {
+			d := StateDiff{Address: addr, Field: "account"}
+			if okA {
+				d.A = "present"
+			} else {
+				d.B = "present"
+			}
+			diffs = append(diffs, d)
+			continue
+		}
+		if balA, balB := dumpBalance(accA), dumpBalance(accB); balA != balB {
+			diffs = append(diffs, StateDiff{Address: addr, Field: "balance", A: balA, B: balB})
+		}
+		if accA.Nonce != accB.Nonce {
+			diffs = append(diffs, StateDiff{Address: addr, Field: "nonce",
+				A: fmt.Sprint(accA.Nonce), B: fmt.Sprint(accB.Nonce)})
+		}
+		if !bytes.Equal(accA.CodeHash, accB.CodeHash) {
+			diffs = append(diffs, StateDiff{Address: addr, Field: "codeHash",
+				A: accA.CodeHash.String(), B: accB.CodeHash.String()})
+		}
+		var (
+			storA = dumpStorage(accA)
+			storB = dumpStorage(accB)
+			slots []common.Hash
+		)
+		for slot := range storA {
+			slots = append(slots, slot)
+		}
+		for slot := range storB {
+			if _, ok := storA[slot]; !ok {
+				slots = append(slots, slot)
+			}
+		}
+		sort.Slice(slots, func(i, j int) bool { return bytes.Compare(slots[i][:], slots[j][:]) < 0 })
+		for _, slot := range slots {
+			if storA[slot] != storB[slot] {
+				diffs = append(diffs, StateDiff{Address: addr, Field: "storage", Slot: slot,
+					A: storA[slot], B: storB[slot]})
+			}
+		}
+	}
+	return diffs
+}
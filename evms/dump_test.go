@@ -0,0 +1,102 @@
+// Copyright 2026 Martin Holst Swende
+// This file is part of the goevmlab library.
+//
+// The library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the goevmlab library. If not, see <http://www.gnu.org/licenses/>.
+
+package evms
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+)
+
+func readDump(t *testing.T, name, client string) *state.Dump {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "dumps", fmt.Sprintf("%v.%v.poststate.json", name, client)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dump, err := ParseStateDump(data)
+	if err != nil {
+		t.Fatalf("%v: %v", name, err)
+	}
+	return dump
+}
+
+// TestEmbeddedGethDump compares the post-state of the in-process geth against
+// the recorded dumps of the geth binary. The dumps were recorded with an older
+// version of geth, so the post-states may differ, but a difference must show
+// up in the diff if and only if the roots differ.
+func TestEmbeddedGethDump(t *testing.T) {
+	finfos, err := os.ReadDir(filepath.Join("testdata", "cases"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	embedded := NewEmbeddedGethVM("embedded").(StateDumper)
+	for _, finfo := range finfos {
+		have, _, err := embedded.DumpState(filepath.Join("testdata", "cases", finfo.Name()))
+		if err != nil {
+			t.Fatalf("%v: %v", finfo.Name(), err)
+		}
+		want := readDump(t, finfo.Name(), "geth")
+		diffs := DiffStateDumps(want, have)
+		if rootsEqual := strings.TrimPrefix(want.Root, "0x") == strings.TrimPrefix(have.Root, "0x"); rootsEqual != (len(diffs) == 0) {
+			t.Errorf("%v: roots equal: %v, but differences: %v", finfo.Name(), rootsEqual, diffs)
+		}
+	}
+}
+
+func TestDiffStateDumps(t *testing.T) {
+	var (
+		a = readDump(t, "negative_refund.json", "geth")
+		b = readDump(t, "negative_refund.json", "bor")
+	)
+	if diffs := DiffStateDumps(a, b); len(diffs) != 0 {
+		t.Fatalf("expected no differences, got %v", diffs)
+	}
+	// Modify the second dump: the keys are not in canonical form, which
+	// should not matter.
+	for key, acc := range b.Accounts {
+		switch common.HexToAddress(key) {
+		case common.HexToAddress("0xf1"):
+			acc.Storage[common.HexToHash("0x02")] = "0x05"
+			acc.Storage[common.HexToHash("0x07")] = "00"
+			acc.Balance = "1"
+			b.Accounts[key] = acc
+		case common.HexToAddress("0xf5"):
+			delete(b.Accounts, key)
+			b.Accounts[strings.ToLower(key)] = acc
+		case common.HexToAddress("0x00"):
+			delete(b.Accounts, key)
+		}
+	}
+	var have []string
+	for _, d := range DiffStateDumps(a, b) {
+		have = append(have, d.String())
+	}
+	want := []string{
+		"0x0000000000000000000000000000000000000000 account: present != <missing>",
+		"0x00000000000000000000000000000000000000f1 balance: 33918 != 1",
+		"0x00000000000000000000000000000000000000f1 storage[0x0000000000000000000000000000000000000000000000000000000000000002]: 0x4 != 0x5",
+	}
+	if strings.Join(have, "\n") != strings.Join(want, "\n") {
+		t.Errorf("wrong diff\nhave:\n%v\nwant:\n%v", strings.Join(have, "\n"), strings.Join(want, "\n"))
+	}
+}
@@ -19,14 +19,11 @@ package evms
 
 import (
 	"bytes"
-	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
 	"time"
-
-	"github.com/ethereum/go-ethereum/log"
 )
 
 // EelsEVM is s Evm-interface wrapper around the `evm` binary, based on go-ethereum.
@@ -36,6 +33,8 @@ type EelsEVM struct {
 
 	// Some metrics
 	stats *VMStat
+
+	cmdOpts // extra arguments and environment for the binary
 }
 
 func NewEelsEVM(path string, name string) Evm {
@@ -54,22 +53,30 @@ func (evm *EelsEVM) Name() string {
 	return evm.name
 }
 
+// Kind implements ClientInfo.
+func (evm *EelsEVM) Kind() string {
+	return "eels"
+}
+
+// Binary implements ClientInfo.
+func (evm *EelsEVM) Binary() string {
+	return evm.path
+}
+
+// Version implements ClientInfo.
+func (evm *EelsEVM) Version() (string, error) {
+	return binaryVersion(evm.execCommand(evm.path, "--version"))
+}
+
 // GetStateRoot runs the test and returns the stateroot
 // This currently only works for non-filled statetests. TODO: make it work even if the
 // test is filled. Either by getting the whole trace, or adding stateroot to exec std output
 // even in success-case
 func (evm *EelsEVM) GetStateRoot(path string) (root, command string, err error) {
 	// In this mode, we can run it without tracing
-	cmd := exec.Command(evm.path, "statetest", path)
-	data, err := cmd.Output()
-	if err != nil {
-		return "", cmd.String(), err
-	}
-	root, err = evm.ParseStateRoot(data)
-	if err != nil {
-		log.Error("Failed to find stateroot", "vm", evm.Name(), "cmd", cmd.String())
-		return "", cmd.String(), err
-	}
+	cmd := evm.execCommand(evm.path, "statetest", path)
+	data, err := outputTimed(cmd, false, ExecTimeout)
+	root, err = checkRoot(cmd, data, err, evm.ParseStateRoot)
 	return root, cmd.String(), err
 }
 
@@ -92,9 +99,9 @@ func (evm *EelsEVM) RunStateTest(path string, out io.Writer, speedTest bool) (*t
 		cmd    *exec.Cmd
 	)
 	if speedTest {
-		cmd = exec.Command(evm.path, "statetest", "--nomemory", "--noreturndata", "--nostack", path)
+		cmd = evm.execCommand(evm.path, "statetest", "--nomemory", "--noreturndata", "--nostack", path)
 	} else {
-		cmd = exec.Command(evm.path, "statetest", "--json", "--noreturndata", "--nomemory", path)
+		cmd = evm.execCommand(evm.path, "statetest", "--json", "--noreturndata", "--nomemory", path)
 	}
 	if stderr, err = cmd.StderrPipe(); err != nil {
 		return &tracingResult{Cmd: cmd.String()}, err
@@ -102,10 +109,13 @@ func (evm *EelsEVM) RunStateTest(path string, out io.Writer, speedTest bool) (*t
 	if err = cmd.Start(); err != nil {
 		return &tracingResult{Cmd: cmd.String()}, err
 	}
+	var root stateRoot
 	// copy everything to the given writer
-	evm.Copy(out, stderr)
-	_, _ = io.ReadAll(stderr)
-	err = cmd.Wait()
+	err = waitTimed(cmd, stderr, ExecTimeout, func() {
+		root = evm.copyUntilEnd(out, evm.tee(stderr), subtestCount(path))
+		_, _ = io.ReadAll(stderr)
+	})
+	err = checkExit(cmd, root, err)
 	// release resources
 	duration, slow := evm.stats.TraceDone(t0)
 
@@ -122,23 +132,25 @@ func (evm *EelsEVM) Close() {
 // Copy reads from the reader, does some geth-specific filtering and
 // outputs items onto the channel
 func (evm *EelsEVM) Copy(out io.Writer, input io.Reader) {
-	evm.copyUntilEnd(out, input)
+	evm.copyUntilEnd(out, input, 1)
 }
 
 // copyUntilEnd reads from the reader, does some vm-specific filtering and
 // outputs items onto the channel
-func (evm *EelsEVM) copyUntilEnd(out io.Writer, input io.Reader) stateRoot {
+func (evm *EelsEVM) copyUntilEnd(out io.Writer, input io.Reader, subtests int) stateRoot {
 	scanner := NewJsonlScanner("eels", input, os.Stderr)
 	defer scanner.Release()
-	var stateRoot stateRoot
+	roots := &stateRoots{want: subtests}
 	for {
 		var elem opLog
 		if scanner.Next(&elem) != nil {
 			break
 		}
 		if len(elem.StateRoot1) != 0 {
-			stateRoot.StateRoot = elem.StateRoot1
-			break
+			if roots.add(out, elem.StateRoot1) {
+				break
+			}
+			continue
 		}
 		// General parsing error, some jsonl-output with nothing meaningful
 		if elem.Depth == 0 {
@@ -153,11 +165,7 @@ func (evm *EelsEVM) copyUntilEnd(out io.Writer, input io.Reader) stateRoot {
 			fmt.Fprintf(os.Stderr, "Error writing to out: %v\n", err)
 		}
 	}
-	root, _ := json.Marshal(stateRoot)
-	if _, err := out.Write(append(root, '\n')); err != nil {
-		fmt.Fprintf(os.Stderr, "Error writing to out: %v\n", err)
-	}
-	return stateRoot
+	return roots.finish(out)
 }
 
 func (evm *EelsEVM) Stats() []any {
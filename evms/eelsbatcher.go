@@ -36,16 +36,17 @@ type EelsBatchVM struct {
 
 func NewEelsBatchVM(path, name string) Evm {
 	return &EelsBatchVM{
-		EelsEVM: EelsEVM{path, name, &VMStat{}},
+		EelsEVM: EelsEVM{path: path, name: name, stats: &VMStat{}},
 	}
 }
 
 func (evm *EelsBatchVM) Instance(threadID int) Evm {
 	return &EelsBatchVM{
 		EelsEVM: EelsEVM{
-			path:  evm.path,
-			name:  fmt.Sprintf("%v-%d", evm.name, threadID),
-			stats: evm.stats,
+			path:    evm.path,
+			name:    fmt.Sprintf("%v-%d", evm.name, threadID),
+			stats:   evm.stats,
+			cmdOpts: evm.cmdOpts,
 		},
 	}
 }
@@ -59,11 +60,13 @@ func (evm *EelsBatchVM) RunStateTest(path string, out io.Writer, speedTest bool)
 		stdout io.ReadCloser
 		stdin  io.WriteCloser
 	)
+	evm.mu.Lock()
+	defer evm.mu.Unlock()
 	if evm.cmd == nil {
 		if speedTest {
-			cmd = exec.Command(evm.path, "statetest")
+			cmd = evm.execCommand(evm.path, "statetest")
 		} else {
-			cmd = exec.Command(evm.path, "statetest", "--json", "--noreturndata", "--nomemory")
+			cmd = evm.execCommand(evm.path, "statetest", "--json", "--noreturndata", "--nomemory")
 		}
 		if stdout, err = cmd.StderrPipe(); err != nil {
 			return &tracingResult{Cmd: cmd.String()}, err
@@ -78,12 +81,14 @@ func (evm *EelsBatchVM) RunStateTest(path string, out io.Writer, speedTest bool)
 		evm.stdout = stdout
 		evm.stdin = stdin
 	}
-	evm.mu.Lock()
-	defer evm.mu.Unlock()
-	_, _ = fmt.Fprintf(evm.stdin, "%v\n", path)
 	// copy everything for the _current_ statetest to the given writer
-	evm.copyUntilEnd(out, evm.stdout)
-	// release resources, handle error but ignore non-zero exit codes
+	_, err = execBatched(evm.cmd, evm.stdin, evm.stdout, path, ExecTimeout, func() stateRoot {
+		return evm.copyUntilEnd(out, evm.tee(evm.stdout), subtestCount(path))
+	})
+	if err != nil {
+		cmd, evm.cmd = evm.cmd, nil // restart on next test
+		return &tracingResult{Cmd: cmd.String()}, err
+	}
 	duration, slow := evm.stats.TraceDone(t0)
 	return &tracingResult{
 			Slow:     slow,
@@ -102,8 +107,10 @@ func (evm *EelsBatchVM) Close() {
 }
 
 func (evm *EelsBatchVM) GetStateRoot(path string) (root, command string, err error) {
+	evm.mu.Lock()
+	defer evm.mu.Unlock()
 	if evm.cmd == nil {
-		evm.cmd = exec.Command(evm.path, "statetest")
+		evm.cmd = evm.execCommand(evm.path, "statetest")
 		if evm.stdout, err = evm.cmd.StderrPipe(); err != nil {
 			return "", evm.cmd.String(), err
 		}
@@ -114,10 +121,12 @@ func (evm *EelsBatchVM) GetStateRoot(path string) (root, command string, err err
 			return "", evm.cmd.String(), err
 		}
 	}
-	evm.mu.Lock()
-	defer evm.mu.Unlock()
-	_, _ = fmt.Fprintf(evm.stdin, "%v\n", path)
-
-	sRoot := evm.copyUntilEnd(io.Discard, evm.stdout)
-	return sRoot.StateRoot, evm.cmd.String(), nil
+	command = evm.cmd.String()
+	sRoot, err := execBatched(evm.cmd, evm.stdin, evm.stdout, path, ExecTimeout, func() stateRoot {
+		return evm.copyUntilEnd(io.Discard, evm.stdout, subtestCount(path))
+	})
+	if err != nil {
+		evm.cmd = nil // restart on next test
+	}
+	return sRoot.StateRoot, command, err
 }
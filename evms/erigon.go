@@ -22,7 +22,6 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"os/exec"
 	"time"
 
 	"github.com/ethereum/go-ethereum/log"
@@ -34,6 +33,8 @@ type ErigonVM struct {
 	name string // in case multiple instances are used
 	// Some metrics
 	stats *VMStat
+
+	cmdOpts // extra arguments and environment for the binary
 }
 
 func NewErigonVM(path, name string) Evm {
@@ -52,22 +53,30 @@ func (evm *ErigonVM) Name() string {
 	return evm.name
 }
 
+// Kind implements ClientInfo.
+func (evm *ErigonVM) Kind() string {
+	return "erigon"
+}
+
+// Binary implements ClientInfo.
+func (evm *ErigonVM) Binary() string {
+	return evm.path
+}
+
+// Version implements ClientInfo.
+func (evm *ErigonVM) Version() (string, error) {
+	return binaryVersion(evm.execCommand(evm.path, "--version"))
+}
+
 // GetStateRoot runs the test and returns the stateroot
 // This currently only works for non-filled statetests. TODO: make it work even if the
 // test is filled. Either by getting the whole trace, or adding stateroot to exec std output
 // even in success-case
 func (evm *ErigonVM) GetStateRoot(path string) (root, command string, err error) {
 	// In this mode, we can run it without tracing
-	cmd := exec.Command(evm.path, "statetest", path)
-	data, err := cmd.CombinedOutput()
-	if err != nil {
-		return "", cmd.String(), err
-	}
-	root, err = evm.ParseStateRoot(data)
-	if err != nil {
-		log.Error("Failed to find stateroot", "vm", evm.Name(), "cmd", cmd.String())
-		return "", cmd.String(), err
-	}
+	cmd := evm.execCommand(evm.path, "statetest", path)
+	data, err := outputTimed(cmd, true, ExecTimeout)
+	root, err = checkRoot(cmd, data, err, evm.ParseStateRoot)
 	return root, cmd.String(), err
 }
 
@@ -87,10 +96,10 @@ func (evm *ErigonVM) RunStateTest(path string, out io.Writer, speedTest bool) (*
 		t0     = time.Now()
 		stderr io.ReadCloser
 		err    error
-		cmd    = exec.Command(evm.path, "statetest", "--json", "--jsonout", "--noreturndata", "--nomemory", path)
+		cmd    = evm.execCommand(evm.path, "statetest", "--json", "--jsonout", "--noreturndata", "--nomemory", path)
 	)
 	if speedTest {
-		cmd = exec.Command(evm.path, "statetest", "--jsonout", "--nomemory", "--noreturndata", "--nostack", path)
+		cmd = evm.execCommand(evm.path, "statetest", "--jsonout", "--nomemory", "--noreturndata", "--nostack", path)
 	}
 	if stderr, err = cmd.StderrPipe(); err != nil {
 		return &tracingResult{Cmd: cmd.String()}, err
@@ -98,10 +107,13 @@ func (evm *ErigonVM) RunStateTest(path string, out io.Writer, speedTest bool) (*
 	if err = cmd.Start(); err != nil {
 		return &tracingResult{Cmd: cmd.String()}, err
 	}
+	var root stateRoot
 	// copy everything to the given writer
-	evm.Copy(out, stderr)
-	_, _ = io.ReadAll(stderr)
-	err = cmd.Wait()
+	err = waitTimed(cmd, stderr, ExecTimeout, func() {
+		root = evm.copyUntilEnd(out, evm.tee(stderr), false, subtestCount(path))
+		_, _ = io.ReadAll(stderr)
+	})
+	err = checkExit(cmd, root, err)
 	// release resources
 	duration, slow := evm.stats.TraceDone(t0)
 	return &tracingResult{
@@ -117,12 +129,12 @@ func (evm *ErigonVM) Close() {
 // Copy reads from the reader, does some geth-specific filtering and
 // outputs items onto the channel
 func (evm *ErigonVM) Copy(out io.Writer, input io.Reader) {
-	evm.copyUntilEnd(out, input, false)
+	evm.copyUntilEnd(out, input, false, 1)
 }
 
 // copyUntilEnd reads from the reader, does some geth-specific filtering and
 // outputs items onto the channel
-func (evm *ErigonVM) copyUntilEnd(out io.Writer, input io.Reader, speedMode bool) stateRoot {
+func (evm *ErigonVM) copyUntilEnd(out io.Writer, input io.Reader, speedMode bool, subtests int) stateRoot {
 	if speedMode {
 		// In speednode, there's no jsonl output, just the json stateroot
 		var r []stateRoot
@@ -130,25 +142,27 @@ func (evm *ErigonVM) copyUntilEnd(out io.Writer, input io.Reader, speedMode bool
 			log.Warn("Error parsing erigonbatch output", "error", err)
 			return stateRoot{}
 		}
-		rootJSON, _ := json.Marshal(r[0])
-		if _, err := out.Write(append(rootJSON, '\n')); err != nil {
-			fmt.Fprintf(os.Stderr, "Error writing to out: %v\n", err)
+		roots := &stateRoots{want: subtests}
+		for _, root := range r {
+			roots.add(out, root.StateRoot)
 		}
-		return r[0]
+		return roots.finish(out)
 	}
 	scanner := NewJsonlScanner("erigon", input, os.Stderr)
 	defer scanner.Release()
-	var stateRoot stateRoot
+	roots := &stateRoots{want: subtests}
 
 	for {
 		var elem opLog
 		if err := scanner.Next(&elem); err != nil {
 			break
 		}
-		// If we have a stateroot, we're done
+		// If we have a stateroot, the subtest is done
 		if len(elem.StateRoot1) != 0 {
-			stateRoot.StateRoot = elem.StateRoot1
-			break
+			if roots.add(out, elem.StateRoot1) {
+				break
+			}
+			continue
 		}
 		if elem.Depth == 0 {
 			continue
@@ -161,14 +175,10 @@ func (evm *ErigonVM) copyUntilEnd(out io.Writer, input io.Reader, speedMode bool
 		outp := CustomMarshal(&elem)
 		if _, err := out.Write(append(outp, '\n')); err != nil {
 			fmt.Fprintf(os.Stderr, "Error writing to out: %v\n", err)
-			return stateRoot
+			return stateRoot{}
 		}
 	}
-	root, _ := json.Marshal(stateRoot)
-	if _, err := out.Write(append(root, '\n')); err != nil {
-		fmt.Fprintf(os.Stderr, "Error writing to out: %v\n", err)
-	}
-	return stateRoot
+	return roots.finish(out)
 }
 
 func (evm *ErigonVM) Stats() []any {
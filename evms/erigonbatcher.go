@@ -35,16 +35,17 @@ type ErigonBatchVM struct {
 
 func NewErigonBatchVM(path, name string) Evm {
 	return &ErigonBatchVM{
-		ErigonVM: ErigonVM{path, name, &VMStat{}},
+		ErigonVM: ErigonVM{path: path, name: name, stats: &VMStat{}},
 	}
 }
 
 func (evm *ErigonBatchVM) Instance(threadID int) Evm {
 	return &ErigonBatchVM{
 		ErigonVM: ErigonVM{
-			path:  evm.path,
-			name:  fmt.Sprintf("%v-%d", evm.name, threadID),
-			stats: evm.stats,
+			path:    evm.path,
+			name:    fmt.Sprintf("%v-%d", evm.name, threadID),
+			stats:   evm.stats,
+			cmdOpts: evm.cmdOpts,
 		},
 	}
 }
@@ -58,11 +59,13 @@ func (evm *ErigonBatchVM) RunStateTest(path string, out io.Writer, speedTest boo
 		stdout io.ReadCloser
 		stdin  io.WriteCloser
 	)
+	evm.mu.Lock()
+	defer evm.mu.Unlock()
 	if evm.cmd == nil {
 		if speedTest {
-			cmd = exec.Command(evm.path, "statetest", "--jsonout", "--nomemory", "--noreturndata", "--nostack")
+			cmd = evm.execCommand(evm.path, "statetest", "--jsonout", "--nomemory", "--noreturndata", "--nostack")
 		} else {
-			cmd = exec.Command(evm.path, "statetest", "--json", "--jsonout", "--noreturndata", "--nomemory")
+			cmd = evm.execCommand(evm.path, "statetest", "--json", "--jsonout", "--noreturndata", "--nomemory")
 		}
 		if stdout, err = cmd.StderrPipe(); err != nil {
 			return &tracingResult{Cmd: cmd.String()}, err
@@ -77,12 +80,14 @@ func (evm *ErigonBatchVM) RunStateTest(path string, out io.Writer, speedTest boo
 		evm.stdout = stdout
 		evm.stdin = stdin
 	}
-	evm.mu.Lock()
-	defer evm.mu.Unlock()
-	_, _ = fmt.Fprintf(evm.stdin, "%v\n", path)
 	// copy everything for the _current_ statetest to the given writer
-	evm.copyUntilEnd(out, evm.stdout, speedTest)
-	// release resources, handle error but ignore non-zero exit codes
+	_, err = execBatched(evm.cmd, evm.stdin, evm.stdout, path, ExecTimeout, func() stateRoot {
+		return evm.copyUntilEnd(out, evm.tee(evm.stdout), speedTest, subtestCount(path))
+	})
+	if err != nil {
+		cmd, evm.cmd = evm.cmd, nil // restart on next test
+		return &tracingResult{Cmd: cmd.String()}, err
+	}
 	duration, slow := evm.stats.TraceDone(t0)
 	return &tracingResult{
 			Slow:     slow,
@@ -101,8 +106,10 @@ func (evm *ErigonBatchVM) Close() {
 }
 
 func (evm *ErigonBatchVM) GetStateRoot(path string) (root, command string, err error) {
+	evm.mu.Lock()
+	defer evm.mu.Unlock()
 	if evm.cmd == nil {
-		evm.cmd = exec.Command(evm.path, "statetest")
+		evm.cmd = evm.execCommand(evm.path, "statetest")
 		if evm.stdout, err = evm.cmd.StdoutPipe(); err != nil {
 			return "", evm.cmd.String(), err
 		}
@@ -113,9 +120,12 @@ func (evm *ErigonBatchVM) GetStateRoot(path string) (root, command string, err e
 			return "", evm.cmd.String(), err
 		}
 	}
-	evm.mu.Lock()
-	defer evm.mu.Unlock()
-	_, _ = fmt.Fprintf(evm.stdin, "%v\n", path)
-	sRoot := evm.copyUntilEnd(io.Discard, evm.stdout, true)
-	return sRoot.StateRoot, evm.cmd.String(), nil
+	command = evm.cmd.String()
+	sRoot, err := execBatched(evm.cmd, evm.stdin, evm.stdout, path, ExecTimeout, func() stateRoot {
+		return evm.copyUntilEnd(io.Discard, evm.stdout, true, subtestCount(path))
+	})
+	if err != nil {
+		evm.cmd = nil // restart on next test
+	}
+	return sRoot.StateRoot, command, err
 }
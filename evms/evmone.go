@@ -18,14 +18,10 @@ package evms
 
 import (
 	"bytes"
-	"encoding/json"
 	"fmt"
 	"io"
 	"os"
-	"os/exec"
 	"time"
-
-	"github.com/ethereum/go-ethereum/log"
 )
 
 type EvmoneVM struct {
@@ -33,6 +29,8 @@ type EvmoneVM struct {
 	name string
 
 	stats *VMStat
+
+	cmdOpts // extra arguments and environment for the binary
 }
 
 func NewEvmoneVM(path string, name string) Evm {
@@ -51,24 +49,26 @@ func (evm *EvmoneVM) Name() string {
 	return evm.name
 }
 
-func (evm *EvmoneVM) GetStateRoot(path string) (root, command string, err error) {
-	cmd := exec.Command(evm.path, "--trace-summary", path)
-	data, err := StdErrOutput(cmd)
+// Kind implements ClientInfo.
+func (evm *EvmoneVM) Kind() string {
+	return "evmone"
+}
 
-	// In case of root hash mismatch evmone exists with 1. Ignore this.
-	if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
-		err = nil
-	}
-	if err != nil {
-		return "", cmd.String(), err
-	}
+// Binary implements ClientInfo.
+func (evm *EvmoneVM) Binary() string {
+	return evm.path
+}
 
-	root, err = evm.ParseStateRoot(data)
-	if err != nil {
-		log.Error("Failed to find stateroot", "vm", evm.Name(), "cmd", cmd.String())
-		return "", cmd.String(), err
-	}
-	return root, cmd.String(), nil
+// Version implements ClientInfo.
+func (evm *EvmoneVM) Version() (string, error) {
+	return binaryVersion(evm.execCommand(evm.path, "--version"))
+}
+
+func (evm *EvmoneVM) GetStateRoot(path string) (root, command string, err error) {
+	cmd := evm.execCommand(evm.path, "--trace-summary", path)
+	data, err := StdErrOutput(cmd)
+	root, err = checkRoot(cmd, data, err, evm.ParseStateRoot)
+	return root, cmd.String(), err
 }
 
 func (evm *EvmoneVM) ParseStateRoot(data []byte) (root string, err error) {
@@ -87,10 +87,10 @@ func (evm *EvmoneVM) RunStateTest(path string, out io.Writer, speedTest bool) (*
 		t0     = time.Now()
 		stderr io.ReadCloser
 		err    error
-		cmd    = exec.Command(evm.path, "--trace", path)
+		cmd    = evm.execCommand(evm.path, "--trace", path)
 	)
 	if speedTest {
-		cmd = exec.Command(evm.path, "--trace-summary", path)
+		cmd = evm.execCommand(evm.path, "--trace-summary", path)
 	}
 	if stderr, err = cmd.StderrPipe(); err != nil {
 		return nil, err
@@ -99,16 +99,14 @@ func (evm *EvmoneVM) RunStateTest(path string, out io.Writer, speedTest bool) (*
 		return nil, err
 	}
 
-	evm.Copy(out, stderr)
-	_, _ = io.ReadAll(stderr)
-	err = cmd.Wait()
+	var root stateRoot
+	err = waitTimed(cmd, stderr, ExecTimeout, func() {
+		root = evm.copyUntilEnd(out, evm.tee(stderr), subtestCount(path))
+		_, _ = io.ReadAll(stderr)
+	})
+	err = checkExit(cmd, root, err)
 	duration, slow := evm.stats.TraceDone(t0)
 
-	// In case of root hash mismatch evmone exists with 1. Ignore this.
-	if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
-		err = nil
-	}
-
 	return &tracingResult{
 		Slow:     slow,
 		ExecTime: duration,
@@ -120,19 +118,27 @@ func (evm *EvmoneVM) Close() {
 }
 
 func (evm *EvmoneVM) Copy(out io.Writer, input io.Reader) {
+	evm.copyUntilEnd(out, input, 1)
+}
+
+// copyUntilEnd reads from the reader, does some evmone-specific filtering and
+// writes the canonical output to the writer, until the stateroot is found.
+func (evm *EvmoneVM) copyUntilEnd(out io.Writer, input io.Reader, subtests int) stateRoot {
 	scanner := NewJsonlScanner("evmone", input, os.Stderr)
 	defer scanner.Release()
-	var stateRoot stateRoot
+	roots := &stateRoots{want: subtests}
 
 	for {
 		var elem opLog
 		if err := scanner.Next(&elem); err != nil {
 			break
 		}
-		// If we have a stateroot, we're done
+		// If we have a stateroot, the subtest is done
 		if len(elem.StateRoot1) != 0 {
-			stateRoot.StateRoot = elem.StateRoot1
-			break
+			if roots.add(out, elem.StateRoot1) {
+				break
+			}
+			continue
 		}
 		if elem.Depth == 0 {
 			continue
@@ -146,11 +152,7 @@ func (evm *EvmoneVM) Copy(out io.Writer, input io.Reader) {
 			fmt.Fprintf(os.Stderr, "Error writing to out: %v\n", err)
 		}
 	}
-	root, _ := json.Marshal(stateRoot)
-	if _, err := out.Write(append(root, '\n')); err != nil {
-		fmt.Fprintf(os.Stderr, "Error writing to output: %v\n", err)
-		return
-	}
+	return roots.finish(out)
 }
 
 func (evm *EvmoneVM) Stats() []any {
@@ -18,8 +18,12 @@ package evms
 
 import (
 	"bufio"
+	"encoding/json"
 	"fmt"
 	"io"
+	"maps"
+	"os"
+	"slices"
 	"strings"
 	"sync"
 )
@@ -35,7 +39,7 @@ type Evm interface {
 	// ParseStateRoot reads the stateroot from the combined output.
 	ParseStateRoot([]byte) (string, error)
 	// Copy takes the 'raw' output from the VM, and writes the
-	// canonical output to the given writer
+	// canonical output to the given writer, up to the first stateroot
 	Copy(out io.Writer, input io.Reader)
 	//Open() // Preparare for execution
 	Close() // Tear down processes
@@ -53,6 +57,130 @@ type stateRoot struct {
 	StateRoot string `json:"stateRoot"`
 }
 
+// stateRoots collects the stateroots in the output of a client. A client emits
+// one stateroot for each subtest of the statetest it executes.
+type stateRoots struct {
+	want  int // the number of subtests, zero to read until the end of the output
+	roots []string
+}
+
+// add writes the stateroot of a subtest to out, and reports whether all
+// subtests are done.
+func (r *stateRoots) add(out io.Writer, root string) bool {
+	r.roots = append(r.roots, root)
+	writeStateRoot(out, root)
+	return r.want > 0 && len(r.roots) >= r.want
+}
+
+// finish is called when the output has been read. If subtests are missing, an
+// empty stateroot is written to out. The stateroot of the first subtest is
+// returned, or an empty stateroot if subtests are missing.
+func (r *stateRoots) finish(out io.Writer) stateRoot {
+	if len(r.roots) == 0 || len(r.roots) < r.want {
+		writeStateRoot(out, "")
+		return stateRoot{}
+	}
+	return stateRoot{StateRoot: r.roots[0]}
+}
+
+func writeStateRoot(out io.Writer, root string) {
+	data, _ := json.Marshal(stateRoot{StateRoot: root})
+	if _, err := out.Write(append(data, '\n')); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing to out: %v\n", err)
+	}
+}
+
+// The subtests of the tests being executed, by path. A test is registered
+// before it is executed, so the vms need not parse it.
+var subtests = struct {
+	sync.Mutex
+	tests map[string]*registeredTest
+}{tests: make(map[string]*registeredTest)}
+
+type registeredTest struct {
+	forks []forkSubtests
+	refs  int // the number of registrations not yet forgotten
+}
+
+// forkSubtests is the number of subtests of a statetest for one fork.
+type forkSubtests struct {
+	fork  string
+	count int
+}
+
+// RegisterTest records the subtests of the statetest at the given path, for
+// the vms executing it. Each RegisterTest must be paired with a ForgetTest.
+func RegisterTest(path string) {
+	subtests.Lock()
+	defer subtests.Unlock()
+	if t, ok := subtests.tests[path]; ok {
+		t.refs++
+		return
+	}
+	subtests.tests[path] = &registeredTest{forks: parseSubtests(path), refs: 1}
+}
+
+// ForgetTest is called when the registered test has been executed.
+func ForgetTest(path string) {
+	subtests.Lock()
+	defer subtests.Unlock()
+	if t, ok := subtests.tests[path]; ok {
+		if t.refs--; t.refs == 0 {
+			delete(subtests.tests, path)
+		}
+	}
+}
+
+// subtestCount returns the number of subtests in the statetest file, which is
+// the number of stateroots a client emits when executing it. A test which is
+// not registered is assumed to have a single subtest.
+func subtestCount(path string) int {
+	var n int
+	for _, f := range testForks(path) {
+		n += f.count
+	}
+	return max(n, 1)
+}
+
+// testForks returns the number of subtests of each fork of the registered
+// statetest, in the order the clients execute them: sorted by fork name, as
+// the forks appear in the file. If the test is not registered, nil is returned.
+func testForks(path string) []forkSubtests {
+	subtests.Lock()
+	defer subtests.Unlock()
+	if t, ok := subtests.tests[path]; ok {
+		return t.forks
+	}
+	return nil
+}
+
+// parseSubtests parses the statetest file, and returns the number of subtests
+// of each fork, sorted by fork name. If the file cannot be read, nil is
+// returned.
+func parseSubtests(path string) []forkSubtests {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var testsByName map[string]struct {
+		Post map[string][]json.RawMessage `json:"post"`
+	}
+	if err := json.Unmarshal(data, &testsByName); err != nil {
+		return nil
+	}
+	counts := make(map[string]int)
+	for _, test := range testsByName {
+		for fork, posts := range test.Post {
+			counts[fork] += len(posts)
+		}
+	}
+	var forks []forkSubtests
+	for _, fork := range slices.Sorted(maps.Keys(counts)) {
+		forks = append(forks, forkSubtests{fork: fork, count: counts[fork]})
+	}
+	return forks
+}
+
 // CompareFiles returns true if the files are equal, along with the number of line s
 // compared
 func CompareFiles(vms []Evm, readers []io.Reader) (bool, int, string) {
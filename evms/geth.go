@@ -18,14 +18,13 @@ package evms
 
 import (
 	"bytes"
-	"encoding/json"
 	"fmt"
 	"io"
 	"os"
-	"os/exec"
+	"strings"
 	"time"
 
-	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/core/state"
 )
 
 // GethEVM is s Evm-interface wrapper around the `evm` binary, based on go-ethereum.
@@ -35,6 +34,8 @@ type GethEVM struct {
 
 	// Some metrics
 	stats *VMStat
+
+	cmdOpts // extra arguments and environment for the binary
 }
 
 func NewGethEVM(path string, name string) Evm {
@@ -53,23 +54,42 @@ func (evm *GethEVM) Name() string {
 	return evm.name
 }
 
+// Kind implements ClientInfo.
+func (evm *GethEVM) Kind() string {
+	return "geth"
+}
+
+// Binary implements ClientInfo.
+func (evm *GethEVM) Binary() string {
+	return evm.path
+}
+
+// Version implements ClientInfo.
+func (evm *GethEVM) Version() (string, error) {
+	return binaryVersion(evm.execCommand(evm.path, "--version"))
+}
+
 // GetStateRoot runs the test and returns the stateroot
 // This currently only works for non-filled statetests. TODO: make it work even if the
 // test is filled. Either by getting the whole trace, or adding stateroot to exec std output
 // even in success-case
 func (evm *GethEVM) GetStateRoot(path string) (root, command string, err error) {
 	// In this mode, we can run it without tracing
-	cmd := exec.Command(evm.path, "statetest", path)
-	data, err := cmd.Output()
-	if err != nil {
-		return "", cmd.String(), err
-	}
-	root, err = evm.ParseStateRoot(data)
+	cmd := evm.execCommand(evm.path, firstFork(path, "statetest")...)
+	data, err := outputTimed(cmd, false, ExecTimeout)
+	root, err = checkRoot(cmd, data, err, evm.ParseStateRoot)
+	return root, cmd.String(), err
+}
+
+// DumpState implements StateDumper.
+func (evm *GethEVM) DumpState(path string) (*state.Dump, string, error) {
+	cmd := evm.execCommand(evm.path, firstFork(path, "statetest", "--dump")...)
+	data, err := outputTimed(cmd, false, ExecTimeout)
 	if err != nil {
-		log.Error("Failed to find stateroot", "vm", evm.Name(), "cmd", cmd.String())
-		return "", cmd.String(), err
+		return nil, cmd.String(), err
 	}
-	return root, cmd.String(), err
+	dump, err := ParseStateDump(data)
+	return dump, cmd.String(), err
 }
 
 // ParseStateRoot reads geth's stateroot from the combined output.
@@ -82,38 +102,78 @@ func (evm *GethEVM) ParseStateRoot(data []byte) (string, error) {
 	return string(data[start+14 : end]), nil
 }
 
-// RunStateTest implements the Evm interface
+// RunStateTest implements the Evm interface. Geth executes the forks of a
+// test in random order, so a test for several forks is executed one fork at a
+// time, in the order of the other clients.
 func (evm *GethEVM) RunStateTest(path string, out io.Writer, speedTest bool) (*tracingResult, error) {
 	var (
-		t0     = time.Now()
+		t0    = time.Now()
+		forks = testForks(path)
+		cmds  []string
+		err   error
+	)
+	if len(forks) < 2 {
+		forks = []forkSubtests{{count: subtestCount(path)}}
+	}
+	for _, f := range forks {
+		var cmd string
+		cmd, err = evm.runFork(path, f, out, speedTest)
+		cmds = append(cmds, cmd)
+		if err != nil {
+			break
+		}
+	}
+	// release resources
+	duration, slow := evm.stats.TraceDone(t0)
+
+	return &tracingResult{
+		Slow:     slow,
+		ExecTime: duration,
+		Cmd:      strings.Join(cmds, " && "),
+	}, err
+}
+
+// runFork executes the subtests of the given fork, or all subtests if no fork
+// is set, and returns the command.
+func (evm *GethEVM) runFork(path string, f forkSubtests, out io.Writer, speedTest bool) (string, error) {
+	var (
 		stderr io.ReadCloser
 		err    error
-		//cmd    = exec.Command(evm.path, "--json", "--noreturndata", "--nomemory", "statetest", path)
-		cmd = exec.Command(evm.path, "statetest", "--trace", "--trace.format=json",
-			"--trace.nomemory=true", "--trace.noreturndata=true", path)
+		//args   = []string{"--json", "--noreturndata", "--nomemory", "statetest"}
+		args = []string{"statetest", "--trace", "--trace.format=json",
+			"--trace.nomemory=true", "--trace.noreturndata=true"}
 	)
 	if speedTest {
-		//cmd = exec.Command(evm.path, "--nomemory", "--noreturndata", "--nostack", "statetest", path)
-		cmd = exec.Command(evm.path, "statetest", path)
+		//args = []string{"--nomemory", "--noreturndata", "--nostack", "statetest"}
+		args = []string{"statetest"}
+	}
+	if f.fork != "" {
+		args = append(args, "--statetest.fork", f.fork)
 	}
+	cmd := evm.execCommand(evm.path, append(args, path)...)
 	if stderr, err = cmd.StderrPipe(); err != nil {
-		return &tracingResult{Cmd: cmd.String()}, err
+		return cmd.String(), err
 	}
 	if err = cmd.Start(); err != nil {
-		return &tracingResult{Cmd: cmd.String()}, err
+		return cmd.String(), err
 	}
+	var root stateRoot
 	// copy everything to the given writer
-	evm.Copy(out, stderr)
-	_, _ = io.ReadAll(stderr)
-	err = cmd.Wait()
-	// release resources
-	duration, slow := evm.stats.TraceDone(t0)
+	err = waitTimed(cmd, stderr, ExecTimeout, func() {
+		root = evm.copyUntilEnd(out, evm.tee(stderr), f.count)
+		_, _ = io.ReadAll(stderr)
+	})
+	return cmd.String(), checkExit(cmd, root, err)
+}
 
-	return &tracingResult{
-		Slow:     slow,
-		ExecTime: duration,
-		Cmd:      cmd.String(),
-	}, err
+// firstFork appends the path to the given arguments. For a test for several
+// forks, geth is also told to execute only the first fork, whose stateroot
+// the other clients report first.
+func firstFork(path string, args ...string) []string {
+	if forks := testForks(path); len(forks) > 1 {
+		args = append(args, "--statetest.fork", forks[0].fork)
+	}
+	return append(args, path)
 }
 
 func (evm *GethEVM) Close() {
@@ -122,15 +182,16 @@ func (evm *GethEVM) Close() {
 // Copy reads from the reader, does some geth-specific filtering and
 // outputs items onto the channel
 func (evm *GethEVM) Copy(out io.Writer, input io.Reader) {
-	evm.copyUntilEnd(out, input)
+	evm.copyUntilEnd(out, input, 1)
 }
 
 // copyUntilEnd reads from the reader, does some geth-specific filtering and
-// outputs items onto the channel
-func (evm *GethEVM) copyUntilEnd(out io.Writer, input io.Reader) stateRoot {
+// outputs items onto the channel, until the given number of subtests are done
+// (zero meaning all of the input).
+func (evm *GethEVM) copyUntilEnd(out io.Writer, input io.Reader, subtests int) stateRoot {
 	scanner := NewJsonlScanner("geth", input, os.Stderr)
 	defer scanner.Release()
-	var stateRoot stateRoot
+	roots := &stateRoots{want: subtests}
 	// When geth encounters an error, it may already have spat out the info, prematurely.
 	// We need to merge it back to one item
 	// https://github.com/ethereum/go-ethereum/pull/23970#issuecomment-979851712
@@ -159,10 +220,14 @@ func (evm *GethEVM) copyUntilEnd(out io.Writer, input io.Reader) stateRoot {
 		if err := scanner.Next(&elem); err != nil {
 			break
 		}
-		// If we have a stateroot, we're done
+		// If we have a stateroot, the subtest is done
 		if len(elem.StateRoot1) != 0 {
-			stateRoot.StateRoot = elem.StateRoot1
-			break
+			yield(nil)
+			prev = nil
+			if roots.add(out, elem.StateRoot1) {
+				break
+			}
+			continue
 		}
 		// If the output cannot be marshalled, all fields will be blanks.
 		// We can detect that through 'depth', which should never be less than 1
@@ -178,11 +243,7 @@ func (evm *GethEVM) copyUntilEnd(out io.Writer, input io.Reader) stateRoot {
 		yield(&elem)
 	}
 	yield(nil)
-	root, _ := json.Marshal(stateRoot)
-	if _, err := out.Write(append(root, '\n')); err != nil {
-		fmt.Fprintf(os.Stderr, "Error writing to out: %v\n", err)
-	}
-	return stateRoot
+	return roots.finish(out)
 }
 
 func (evm *GethEVM) Stats() []any {
@@ -17,9 +17,12 @@
 package evms
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
+	"os"
 	"os/exec"
+	"strings"
 	"sync"
 	"time"
 )
@@ -35,16 +38,17 @@ type GethBatchVM struct {
 
 func NewGethBatchVM(path, name string) Evm {
 	return &GethBatchVM{
-		GethEVM: GethEVM{path, name, &VMStat{}},
+		GethEVM: GethEVM{path: path, name: name, stats: &VMStat{}},
 	}
 }
 
 func (evm *GethBatchVM) Instance(threadID int) Evm {
 	return &GethBatchVM{
 		GethEVM: GethEVM{
-			path:  evm.path,
-			name:  fmt.Sprintf("%v-%d", evm.name, threadID),
-			stats: evm.stats,
+			path:    evm.path,
+			name:    fmt.Sprintf("%v-%d", evm.name, threadID),
+			stats:   evm.stats,
+			cmdOpts: evm.cmdOpts,
 		},
 	}
 }
@@ -58,13 +62,15 @@ func (evm *GethBatchVM) RunStateTest(path string, out io.Writer, speedTest bool)
 		stdout io.ReadCloser
 		stdin  io.WriteCloser
 	)
+	evm.mu.Lock()
+	defer evm.mu.Unlock()
 	if evm.cmd == nil {
 		if speedTest {
 			//cmd = exec.Command(evm.path, "--nomemory", "--noreturndata", "--nostack", "statetest")
-			cmd = exec.Command(evm.path, "statetest")
+			cmd = evm.execCommand(evm.path, "statetest")
 		} else {
 			//cmd = exec.Command(evm.path, "--json", "--noreturndata", "--nomemory", "statetest")
-			cmd = exec.Command(evm.path, "statetest", "--trace", "--trace.format=json",
+			cmd = evm.execCommand(evm.path, "statetest", "--trace", "--trace.format=json",
 				"--trace.nomemory=true", "--trace.noreturndata=true")
 
 		}
@@ -81,12 +87,24 @@ func (evm *GethBatchVM) RunStateTest(path string, out io.Writer, speedTest bool)
 		evm.stdout = stdout
 		evm.stdin = stdin
 	}
-	evm.mu.Lock()
-	defer evm.mu.Unlock()
-	_, _ = fmt.Fprintf(evm.stdin, "%v\n", path)
+	forks, cleanup, err := splitForks(path)
+	if err != nil {
+		return &tracingResult{Cmd: evm.cmd.String()}, err
+	}
+	defer cleanup()
 	// copy everything for the _current_ statetest to the given writer
-	evm.copyUntilEnd(out, evm.stdout)
-	// release resources, handle error but ignore non-zero exit codes
+	for _, f := range forks {
+		_, err = execBatched(evm.cmd, evm.stdin, evm.stdout, f.path, ExecTimeout, func() stateRoot {
+			return evm.copyUntilEnd(out, evm.tee(evm.stdout), f.count)
+		})
+		if err != nil {
+			break
+		}
+	}
+	if err != nil {
+		cmd, evm.cmd = evm.cmd, nil // restart on next test
+		return &tracingResult{Cmd: cmd.String()}, err
+	}
 	duration, slow := evm.stats.TraceDone(t0)
 	return &tracingResult{
 			Slow:     slow,
@@ -105,9 +123,11 @@ func (evm *GethBatchVM) Close() {
 }
 
 func (evm *GethBatchVM) GetStateRoot(path string) (root, command string, err error) {
+	evm.mu.Lock()
+	defer evm.mu.Unlock()
 	if evm.cmd == nil {
 		//evm.cmd = exec.Command(evm.path, "--nomemory", "--noreturndata", "--nostack", "statetest")
-		evm.cmd = exec.Command(evm.path, "statetest")
+		evm.cmd = evm.execCommand(evm.path, "statetest")
 		if evm.stdout, err = evm.cmd.StderrPipe(); err != nil {
 			return "", evm.cmd.String(), err
 		}
@@ -118,9 +138,77 @@ func (evm *GethBatchVM) GetStateRoot(path string) (root, command string, err err
 			return "", evm.cmd.String(), err
 		}
 	}
-	evm.mu.Lock()
-	defer evm.mu.Unlock()
-	_, _ = fmt.Fprintf(evm.stdin, "%v\n", path)
-	sRoot := evm.copyUntilEnd(io.Discard, evm.stdout)
-	return sRoot.StateRoot, evm.cmd.String(), nil
+	command = evm.cmd.String()
+	// The stateroot of the first fork is reported
+	forks, cleanup, err := splitForks(path)
+	if err != nil {
+		return "", command, err
+	}
+	defer cleanup()
+	sRoot, err := execBatched(evm.cmd, evm.stdin, evm.stdout, forks[0].path, ExecTimeout, func() stateRoot {
+		return evm.copyUntilEnd(io.Discard, evm.stdout, forks[0].count)
+	})
+	if err != nil {
+		evm.cmd = nil // restart on next test
+	}
+	return sRoot.StateRoot, command, err
+}
+
+// forkPath is a statetest file, and its number of subtests.
+type forkPath struct {
+	path  string
+	count int
+}
+
+// splitForks writes a copy of a statetest for several forks for each of the
+// forks, holding only the post-states of that fork, so that geth can execute
+// the forks in the order of the other clients. It returns the copies, and a
+// function which removes them. A test for a single fork is not copied.
+func splitForks(path string) ([]forkPath, func(), error) {
+	forks := testForks(path)
+	if len(forks) < 2 {
+		return []forkPath{{path, subtestCount(path)}}, func() {}, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	var (
+		testsByName map[string]map[string]json.RawMessage
+		posts       = make(map[string]map[string]json.RawMessage)
+		paths       []forkPath
+	)
+	if err := json.Unmarshal(data, &testsByName); err != nil {
+		return nil, nil, err
+	}
+	for name, test := range testsByName {
+		var post map[string]json.RawMessage
+		if err := json.Unmarshal(test["post"], &post); err != nil {
+			return nil, nil, err
+		}
+		posts[name] = post
+	}
+	cleanup := func() {
+		for _, f := range paths {
+			os.Remove(f.path)
+		}
+	}
+	for _, f := range forks {
+		for name, test := range testsByName {
+			post, _ := json.Marshal(map[string]json.RawMessage{f.fork: posts[name][f.fork]})
+			test["post"] = post
+		}
+		data, err := json.Marshal(testsByName)
+		if err != nil {
+			cleanup()
+			return nil, nil, err
+		}
+		fpath := fmt.Sprintf("%v.%v.json", strings.TrimSuffix(path, ".json"), f.fork)
+		if err := os.WriteFile(fpath, data, 0644); err != nil {
+			cleanup()
+			return nil, nil, err
+		}
+		paths = append(paths, forkPath{fpath, f.count})
+	}
+	return paths, cleanup, nil
 }
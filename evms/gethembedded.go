@@ -0,0 +1,200 @@
+// Copyright 2026 Martin Holst Swende
+// This file is part of the goevmlab library.
+//
+// The library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the goevmlab library. If not, see <http://www.gnu.org/licenses/>.
+
+package evms
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers/logger"
+	"github.com/ethereum/go-ethereum/tests"
+	"github.com/ethereum/go-ethereum/version"
+)
+
+// EmbeddedGethVM executes statetests in-process, using the go-ethereum
+// statetest runner that goevmlab links against. It does not need any external
+// binary. The output is produced in the same format as the `evm` binary, and
+// is normalized in the same way as for the GethEVM.
+type EmbeddedGethVM struct {
+	GethEVM
+}
+
+func NewEmbeddedGethVM(name string) Evm {
+	return &EmbeddedGethVM{
+		GethEVM: GethEVM{
+			name:  name,
+			stats: &VMStat{},
+		},
+	}
+}
+
+func (evm *EmbeddedGethVM) Instance(int) Evm {
+	return evm
+}
+
+// Binary implements ClientInfo.
+func (evm *EmbeddedGethVM) Binary() string {
+	return ""
+}
+
+// Version implements ClientInfo, reporting the linked go-ethereum version.
+func (evm *EmbeddedGethVM) Version() (string, error) {
+	return fmt.Sprintf("go-ethereum %d.%d.%d-%v (embedded)", version.Major, version.Minor, version.Patch, version.Meta), nil
+}
+
+// command returns a pseudo-command, describing the execution.
+func (evm *EmbeddedGethVM) command(path string, trace bool) string {
+	if trace {
+		return fmt.Sprintf("<embedded go-ethereum> statetest --trace %v", path)
+	}
+	return fmt.Sprintf("<embedded go-ethereum> statetest %v", path)
+}
+
+// run executes all subtests in the given file, and writes the (raw) output
+// to the given writer, in the same format as `evm statetest` does. If dump is
+// non-nil, it is called with the post-state of each subtest.
+func (evm *EmbeddedGethVM) run(path string, out io.Writer, trace bool, dump func(*state.Dump)) (err error) {
+	// A panic in the vm should not take down the whole process.
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic executing %v: %v", path, r)
+		}
+	}()
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var testsByName map[string]tests.StateTest
+	if err := json.Unmarshal(src, &testsByName); err != nil {
+		return fmt.Errorf("unable to read test file %s: %w", path, err)
+	}
+	var names []string
+	for name := range testsByName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		test := testsByName[name]
+		subtests := test.Subtests()
+		sort.SliceStable(subtests, func(i, j int) bool {
+			if subtests[i].Fork != subtests[j].Fork {
+				return subtests[i].Fork < subtests[j].Fork
+			}
+			return subtests[i].Index < subtests[j].Index
+		})
+		for _, st := range subtests {
+			cfg := vm.Config{}
+			if trace {
+				cfg.Tracer = logger.NewJSONLogger(&logger.Config{}, out)
+			}
+			// Mirror what the `evm statetest` command does: the post-check
+			// errors are ignored, we're only interested in the stateroot.
+			_ = test.Run(st, cfg, false, rawdb.HashScheme, func(err error, post *tests.StateTestState) {
+				if post.StateDB != nil {
+					root := post.StateDB.IntermediateRoot(false)
+					fmt.Fprintf(out, "{\"stateRoot\": \"%#x\"}\n", root)
+					if dump != nil {
+						// Same as `evm statetest --dump`
+						cpy, _ := state.New(root, post.StateDB.Database())
+						d := cpy.RawDump(nil)
+						dump(&d)
+					}
+				}
+			})
+		}
+	}
+	return nil
+}
+
+// GetStateRoot runs the test and returns the stateroot.
+func (evm *EmbeddedGethVM) GetStateRoot(path string) (root, command string, err error) {
+	var out bytes.Buffer
+	command = evm.command(path, false)
+	if err := evm.run(path, &out, false, nil); err != nil {
+		return "", command, err
+	}
+	root, err = evm.ParseStateRoot(out.Bytes())
+	return root, command, err
+}
+
+// DumpState implements StateDumper.
+func (evm *EmbeddedGethVM) DumpState(path string) (*state.Dump, string, error) {
+	var dumps []*state.Dump
+	command := evm.command(path, false)
+	if err := evm.run(path, io.Discard, false, func(d *state.Dump) {
+		dumps = append(dumps, d)
+	}); err != nil {
+		return nil, command, err
+	}
+	if len(dumps) == 0 {
+		return nil, command, errors.New("no state in dump")
+	}
+	return dumps[0], command, nil
+}
+
+// RunStateTest implements the Evm interface
+func (evm *EmbeddedGethVM) RunStateTest(path string, out io.Writer, speedTest bool) (*tracingResult, error) {
+	var (
+		t0      = time.Now()
+		pr, pw  = io.Pipe()
+		command = evm.command(path, !speedTest)
+		errCh   = make(chan error, 1)
+	)
+	go func() {
+		err := evm.run(path, pw, !speedTest, nil)
+		pw.Close()
+		errCh <- err
+	}()
+	copied := make(chan struct{})
+	go func() {
+		// copy everything to the given writer
+		evm.copyUntilEnd(out, evm.tee(pr), subtestCount(path))
+		_, _ = io.Copy(io.Discard, pr)
+		close(copied)
+	}()
+	var timer <-chan time.Time
+	if ExecTimeout > 0 {
+		t := time.NewTimer(ExecTimeout)
+		defer t.Stop()
+		timer = t.C
+	}
+	select {
+	case <-copied:
+	case <-timer:
+		// The execution cannot be interrupted. Instead, the output is cut
+		// off, and the execution is left to finish in the background.
+		pr.CloseWithError(errors.New("timeout"))
+		<-copied
+		return &tracingResult{Cmd: command}, &CrashError{Cmd: command, Timeout: true}
+	}
+	err := <-errCh
+	duration, slow := evm.stats.TraceDone(t0)
+	return &tracingResult{
+		Slow:     slow,
+		ExecTime: duration,
+		Cmd:      command,
+	}, err
+}
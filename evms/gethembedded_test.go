@@ -0,0 +1,105 @@
+// Copyright 2026 Martin Holst Swende
+// This file is part of the goevmlab library.
+//
+// The library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the goevmlab library. If not, see <http://www.gnu.org/licenses/>.
+
+package evms
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestEmbeddedGeth executes the reference tests on the in-process geth, and
+// compares the output against the recorded output of the geth binary.
+func TestEmbeddedGeth(t *testing.T) {
+	finfos, err := os.ReadDir(filepath.Join("testdata", "cases"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		embedded = NewEmbeddedGethVM("embedded")
+		geth     = NewGethEVM("", "geth")
+	)
+	for _, finfo := range finfos {
+		testfile := filepath.Join("testdata", "cases", finfo.Name())
+		// The reference output
+		want := new(bytes.Buffer)
+		raw, err := os.Open(fmt.Sprintf("%v.geth.stderr.txt", filepath.Join("testdata", "traces", finfo.Name())))
+		if err != nil {
+			t.Fatal(err)
+		}
+		geth.Copy(want, raw)
+		raw.Close()
+
+		have := new(bytes.Buffer)
+		if _, err := embedded.RunStateTest(testfile, have, false); err != nil {
+			t.Fatalf("%v: %v", finfo.Name(), err)
+		}
+		if div := DiffFiles([]Evm{geth, embedded}, []io.Reader{want, have}, 3); div != nil {
+			t.Errorf("%v: %v", finfo.Name(), div.Report())
+		}
+	}
+}
+
+// TestEmbeddedGethSubtests checks that each subtest of a statetest is
+// executed, yielding a stateroot each.
+func TestEmbeddedGethSubtests(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "cases", "statetest1.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tests map[string]map[string]any
+	if err := json.Unmarshal(data, &tests); err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range tests {
+		tx := test["transaction"].(map[string]any)
+		tx["gasLimit"] = append(tx["gasLimit"].([]any), "0x5208")
+		post := test["post"].(map[string]any)
+		post["Byzantium"] = append(post["Byzantium"].([]any), map[string]any{
+			"hash":    "0x0000000000000000000000000000000000000000000000000000000000000000",
+			"logs":    "0x0000000000000000000000000000000000000000000000000000000000000000",
+			"indexes": map[string]int{"data": 0, "gas": 1, "value": 0},
+		})
+	}
+	testfile := filepath.Join(t.TempDir(), "subtests.json")
+	data, _ = json.Marshal(tests)
+	if err := os.WriteFile(testfile, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if have := subtestCount(testfile); have != 1 {
+		t.Fatalf("unregistered test: wrong number of subtests: %d", have)
+	}
+	RegisterTest(testfile)
+	RegisterTest(testfile)
+	ForgetTest(testfile)
+	defer ForgetTest(testfile)
+	if have := subtestCount(testfile); have != 2 {
+		t.Fatalf("wrong number of subtests: %d", have)
+	}
+	out := new(bytes.Buffer)
+	if _, err := NewEmbeddedGethVM("embedded").RunStateTest(testfile, out, false); err != nil {
+		t.Fatal(err)
+	}
+	roots := bytes.Count(out.Bytes(), []byte(`{"stateRoot":"0x`))
+	if roots != 2 {
+		t.Fatalf("wrong number of stateroots: %d\n%s", roots, out)
+	}
+}
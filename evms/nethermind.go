@@ -22,7 +22,6 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"os/exec"
 	"time"
 
 	"github.com/ethereum/go-ethereum/log"
@@ -34,6 +33,8 @@ type NethermindVM struct {
 	name string
 	// Some metrics
 	stats *VMStat
+
+	cmdOpts // extra arguments and environment for the binary
 }
 
 func NewNethermindVM(path, name string) Evm {
@@ -46,9 +47,10 @@ func NewNethermindVM(path, name string) Evm {
 
 func (evm *NethermindVM) Instance(threadID int) Evm {
 	return &NethermindVM{
-		path:  evm.path,
-		name:  fmt.Sprintf("%v-%d", evm.name, threadID),
-		stats: evm.stats,
+		path:    evm.path,
+		name:    fmt.Sprintf("%v-%d", evm.name, threadID),
+		stats:   evm.stats,
+		cmdOpts: evm.cmdOpts,
 	}
 }
 
@@ -56,19 +58,27 @@ func (evm *NethermindVM) Name() string {
 	return evm.name
 }
 
+// Kind implements ClientInfo.
+func (evm *NethermindVM) Kind() string {
+	return "nethermind"
+}
+
+// Binary implements ClientInfo.
+func (evm *NethermindVM) Binary() string {
+	return evm.path
+}
+
+// Version implements ClientInfo.
+func (evm *NethermindVM) Version() (string, error) {
+	return binaryVersion(evm.execCommand(evm.path, "--version"))
+}
+
 // GetStateRoot runs the test and returns the stateroot
 func (evm *NethermindVM) GetStateRoot(path string) (root, command string, err error) {
 	// In this mode, we can run it without tracing
-	cmd := exec.Command(evm.path, "--neverTrace", "-m", "-s", "--stateTest", "-i", path)
-	data, err := cmd.Output()
-	if err != nil {
-		return "", cmd.String(), err
-	}
-	root, err = evm.ParseStateRoot(data)
-	if err != nil {
-		log.Error("Failed to find stateroot", "vm", evm.Name(), "cmd", cmd.String())
-		return "", cmd.String(), err
-	}
+	cmd := evm.execCommand(evm.path, "--neverTrace", "-m", "-s", "--stateTest", "-i", path)
+	data, err := outputTimed(cmd, false, ExecTimeout)
+	root, err = checkRoot(cmd, data, err, evm.ParseStateRoot)
 	return root, cmd.String(), err
 }
 
@@ -88,7 +98,7 @@ func (evm *NethermindVM) RunStateTest(path string, out io.Writer, speedTest bool
 		t0      = time.Now()
 		procOut io.ReadCloser
 		err     error
-		cmd     = exec.Command(evm.path, "--trace", "-m", "--stateTest", "--input", path)
+		cmd     = evm.execCommand(evm.path, "--trace", "-m", "--stateTest", "--input", path)
 	)
 	if !speedTest {
 		// in normal execution, we read traces from standard error
@@ -98,7 +108,7 @@ func (evm *NethermindVM) RunStateTest(path string, out io.Writer, speedTest bool
 	} else {
 		// In speedtest-mode, we don't want the actual traces, but we do
 		// need to read the stateroot. The stateroot can be found on stdout
-		cmd = exec.Command(evm.path, "-m", "--neverTrace", "--stateTest", "--input", path)
+		cmd = evm.execCommand(evm.path, "-m", "--neverTrace", "--stateTest", "--input", path)
 		if procOut, err = cmd.StdoutPipe(); err != nil {
 			return &tracingResult{Cmd: cmd.String()}, err
 		}
@@ -106,16 +116,19 @@ func (evm *NethermindVM) RunStateTest(path string, out io.Writer, speedTest bool
 	if err = cmd.Start(); err != nil {
 		return &tracingResult{Cmd: cmd.String()}, err
 	}
+	var root stateRoot
 	// copy everything to the given writer
-	evm.copyUntilEnd(out, procOut, speedTest)
-	// release resources, handle error but ignore non-zero exit codes
-	_, _ = io.ReadAll(procOut)
-	_ = cmd.Wait()
+	err = waitTimed(cmd, procOut, ExecTimeout, func() {
+		root = evm.copyUntilEnd(out, evm.tee(procOut), speedTest, subtestCount(path))
+		// release resources, handle error but ignore non-zero exit codes
+		_, _ = io.ReadAll(procOut)
+	})
+	err = checkExit(cmd, root, err)
 	duration, slow := evm.stats.TraceDone(t0)
 	return &tracingResult{
 		Slow:     slow,
 		ExecTime: duration,
-		Cmd:      cmd.String()}, nil
+		Cmd:      cmd.String()}, err
 }
 
 func (evm *NethermindVM) Close() {
@@ -124,10 +137,10 @@ func (evm *NethermindVM) Close() {
 // Copy feed reads from the reader, does some vm-specific filtering and
 // outputs items onto the channel
 func (evm *NethermindVM) Copy(out io.Writer, input io.Reader) {
-	evm.copyUntilEnd(out, input, false)
+	evm.copyUntilEnd(out, input, false, 1)
 }
 
-func (evm *NethermindVM) copyUntilEnd(out io.Writer, input io.Reader, speedMode bool) stateRoot {
+func (evm *NethermindVM) copyUntilEnd(out io.Writer, input io.Reader, speedMode bool, subtests int) stateRoot {
 	if speedMode {
 		// In speednode, there's no jsonl output, it instead looks like
 		var r []stateRoot
@@ -135,24 +148,26 @@ func (evm *NethermindVM) copyUntilEnd(out io.Writer, input io.Reader, speedMode
 			log.Warn("Error parsing nethermind output", "error", err)
 			return stateRoot{}
 		}
-		rootJSON, _ := json.Marshal(r[0])
-		if _, err := out.Write(append(rootJSON, '\n')); err != nil {
-			fmt.Fprintf(os.Stderr, "Error writing to out: %v\n", err)
+		roots := &stateRoots{want: subtests}
+		for _, root := range r {
+			roots.add(out, root.StateRoot)
 		}
-		return r[0]
+		return roots.finish(out)
 	}
 	scanner := NewJsonlScanner("neth", input, os.Stderr)
 	defer scanner.Release()
-	var stateRoot stateRoot
+	roots := &stateRoots{want: subtests}
 	for {
 		var elem opLog
 		if err := scanner.Next(&elem); err != nil {
 			break
 		}
-		// If we have a stateroot, we're done
+		// If we have a stateroot, the subtest is done
 		if len(elem.StateRoot1) != 0 {
-			stateRoot.StateRoot = elem.StateRoot1
-			break
+			if roots.add(out, elem.StateRoot1) {
+				break
+			}
+			continue
 		}
 		if elem.Depth == 0 {
 			continue
@@ -164,14 +179,10 @@ func (evm *NethermindVM) copyUntilEnd(out io.Writer, input io.Reader, speedMode
 		outp := CustomMarshal(&elem)
 		if _, err := out.Write(append(outp, '\n')); err != nil {
 			fmt.Fprintf(os.Stderr, "Error writing to out: %v\n", err)
-			return stateRoot
+			return stateRoot{}
 		}
 	}
-	root, _ := json.Marshal(stateRoot)
-	if _, err := out.Write(append(root, '\n')); err != nil {
-		fmt.Fprintf(os.Stderr, "Error writing to out: %v\n", err)
-	}
-	return stateRoot
+	return roots.finish(out)
 }
 
 func (evm *NethermindVM) Stats() []any {
@@ -22,8 +22,6 @@ import (
 	"os/exec"
 	"sync"
 	"time"
-
-	"github.com/ethereum/go-ethereum/log"
 )
 
 // The NethermindBatchVM spins up one 'master' instance of the VM, and uses that to execute tests
@@ -37,16 +35,17 @@ type NethermindBatchVM struct {
 
 func NewNethermindBatchVM(path, name string) Evm {
 	return &NethermindBatchVM{
-		NethermindVM: NethermindVM{path, name, &VMStat{}},
+		NethermindVM: NethermindVM{path: path, name: name, stats: &VMStat{}},
 	}
 }
 
 func (evm *NethermindBatchVM) Instance(threadID int) Evm {
 	return &NethermindBatchVM{
 		NethermindVM: NethermindVM{
-			path:  evm.path,
-			name:  fmt.Sprintf("%v-%d", evm.name, threadID),
-			stats: evm.stats,
+			path:    evm.path,
+			name:    fmt.Sprintf("%v-%d", evm.name, threadID),
+			stats:   evm.stats,
+			cmdOpts: evm.cmdOpts,
 		},
 	}
 }
@@ -58,8 +57,10 @@ func (evm *NethermindBatchVM) RunStateTest(path string, out io.Writer, speedTest
 		err     error
 		procOut io.ReadCloser
 		stdin   io.WriteCloser
-		cmd     = exec.Command(evm.path, "-x", "--trace", "-m", "--stateTest")
+		cmd     = evm.execCommand(evm.path, "-x", "--trace", "-m", "--stateTest")
 	)
+	evm.mu.Lock()
+	defer evm.mu.Unlock()
 	if evm.cmd == nil {
 		if !speedTest {
 			// in normal execution, we read traces from standard error
@@ -69,7 +70,7 @@ func (evm *NethermindBatchVM) RunStateTest(path string, out io.Writer, speedTest
 		} else {
 			// In speedtest-mode, we don't want the actual traces, but we do
 			// need to read the stateroot. The stateroot can be found on stdout
-			cmd = exec.Command(evm.path, "-x", "-m", "--stateTest", "--neverTrace")
+			cmd = evm.execCommand(evm.path, "-x", "-m", "--stateTest", "--neverTrace")
 			if procOut, err = cmd.StdoutPipe(); err != nil {
 				return &tracingResult{Cmd: cmd.String()}, err
 			}
@@ -84,14 +85,14 @@ func (evm *NethermindBatchVM) RunStateTest(path string, out io.Writer, speedTest
 		evm.procOut = procOut
 		evm.stdin = stdin
 	}
-	evm.mu.Lock()
-	defer evm.mu.Unlock()
-	_, err = fmt.Fprintf(evm.stdin, "%v\n", path)
+	// copy everything for the _current_ statetest to the given writer
+	_, err = execBatched(evm.cmd, evm.stdin, evm.procOut, path, ExecTimeout, func() stateRoot {
+		return evm.copyUntilEnd(out, evm.tee(evm.procOut), speedTest, subtestCount(path))
+	})
 	if err != nil {
-		log.Error("Error writing to nethermind", "err", err)
+		cmd, evm.cmd = evm.cmd, nil // restart on next test
+		return &tracingResult{Cmd: cmd.String()}, err
 	}
-	// copy everything for the _current_ statetest to the given writer
-	evm.copyUntilEnd(out, evm.procOut, speedTest)
 	duration, slow := evm.stats.TraceDone(t0)
 	return &tracingResult{
 		Slow:     slow,
@@ -110,8 +111,10 @@ func (evm *NethermindBatchVM) Close() {
 }
 
 func (evm *NethermindBatchVM) GetStateRoot(path string) (root, command string, err error) {
+	evm.mu.Lock()
+	defer evm.mu.Unlock()
 	if evm.cmd == nil {
-		evm.cmd = exec.Command(evm.path, "--neverTrace", "-m", "-s", "--stateTest", "-x")
+		evm.cmd = evm.execCommand(evm.path, "--neverTrace", "-m", "-s", "--stateTest", "-x")
 		if evm.procOut, err = evm.cmd.StdoutPipe(); err != nil {
 			return "", evm.cmd.String(), err
 		}
@@ -122,9 +125,12 @@ func (evm *NethermindBatchVM) GetStateRoot(path string) (root, command string, e
 			return "", evm.cmd.String(), err
 		}
 	}
-	evm.mu.Lock()
-	defer evm.mu.Unlock()
-	_, _ = fmt.Fprintf(evm.stdin, "%v\n", path)
-	sRoot := evm.copyUntilEnd(io.Discard, evm.procOut, true)
-	return sRoot.StateRoot, evm.cmd.String(), nil
+	command = evm.cmd.String()
+	sRoot, err := execBatched(evm.cmd, evm.stdin, evm.procOut, path, ExecTimeout, func() stateRoot {
+		return evm.copyUntilEnd(io.Discard, evm.procOut, true, subtestCount(path))
+	})
+	if err != nil {
+		evm.cmd = nil // restart on next test
+	}
+	return sRoot.StateRoot, command, err
 }
@@ -35,6 +35,8 @@ type NimbusEVM struct {
 	name string
 	// Some metrics
 	stats *VMStat
+
+	cmdOpts // extra arguments and environment for the binary
 }
 
 func NewNimbusEVM(path string, name string) Evm {
@@ -53,20 +55,30 @@ func (evm *NimbusEVM) Name() string {
 	return evm.name
 }
 
+// Kind implements ClientInfo.
+func (evm *NimbusEVM) Kind() string {
+	return "nimbus"
+}
+
+// Binary implements ClientInfo.
+func (evm *NimbusEVM) Binary() string {
+	return evm.path
+}
+
+// Version implements ClientInfo.
+func (evm *NimbusEVM) Version() (string, error) {
+	return binaryVersion(evm.execCommand(evm.path, "--version"))
+}
+
 // GetStateRoot runs the test and returns the stateroot
 // This currently only works for non-filled statetests. TODO: make it work even if the
 // test is filled. Either by getting the whole trace, or adding stateroot to exec std output
 // even in success-case
 func (evm *NimbusEVM) GetStateRoot(path string) (root, command string, err error) {
 	// In this mode, we can run it without tracing
-	cmd := exec.Command(evm.path, path)
-	data, _ := cmd.Output()
-
-	root, err = evm.ParseStateRoot(data)
-	if err != nil {
-		log.Error("Failed to find stateroot", "vm", evm.Name(), "cmd", cmd.String())
-		return "", cmd.String(), err
-	}
+	cmd := evm.execCommand(evm.path, path)
+	data, err := outputTimed(cmd, false, ExecTimeout)
+	root, err = checkRoot(cmd, data, err, evm.ParseStateRoot)
 	return root, cmd.String(), err
 }
 
@@ -90,9 +102,9 @@ func (evm *NimbusEVM) RunStateTest(path string, out io.Writer, speedTest bool) (
 		cmd    *exec.Cmd
 	)
 	if speedTest {
-		cmd = exec.Command(evm.path, "--noreturndata", "--nomemory", "--nostorage", path)
+		cmd = evm.execCommand(evm.path, "--noreturndata", "--nomemory", "--nostorage", path)
 	} else {
-		cmd = exec.Command(evm.path, "--json", "--noreturndata", "--nomemory", "--nostorage", path)
+		cmd = evm.execCommand(evm.path, "--json", "--noreturndata", "--nomemory", "--nostorage", path)
 	}
 	if stderr, err = cmd.StderrPipe(); err != nil {
 		return &tracingResult{Cmd: cmd.String()}, err
@@ -100,11 +112,14 @@ func (evm *NimbusEVM) RunStateTest(path string, out io.Writer, speedTest bool) (
 	if err = cmd.Start(); err != nil {
 		return &tracingResult{Cmd: cmd.String()}, err
 	}
+	var root stateRoot
 	// copy everything to the given writer
-	evm.Copy(out, stderr)
-	// Nimbus returns a non-zero exit code for tests that do not pass. We just ignore that.
-	_, _ = io.ReadAll(stderr)
-	_ = cmd.Wait()
+	err = waitTimed(cmd, stderr, ExecTimeout, func() {
+		root = evm.copyUntilEnd(out, evm.tee(stderr), false, subtestCount(path))
+		// Nimbus returns a non-zero exit code for tests that do not pass. We just ignore that.
+		_, _ = io.ReadAll(stderr)
+	})
+	err = checkExit(cmd, root, err)
 	// release resources
 	duration, slow := evm.stats.TraceDone(t0)
 
@@ -112,18 +127,18 @@ func (evm *NimbusEVM) RunStateTest(path string, out io.Writer, speedTest bool) (
 		Slow:     slow,
 		ExecTime: duration,
 		Cmd:      cmd.String(),
-	}, nil
+	}, err
 }
 
 func (evm *NimbusEVM) Close() {
 }
 
 func (evm *NimbusEVM) Copy(out io.Writer, input io.Reader) {
-	evm.copyUntilEnd(out, input, false)
+	evm.copyUntilEnd(out, input, false, 1)
 }
 
 // copyUntilEnd copies the input to output, and returns the stateroot on completion
-func (evm *NimbusEVM) copyUntilEnd(out io.Writer, input io.Reader, speedMode bool) stateRoot {
+func (evm *NimbusEVM) copyUntilEnd(out io.Writer, input io.Reader, speedMode bool, subtests int) stateRoot {
 	if speedMode {
 		// In speednode, there's no jsonl output, it instead looks like
 		var r []stateRoot
@@ -131,15 +146,15 @@ func (evm *NimbusEVM) copyUntilEnd(out io.Writer, input io.Reader, speedMode boo
 			log.Warn("Error parsing nimbus output", "error", err)
 			return stateRoot{}
 		}
-		rootJSON, _ := json.Marshal(r[0])
-		if _, err := out.Write(append(rootJSON, '\n')); err != nil {
-			fmt.Fprintf(os.Stderr, "Error writing to out: %v\n", err)
+		roots := &stateRoots{want: subtests}
+		for _, root := range r {
+			roots.add(out, root.StateRoot)
 		}
-		return r[0]
+		return roots.finish(out)
 	}
 	scanner := NewJsonlScanner("nimb", input, os.Stderr)
 	defer scanner.Release()
-	var root stateRoot
+	roots := &stateRoots{want: subtests}
 
 	// When nimbus encounters an error, it may already have spat out the info prematurely.
 	// We need to merge it back to one item, just like geth
@@ -170,10 +185,14 @@ func (evm *NimbusEVM) copyUntilEnd(out io.Writer, input io.Reader, speedMode boo
 		if err := scanner.Next(&elem); err != nil {
 			break
 		}
-		// If we have a stateroot, we're done
+		// If we have a stateroot, the subtest is done
 		if len(elem.StateRoot1) != 0 {
-			root.StateRoot = elem.StateRoot1
-			break
+			yield(nil)
+			prev = nil
+			if roots.add(out, elem.StateRoot1) {
+				break
+			}
+			continue
 		}
 		// If the output cannot be marshalled, all fields will be blanks.
 		// We can detect that through 'depth', which should never be less than 1
@@ -189,11 +208,7 @@ func (evm *NimbusEVM) copyUntilEnd(out io.Writer, input io.Reader, speedMode boo
 		yield(&elem)
 	}
 	yield(nil)
-	rootJSON, _ := json.Marshal(root)
-	if _, err := out.Write(append(rootJSON, '\n')); err != nil {
-		fmt.Fprintf(os.Stderr, "Error writing to out: %v\n", err)
-	}
-	return root
+	return roots.finish(out)
 }
 
 func (evm *NimbusEVM) Stats() []any {
@@ -22,8 +22,6 @@ import (
 	"os/exec"
 	"sync"
 	"time"
-
-	"github.com/ethereum/go-ethereum/log"
 )
 
 // The NimbusBatchVM spins up one 'master' instance of the VM, and uses that to execute tests
@@ -48,9 +46,10 @@ func NewNimbusBatchVM(path, name string) Evm {
 func (evm *NimbusBatchVM) Instance(threadID int) Evm {
 	return &NimbusBatchVM{
 		NimbusEVM: NimbusEVM{
-			path:  evm.path,
-			name:  fmt.Sprintf("%v-%d", evm.name, threadID),
-			stats: evm.stats,
+			path:    evm.path,
+			name:    fmt.Sprintf("%v-%d", evm.name, threadID),
+			stats:   evm.stats,
+			cmdOpts: evm.cmdOpts,
 		},
 	}
 }
@@ -62,7 +61,7 @@ func (evm *NimbusBatchVM) RunStateTest(path string, out io.Writer, speedTest boo
 		err     error
 		procOut io.ReadCloser
 		stdin   io.WriteCloser
-		cmd     = exec.Command(evm.path, "--json", "--noreturndata", "--nomemory", "--nostorage")
+		cmd     = evm.execCommand(evm.path, "--json", "--noreturndata", "--nomemory", "--nostorage")
 	)
 	evm.mu.Lock()
 	defer evm.mu.Unlock()
@@ -75,7 +74,7 @@ func (evm *NimbusBatchVM) RunStateTest(path string, out io.Writer, speedTest boo
 		} else {
 			// In speedtest-mode, we don't want the actual traces, but we do
 			// need to read the stateroot. The stateroot can be found on stdout
-			cmd = exec.Command(evm.path)
+			cmd = evm.execCommand(evm.path)
 			if procOut, err = cmd.StdoutPipe(); err != nil {
 				return &tracingResult{Cmd: cmd.String()}, err
 			}
@@ -90,12 +89,14 @@ func (evm *NimbusBatchVM) RunStateTest(path string, out io.Writer, speedTest boo
 		evm.procOut = procOut
 		evm.stdin = stdin
 	}
-	_, err = fmt.Fprintf(evm.stdin, "%v\n", path)
+	// copy everything for the _current_ statetest to the given writer
+	_, err = execBatched(evm.cmd, evm.stdin, evm.procOut, path, ExecTimeout, func() stateRoot {
+		return evm.copyUntilEnd(out, evm.tee(evm.procOut), speedTest, subtestCount(path))
+	})
 	if err != nil {
-		log.Error("Error writing to nimbus", "path", path, "err", err)
+		cmd, evm.cmd = evm.cmd, nil // restart on next test
+		return &tracingResult{Cmd: cmd.String()}, err
 	}
-	// copy everything for the _current_ statetest to the given writer
-	evm.copyUntilEnd(out, evm.procOut, speedTest)
 	duration, slow := evm.stats.TraceDone(t0)
 	return &tracingResult{
 		Slow:     slow,
@@ -114,8 +115,10 @@ func (evm *NimbusBatchVM) Close() {
 }
 
 func (evm *NimbusBatchVM) GetStateRoot(path string) (root, command string, err error) {
+	evm.mu.Lock()
+	defer evm.mu.Unlock()
 	if evm.cmd == nil {
-		evm.cmd = exec.Command(evm.path)
+		evm.cmd = evm.execCommand(evm.path)
 		if evm.procOut, err = evm.cmd.StdoutPipe(); err != nil {
 			return "", evm.cmd.String(), err
 		}
@@ -126,9 +129,12 @@ func (evm *NimbusBatchVM) GetStateRoot(path string) (root, command string, err e
 			return "", evm.cmd.String(), err
 		}
 	}
-	evm.mu.Lock()
-	defer evm.mu.Unlock()
-	_, _ = fmt.Fprintf(evm.stdin, "%v\n", path)
-	sRoot := evm.copyUntilEnd(io.Discard, evm.procOut, true)
-	return sRoot.StateRoot, evm.cmd.String(), nil
+	command = evm.cmd.String()
+	sRoot, err := execBatched(evm.cmd, evm.stdin, evm.procOut, path, ExecTimeout, func() stateRoot {
+		return evm.copyUntilEnd(io.Discard, evm.procOut, true, subtestCount(path))
+	})
+	if err != nil {
+		evm.cmd = nil // restart on next test
+	}
+	return sRoot.StateRoot, command, err
 }
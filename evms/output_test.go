@@ -19,10 +19,13 @@ package evms
 import (
 	"bytes"
 	"embed"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -42,9 +45,17 @@ func TestVMsOutput(t *testing.T) {
 		}
 		testVmsOutput(t, filepath.Join("testdata", "traces", finfo.Name()))
 	}
+	// A statetest with several subtests yields one trace and stateroot per
+	// subtest, which is simulated by concatenating the recordings.
+	testVmsOutput(t, filepath.Join("testdata", "traces", "statetest1.json"),
+		filepath.Join("testdata", "traces", "negative_refund.json"),
+		filepath.Join("testdata", "traces", "00000936-mixed-1.json"))
 }
 
-func testVmsOutput(t *testing.T, testfile string) {
+// testVmsOutput feeds the recorded outputs of the testfiles to each vm, one
+// after the other, as if they were the subtests of one statetest.
+func testVmsOutput(t *testing.T, testfiles ...string) {
+	testfile := testfiles[0]
 	type testCase struct {
 		vm     Evm
 		stdout string
@@ -59,8 +70,14 @@ func testVmsOutput(t *testing.T, testfile string) {
 		{NewNimbusEVM("", "nimbus"), "", fmt.Sprintf("%v.nimbus.stderr.txt", testfile)},
 		{NewNimbusBatchVM("", "nimbusba"), "", fmt.Sprintf("%v.nimbus.stderr.txt", testfile)},
 		{NewEvmoneVM("", "evmone"), "", fmt.Sprintf("%v.evmone.stderr.txt", testfile)},
+		{NewEvmoneBatchVM("", "evmoneba"), "", fmt.Sprintf("%v.evmone.stderr.txt", testfile)},
 		{NewRethVM("", "rethvm"), "", fmt.Sprintf("%v.revm.stderr.txt", testfile)},
+		{NewRethBatchVM("", "rethba"), "", fmt.Sprintf("%v.revm.stderr.txt", testfile)},
 		{NewEelsEVM("", "eelsvm"), "", fmt.Sprintf("%v.eels.stderr.txt", testfile)},
+		// Custom vms, configured to mimic the regular ones
+		{newTestCustomVM(t, "customgeth", OutputProfile{DropStops: true, MergeErrors: true}), "", fmt.Sprintf("%v.geth.stderr.txt", testfile)},
+		{newTestCustomVM(t, "custombesu", OutputProfile{Stream: "stdout", DropStops: true}), fmt.Sprintf("%v.besu.stdout.txt", testfile), ""},
+		{newTestCustomVM(t, "customevmone", OutputProfile{DropStops: true}), "", fmt.Sprintf("%v.evmone.stderr.txt", testfile)},
 	}
 	var readers []io.Reader
 	var vms []Evm
@@ -71,20 +88,123 @@ func testVmsOutput(t *testing.T, testfile string) {
 			if len(f) == 0 {
 				continue
 			}
-			rawOutput, err := os.Open(f)
-			if err != nil {
-				t.Fatal(err)
+			for _, other := range testfiles {
+				data, err := os.ReadFile(other + strings.TrimPrefix(f, testfile))
+				if err != nil {
+					t.Fatal(err)
+				}
+				// And feed via the vm-specific copy-shim
+				tc.vm.Copy(parsedOutput, bytes.NewReader(data))
 			}
-			// And feed via the vm-specific copy-shim
-			tc.vm.Copy(parsedOutput, rawOutput)
-			rawOutput.Close()
+		}
+		if have := bytes.Count(parsedOutput.Bytes(), []byte(`{"stateRoot":"0x`)); have != len(testfiles) {
+			t.Errorf("%v: wrong number of stateroots: have %d, want %d", tc.vm.Name(), have, len(testfiles))
 		}
 		readers = append(readers, bytes.NewReader(parsedOutput.Bytes()))
 		vms = append(vms, tc.vm)
 	}
 	if eq, _, data := CompareFiles(vms, readers); !eq {
 		t.Log(data)
-		t.Errorf("Expected equality, didn't get it, file: %v", testfile)
+		t.Errorf("Expected equality, didn't get it, files: %v", testfiles)
+	}
+}
+
+func newTestCustomVM(t *testing.T, name string, profile OutputProfile) Evm {
+	t.Helper()
+	vm, err := NewCustomVM(name, []string{"evm", PathPlaceholder}, nil, profile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return vm
+}
+
+// TestBatchStream simulates the master process of a batch-mode vm, by feeding
+// the recorded outputs one test at a time over a pipe. The output parsed from
+// the stream should be identical to the output parsed from the single files.
+// If a recorded batch-mode output exists in testdata/batch, that is used as
+// the stream, otherwise the single recordings are concatenated.
+func TestBatchStream(t *testing.T) {
+	type batchCopier interface {
+		Evm
+		copyUntilEnd(out io.Writer, input io.Reader, subtests int) stateRoot
+	}
+	for _, tc := range []struct {
+		vm     batchCopier
+		suffix string
+	}{
+		{NewGethBatchVM("", "gethba").(*GethBatchVM), "geth.stderr.txt"},
+		{NewEvmoneBatchVM("", "evmoneba").(*EvmoneBatchVM), "evmone.stderr.txt"},
+		{NewRethBatchVM("", "rethba").(*RethBatchVM), "revm.stderr.txt"},
+	} {
+		finfos, err := os.ReadDir(filepath.Join("testdata", "cases"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var recordings [][]byte
+		for _, finfo := range finfos {
+			if finfo.Name() == "eofcode.json" {
+				// Evmone refuse to run it.
+				// https://github.com/holiman/goevmlab/issues/127
+				continue
+			}
+			data, err := os.ReadFile(filepath.Join("testdata", "traces", fmt.Sprintf("%v.%v", finfo.Name(), tc.suffix)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			recordings = append(recordings, data)
+		}
+		stream := recordings
+		if data, err := os.ReadFile(filepath.Join("testdata", "batch", tc.suffix)); err == nil {
+			// Split the recording into the outputs of the individual tests,
+			// as the master process would have delivered them.
+			stream = nil
+			for len(data) > 0 {
+				end := bytes.Index(data, []byte(`"stateRoot"`))
+				if end == -1 {
+					end = len(data)
+				} else if nl := bytes.IndexByte(data[end:], '\n'); nl != -1 {
+					end += nl + 1
+				} else {
+					end = len(data)
+				}
+				stream = append(stream, data[:end])
+				data = data[end:]
+			}
+		}
+		pr, pw := io.Pipe()
+		go func() {
+			for _, data := range stream {
+				_, _ = pw.Write(data)
+			}
+			pw.Close()
+		}()
+		for i, data := range recordings {
+			want := new(bytes.Buffer)
+			tc.vm.Copy(want, bytes.NewReader(data))
+			have := new(bytes.Buffer)
+			tc.vm.copyUntilEnd(have, pr, 1)
+			if !bytes.Equal(have.Bytes(), want.Bytes()) {
+				t.Fatalf("%v: test %d: batch output differs\nhave:\n%s\nwant:\n%s", tc.vm.Name(), i, have, want)
+			}
+		}
+		// A test with several subtests yields several stateroots, all of
+		// which are read before the next test.
+		multi := bytes.Join(recordings[:2], nil)
+		mr, mw := io.Pipe()
+		go func() {
+			_, _ = mw.Write(multi)
+			_, _ = mw.Write(recordings[2])
+			mw.Close()
+		}()
+		for _, data := range [][]byte{multi, recordings[2]} {
+			want := new(bytes.Buffer)
+			tc.vm.copyUntilEnd(want, bytes.NewReader(data), 0)
+			have := new(bytes.Buffer)
+			tc.vm.copyUntilEnd(have, mr, bytes.Count(want.Bytes(), []byte(`"stateRoot"`)))
+			if !bytes.Equal(have.Bytes(), want.Bytes()) {
+				t.Fatalf("%v: batch output of subtests differs\nhave:\n%s\nwant:\n%s", tc.vm.Name(), have, want)
+			}
+		}
 	}
 }
 
@@ -169,7 +289,8 @@ var testcases embed.FS
 
 // createEvmsFromEnv instantiates vms bsaed on ENV info.
 func createEvmsFromEnv() []Evm {
-	var vms []Evm
+	// The embedded geth is always available
+	var vms = []Evm{NewEmbeddedGethVM("embedded")}
 	if k := "GETH_BIN"; os.Getenv(k) != "" {
 		vms = append(vms, NewGethEVM(os.Getenv(k), "geth"))
 		vms = append(vms, NewGethBatchVM(os.Getenv(k), "gethbatch"))
@@ -184,6 +305,7 @@ func createEvmsFromEnv() []Evm {
 	}
 	if k := "RETH_BIN"; os.Getenv(k) != "" {
 		vms = append(vms, NewRethVM(os.Getenv(k), "reth"))
+		vms = append(vms, NewRethBatchVM(os.Getenv(k), "rethbatch"))
 	}
 	if k := "ERIG_BIN"; os.Getenv(k) != "" {
 		vms = append(vms, NewErigonVM(os.Getenv(k), "erigon"))
@@ -195,6 +317,7 @@ func createEvmsFromEnv() []Evm {
 	}
 	if k := "EVMO_BIN"; os.Getenv(k) != "" {
 		vms = append(vms, NewEvmoneVM(os.Getenv(k), "evmone"))
+		vms = append(vms, NewEvmoneBatchVM(os.Getenv(k), "evmonebatch"))
 	}
 	if k := "EELS_BIN"; os.Getenv(k) != "" {
 		vms = append(vms, NewEelsEVM(os.Getenv(k), "eels"))
@@ -327,3 +450,177 @@ func TestVMsFromEnv_stateroot(t *testing.T) {
 		}
 	}
 }
+
+// TestSubtestRoots checks that the recorded output of a statetest with several
+// subtests yields one stateroot per subtest, and that nothing beyond the last
+// stateroot is consumed, as the batch-mode vms require. Only the clients with
+// a recording in testdata/subtests are checked.
+func TestSubtestRoots(t *testing.T) {
+	type copyFn func(out io.Writer, input io.Reader, subtests int) stateRoot
+	var (
+		testfile = filepath.Join("testdata", "subtests", "variants.json")
+		nether   = NewNethermindVM("", "nether").(*NethermindVM)
+		erigon   = NewErigonVM("", "erigon").(*ErigonVM)
+		nimbus   = NewNimbusEVM("", "nimbus").(*NimbusEVM)
+	)
+	RegisterTest(testfile)
+	defer ForgetTest(testfile)
+	n := subtestCount(testfile)
+	if n != 3 {
+		t.Fatalf("wrong number of subtests: %d", n)
+	}
+	for _, tc := range []struct {
+		suffix string
+		copy   copyFn
+	}{
+		{"geth.stderr.txt", NewGethEVM("", "geth").(*GethEVM).copyUntilEnd},
+		{"besu.stdout.txt", NewBesuVM("", "besu").(*BesuVM).copyUntilEnd},
+		{"evmone.stderr.txt", NewEvmoneVM("", "evmone").(*EvmoneVM).copyUntilEnd},
+		{"revm.stderr.txt", NewRethVM("", "revm").(*RethVM).copyUntilEnd},
+		{"eels.stderr.txt", NewEelsEVM("", "eels").(*EelsEVM).copyUntilEnd},
+		{"nethermind.stderr.txt", func(out io.Writer, input io.Reader, subtests int) stateRoot {
+			return nether.copyUntilEnd(out, input, false, subtests)
+		}},
+		{"erigon.stderr.txt", func(out io.Writer, input io.Reader, subtests int) stateRoot {
+			return erigon.copyUntilEnd(out, input, false, subtests)
+		}},
+		{"nimbus.stderr.txt", func(out io.Writer, input io.Reader, subtests int) stateRoot {
+			return nimbus.copyUntilEnd(out, input, false, subtests)
+		}},
+	} {
+		data, err := os.ReadFile(fmt.Sprintf("%v.%v", testfile, tc.suffix))
+		if err != nil {
+			t.Logf("%v: no recording", tc.suffix)
+			continue
+		}
+		// The output of the next test follows in the stream
+		stream := io.MultiReader(bytes.NewReader(data), bytes.NewReader(data))
+		var outputs []string
+		for range 2 {
+			out := new(bytes.Buffer)
+			if root := tc.copy(out, stream, n); root.StateRoot == "" {
+				t.Fatalf("%v: subtests missing:\n%s", tc.suffix, out)
+			}
+			if have := bytes.Count(out.Bytes(), []byte(`{"stateRoot":"0x`)); have != n {
+				t.Fatalf("%v: wrong number of stateroots: have %d, want %d", tc.suffix, have, n)
+			}
+			outputs = append(outputs, out.String())
+		}
+		if outputs[0] != outputs[1] {
+			t.Errorf("%v: the first test consumed the output of the next one", tc.suffix)
+		}
+	}
+}
+
+// fakeGeth is a geth 'vm' which, like geth, does not execute the forks of a
+// test in the order of the file, unless told which fork to execute. It emits
+// a stateroot per fork: 0x01 for Cancun, 0x02 for Osaka, 0x03 for Prague.
+const fakeGeth = `#!/bin/sh
+run() {
+	for f in Prague Osaka Cancun; do
+		if [ -n "$2" ] && [ "$2" != "$f" ]; then continue; fi
+		case $f in Cancun) r=0x01 ;; Osaka) r=0x02 ;; Prague) r=0x03 ;; esac
+		if grep -q "\"$f\"" "$1"; then echo "{\"stateRoot\": \"$r\"}" >&2; fi
+	done
+}
+fork=""
+prev=""
+for a in "$@"; do
+	if [ "$prev" = "--statetest.fork" ]; then fork=$a; fi
+	prev=$a
+	last=$a
+done
+case "$last" in
+*.json) run "$last" "$fork" ;;
+*) while read -r p; do run "$p" "$fork"; done ;;
+esac
+`
+
+// TestGethForkOrder checks that the geth vms execute the forks of a test for
+// several forks in the order of the other clients: sorted by name.
+func TestGethForkOrder(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	var (
+		dir      = t.TempDir()
+		bin      = filepath.Join(t.TempDir(), "geth")
+		testfile = filepath.Join(dir, "multifork.json")
+		want     = "{\"stateRoot\":\"0x01\"}\n{\"stateRoot\":\"0x02\"}\n{\"stateRoot\":\"0x03\"}\n"
+	)
+	if err := os.WriteFile(bin, []byte(fakeGeth), 0755); err != nil {
+		t.Fatal(err)
+	}
+	test := `{"multifork":{"post":{"Cancun":[{}],"Osaka":[{}],"Prague":[{}]}}}`
+	if err := os.WriteFile(testfile, []byte(test), 0644); err != nil {
+		t.Fatal(err)
+	}
+	RegisterTest(testfile)
+	defer ForgetTest(testfile)
+	for _, vm := range []Evm{NewGethEVM(bin, "geth"), NewGethBatchVM(bin, "gethbatch")} {
+		for range 2 {
+			out := new(bytes.Buffer)
+			if _, err := vm.RunStateTest(testfile, out, false); err != nil {
+				t.Fatalf("%v: %v", vm.Name(), err)
+			}
+			if out.String() != want {
+				t.Errorf("%v: wrong output:\n%v", vm.Name(), out)
+			}
+		}
+		vm.Close()
+	}
+	if root, _, err := NewGethBatchVM(bin, "gethbatch").GetStateRoot(testfile); err != nil || root != "0x01" {
+		t.Errorf("wrong stateroot: %v %v", root, err)
+	}
+	// The copies of the test for each fork are removed
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("copies of the test left behind: %v", entries)
+	}
+}
+
+// TestGethForkOrderBinary checks that a geth binary, given as GETH_BIN,
+// executes a test for several forks like the embedded geth, which executes
+// the forks sorted by name.
+func TestGethForkOrderBinary(t *testing.T) {
+	bin := os.Getenv("GETH_BIN")
+	if bin == "" {
+		t.Skip("GETH_BIN not set")
+	}
+	data, err := os.ReadFile(filepath.Join("testdata", "subtests", "variants.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tests map[string]map[string]any
+	if err := json.Unmarshal(data, &tests); err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range tests {
+		post := test["post"].(map[string]any)
+		// Berlin traces differently from the later forks
+		post["Berlin"], post["Cancun"] = post["Prague"], post["Prague"]
+	}
+	testfile := filepath.Join(t.TempDir(), "multifork.json")
+	data, _ = json.Marshal(tests)
+	if err := os.WriteFile(testfile, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	RegisterTest(testfile)
+	defer ForgetTest(testfile)
+	want := new(bytes.Buffer)
+	if _, err := NewEmbeddedGethVM("embedded").RunStateTest(testfile, want, false); err != nil {
+		t.Fatal(err)
+	}
+	for _, vm := range []Evm{NewGethEVM(bin, "geth"), NewGethBatchVM(bin, "gethbatch")} {
+		// The fork order of geth is random, try a few times
+		for range 5 {
+			out := new(bytes.Buffer)
+			if _, err := vm.RunStateTest(testfile, out, false); err != nil {
+				t.Fatalf("%v: %v", vm.Name(), err)
+			}
+			if div := DiffFiles([]Evm{vm, vm}, []io.Reader{bytes.NewReader(want.Bytes()), out}, 0); div != nil {
+				t.Fatalf("%v: %v", vm.Name(), div.Report())
+			}
+		}
+		vm.Close()
+	}
+}
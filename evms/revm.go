@@ -18,14 +18,11 @@ package evms
 
 import (
 	"bytes"
-	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
 	"time"
-
-	"github.com/ethereum/go-ethereum/log"
 )
 
 type RethVM struct {
@@ -33,6 +30,8 @@ type RethVM struct {
 	name string
 
 	stats *VMStat
+
+	cmdOpts // extra arguments and environment for the binary
 }
 
 func NewRethVM(path string, name string) Evm {
@@ -51,24 +50,26 @@ func (evm *RethVM) Name() string {
 	return evm.name
 }
 
-func (evm *RethVM) GetStateRoot(path string) (root, command string, err error) {
-	cmd := exec.Command(evm.path, "statetest", "--json-outcome", path)
-	data, err := StdErrOutput(cmd)
+// Kind implements ClientInfo.
+func (evm *RethVM) Kind() string {
+	return "revme"
+}
 
-	// If revm exits with 1 on stateroot errors, uncomment to ignore:
-	if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
-		err = nil
-	}
-	if err != nil {
-		return "", cmd.String(), err
-	}
+// Binary implements ClientInfo.
+func (evm *RethVM) Binary() string {
+	return evm.path
+}
 
-	root, err = evm.ParseStateRoot(data)
-	if err != nil {
-		log.Error("Failed to find stateroot", "vm", evm.Name(), "cmd", cmd.String())
-		return "", cmd.String(), err
-	}
-	return root, cmd.String(), nil
+// Version implements ClientInfo.
+func (evm *RethVM) Version() (string, error) {
+	return binaryVersion(evm.execCommand(evm.path, "--version"))
+}
+
+func (evm *RethVM) GetStateRoot(path string) (root, command string, err error) {
+	cmd := evm.execCommand(evm.path, "statetest", "--json-outcome", path)
+	data, err := StdErrOutput(cmd)
+	root, err = checkRoot(cmd, data, err, evm.ParseStateRoot)
+	return root, cmd.String(), err
 }
 
 func (evm *RethVM) ParseStateRoot(data []byte) (root string, err error) {
@@ -90,9 +91,9 @@ func (evm *RethVM) RunStateTest(path string, out io.Writer, speedTest bool) (*tr
 		err    error
 		cmd    *exec.Cmd
 	)
-	cmd = exec.Command(evm.path, "statetest", "--json", path)
+	cmd = evm.execCommand(evm.path, "statetest", "--json", path)
 	if speedTest {
-		cmd = exec.Command(evm.path, "statetest", "--json-outcome", path)
+		cmd = evm.execCommand(evm.path, "statetest", "--json-outcome", path)
 	}
 
 	if stderr, err = cmd.StderrPipe(); err != nil {
@@ -102,18 +103,15 @@ func (evm *RethVM) RunStateTest(path string, out io.Writer, speedTest bool) (*tr
 		return nil, err
 	}
 
-	evm.Copy(out, stderr)
-	// drain stderr
-	_, _ = io.ReadAll(stderr)
-	err = cmd.Wait()
+	var root stateRoot
+	err = waitTimed(cmd, stderr, ExecTimeout, func() {
+		root = evm.copyUntilEnd(out, evm.tee(stderr), subtestCount(path))
+		// drain stderr
+		_, _ = io.ReadAll(stderr)
+	})
+	err = checkExit(cmd, root, err)
 	duration, slow := evm.stats.TraceDone(t0)
 
-	// revm exits with 1 on test-errors (expected stateroot != observed stateroot)
-	// so need to ignore it.
-	if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
-		err = nil
-	}
-
 	return &tracingResult{
 		Slow:     slow,
 		ExecTime: duration,
@@ -125,15 +123,25 @@ func (evm *RethVM) Close() {
 }
 
 func (evm *RethVM) Copy(out io.Writer, input io.Reader) {
+	evm.copyUntilEnd(out, input, 1)
+}
+
+// copyUntilEnd reads from the reader, does some revm-specific filtering and
+// writes the canonical output to the writer, until the stateroot is found.
+func (evm *RethVM) copyUntilEnd(out io.Writer, input io.Reader, subtests int) stateRoot {
 	scanner := NewJsonlScanner("revm", input, os.Stderr)
 	defer scanner.Release()
-	var stateRoot stateRoot
+	roots := &stateRoots{want: subtests}
 
 	var elem opLog
 	for scanner.Next(&elem) == nil {
 		if len(elem.StateRoot1) != 0 {
-			stateRoot.StateRoot = elem.StateRoot1
-			break
+			root := elem.StateRoot1
+			elem = opLog{}
+			if roots.add(out, root) {
+				break
+			}
+			continue
 		}
 		// Drop all STOP opcodes as geth does
 		if elem.Op == 0x0 {
@@ -144,11 +152,7 @@ func (evm *RethVM) Copy(out io.Writer, input io.Reader) {
 			fmt.Fprintf(os.Stderr, "Error writing to out: %v\n", err)
 		}
 	}
-	root, _ := json.Marshal(stateRoot)
-	if _, err := out.Write(append(root, '\n')); err != nil {
-		fmt.Fprintf(os.Stderr, "Error writing to output: %v\n", err)
-		return
-	}
+	return roots.finish(out)
 }
 
 func (evm *RethVM) Stats() []any {
@@ -0,0 +1,68 @@
+// Copyright 2026 Martin Holst Swende
+// This file is part of the goevmlab library.
+//
+// The library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the goevmlab library. If not, see <http://www.gnu.org/licenses/>.
+
+package evms
+
+import (
+	"fmt"
+	"io"
+)
+
+// The RethBatchVM executes tests in long-lived revme processes, see batchMaster.
+// The paths of the tests are fed to one 'master' process via standard input,
+// which requires a revme build which reads paths from stdin when no paths are
+// given on the command line. Other builds are executed by a long-lived
+// helper process.
+type RethBatchVM struct {
+	RethVM
+	batch *batchMaster
+}
+
+func NewRethBatchVM(path, name string) Evm {
+	return newRethBatchVM(RethVM{
+		path:  path,
+		name:  name,
+		stats: &VMStat{},
+	})
+}
+
+func newRethBatchVM(vm RethVM) *RethBatchVM {
+	evm := &RethBatchVM{RethVM: vm}
+	evm.batch = newBatchMaster(&evm.RethVM, vm.stats, []string{"statetest", "--json"}, []string{"statetest", "--json-outcome"})
+	return evm
+}
+
+func (evm *RethBatchVM) Instance(threadID int) Evm {
+	return newRethBatchVM(RethVM{
+		path:    evm.path,
+		name:    fmt.Sprintf("%v-%d", evm.name, threadID),
+		stats:   evm.stats,
+		cmdOpts: evm.cmdOpts,
+	})
+}
+
+// RunStateTest implements the Evm interface
+func (evm *RethBatchVM) RunStateTest(path string, out io.Writer, speedTest bool) (*tracingResult, error) {
+	return evm.batch.runStateTest(path, out, speedTest)
+}
+
+func (evm *RethBatchVM) GetStateRoot(path string) (root, command string, err error) {
+	return evm.batch.getStateRoot(path)
+}
+
+func (evm *RethBatchVM) Close() {
+	evm.batch.close()
+}
@@ -0,0 +1,464 @@
+// Copyright 2026 Martin Holst Swende
+// This file is part of the goevmlab library.
+//
+// The library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the goevmlab library. If not, see <http://www.gnu.org/licenses/>.
+
+package evms
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// t8nRunner describes how a client executes a state transition.
+type t8nRunner struct {
+	kind string
+	args []string // the arguments preceding the standard t8n flags
+	// copyTrace copies the trace of a transaction. The clients trace the same
+	// way as in their statetest tools, so the filtering of the statetest vm
+	// applies.
+	copyTrace func(out io.Writer, input io.Reader)
+}
+
+var (
+	// geth: evm t8n
+	gethT8nRunner = t8nRunner{"getht8n", []string{"t8n"}, new(GethEVM).Copy}
+	// besu: evmtool t8n
+	besuT8nRunner = t8nRunner{"besut8n", []string{"t8n"}, new(BesuVM).Copy}
+	// nethermind: nethtest t8n
+	nethermindT8nRunner = t8nRunner{"nethermindt8n", []string{"t8n"}, new(NethermindVM).Copy}
+	// evmone: evmone-t8n
+	evmoneT8nRunner = t8nRunner{"evmonet8n", nil, new(EvmoneVM).Copy}
+	// eels: ethereum-spec-evm t8n
+	eelsT8nRunner = t8nRunner{"eelst8n", []string{"t8n"}, new(EelsEVM).Copy}
+)
+
+// T8nVM is an Evm-interface wrapper around the state transition tool (t8n) of
+// a client. It executes t8n tests (see fuzzing.T8nTest): the transactions
+// are applied to the pre-state in one block. The input and output of the t8n
+// tools are standardized, so all clients share the same code path, only the
+// command differs.
+//
+// The canonical output is the trace of the transactions, if requested, the
+// post-state, one account per line, the result of the block, and finally the
+// stateroot. Missing output yields an empty stateroot.
+type T8nVM struct {
+	path   string
+	name   string
+	runner t8nRunner
+	// Some metrics
+	stats *VMStat
+
+	cmdOpts // extra arguments and environment for the binary
+}
+
+// NewGethT8nVM returns a vm executing t8n tests with the geth `evm` binary.
+func NewGethT8nVM(path, name string) Evm {
+	return &T8nVM{path: path, name: name, runner: gethT8nRunner, stats: new(VMStat)}
+}
+
+// NewBesuT8nVM returns a vm executing t8n tests with the besu `evmtool` binary.
+func NewBesuT8nVM(path, name string) Evm {
+	return &T8nVM{path: path, name: name, runner: besuT8nRunner, stats: new(VMStat)}
+}
+
+// NewNethermindT8nVM returns a vm executing t8n tests with the nethermind `nethtest` binary.
+func NewNethermindT8nVM(path, name string) Evm {
+	return &T8nVM{path: path, name: name, runner: nethermindT8nRunner, stats: new(VMStat)}
+}
+
+// NewEvmoneT8nVM returns a vm executing t8n tests with the `evmone-t8n` binary.
+func NewEvmoneT8nVM(path, name string) Evm {
+	return &T8nVM{path: path, name: name, runner: evmoneT8nRunner, stats: new(VMStat)}
+}
+
+// NewEelsT8nVM returns a vm executing t8n tests with the eels `ethereum-spec-evm` binary.
+func NewEelsT8nVM(path, name string) Evm {
+	return &T8nVM{path: path, name: name, runner: eelsT8nRunner, stats: new(VMStat)}
+}
+
+func (evm *T8nVM) Instance(int) Evm {
+	return evm
+}
+
+func (evm *T8nVM) Name() string {
+	return evm.name
+}
+
+// Kind implements ClientInfo.
+func (evm *T8nVM) Kind() string {
+	return evm.runner.kind
+}
+
+// Binary implements ClientInfo.
+func (evm *T8nVM) Binary() string {
+	return evm.path
+}
+
+// Version implements ClientInfo.
+func (evm *T8nVM) Version() (string, error) {
+	return binaryVersion(evm.execCommand(evm.path, "--version"))
+}
+
+// t8nInput is the input of a t8n test, see fuzzing.T8nTest.
+type t8nInput struct {
+	Fork  string          `json:"fork"`
+	Alloc json.RawMessage `json:"alloc"`
+	Env   json.RawMessage `json:"env"`
+	Txs   json.RawMessage `json:"txs"`
+}
+
+// t8nOutput is the output of the t8n tool: the contents of the result and
+// alloc files.
+type t8nOutput struct {
+	Alloc  json.RawMessage `json:"alloc"`
+	Result json.RawMessage `json:"result"`
+}
+
+// prepare writes the input files of the t8n test at the path into the
+// directory, and returns the command to execute it.
+func (evm *T8nVM) prepare(path, dir string, trace bool) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var input t8nInput
+	if err := json.Unmarshal(data, &input); err != nil {
+		return nil, fmt.Errorf("invalid t8n test %v: %w", path, err)
+	}
+	args := slices.Clone(evm.runner.args)
+	for _, f := range []struct {
+		name string
+		data []byte
+	}{{"alloc", input.Alloc}, {"env", input.Env}, {"txs", input.Txs}} {
+		file := filepath.Join(dir, f.name+".json")
+		if err := os.WriteFile(file, f.data, 0644); err != nil {
+			return nil, err
+		}
+		args = append(args, "--input."+f.name, file)
+	}
+	if trace {
+		args = append(args, "--trace")
+	}
+	return append(args,
+		"--output.basedir", filepath.Join(dir, "out"),
+		"--output.result", "result.json",
+		"--output.alloc", "alloc.json",
+		"--state.fork", input.Fork,
+		"--state.chainid", "1"), nil
+}
+
+// execute runs the t8n test at the path, and returns the raw output (see
+// t8nRawOutput).
+func (evm *T8nVM) execute(path string, trace bool) ([]byte, string, error) {
+	dir, err := os.MkdirTemp("", "t8n-")
+	if err != nil {
+		return nil, "", err
+	}
+	defer os.RemoveAll(dir)
+	args, err := evm.prepare(path, dir, trace)
+	if err != nil {
+		return nil, "", err
+	}
+	cmd := evm.execCommand(evm.path, args...)
+	if _, err := outputTimed(cmd, true, ExecTimeout); err != nil {
+		if _, ok := err.(*CrashError); ok {
+			return nil, cmd.String(), err
+		}
+		// An invalid input is reported by the exit code, and yields no output
+	}
+	data, err := t8nRawOutput(filepath.Join(dir, "out"), trace)
+	return data, cmd.String(), err
+}
+
+// t8nRawOutput returns the raw output of a t8n run, read from the output
+// directory: the traces of the transactions, if requested, followed by one
+// line holding the t8nOutput.
+func t8nRawOutput(dir string, trace bool) ([]byte, error) {
+	var (
+		data   []byte
+		output = t8nOutput{Alloc: json.RawMessage("null"), Result: json.RawMessage("null")}
+	)
+	if trace {
+		data = t8nTraces(dir)
+	}
+	for file, dst := range map[string]*json.RawMessage{"alloc.json": &output.Alloc, "result.json": &output.Result} {
+		if raw, err := os.ReadFile(filepath.Join(dir, file)); err == nil && json.Valid(raw) {
+			*dst = raw
+		}
+	}
+	line, err := json.Marshal(output)
+	return append(data, append(line, '\n')...), err
+}
+
+// t8nTraces returns the contents of the trace files in the directory, ordered
+// by transaction index. The t8n tools name them trace-<txIndex>-<txHash>.jsonl.
+// Each trace is preceded by a line naming the file, which separates the
+// transactions.
+func t8nTraces(dir string) []byte {
+	files, _ := filepath.Glob(filepath.Join(dir, "trace-*"))
+	index := func(file string) int {
+		var i int
+		_, _ = fmt.Sscanf(filepath.Base(file), "trace-%d-", &i)
+		return i
+	}
+	slices.SortFunc(files, func(a, b string) int { return index(a) - index(b) })
+	var data []byte
+	for _, file := range files {
+		trace, err := os.ReadFile(file)
+		if err != nil || len(trace) == 0 {
+			continue
+		}
+		header, _ := json.Marshal(map[string]string{"trace": filepath.Base(file)})
+		data = append(data, append(header, '\n')...)
+		data = append(data, trace...)
+		if trace[len(trace)-1] != '\n' {
+			data = append(data, '\n')
+		}
+	}
+	return data
+}
+
+// RunStateTest implements the Evm interface. The test at the path must be a
+// t8n test.
+func (evm *T8nVM) RunStateTest(path string, out io.Writer, speedTest bool) (*tracingResult, error) {
+	t0 := time.Now()
+	data, cmd, err := evm.execute(path, !speedTest)
+	if err != nil {
+		return &tracingResult{Cmd: cmd}, err
+	}
+	evm.Copy(out, evm.tee(bytes.NewReader(data)))
+	duration, slow := evm.stats.TraceDone(t0)
+	return &tracingResult{
+		Slow:     slow,
+		ExecTime: duration,
+		Cmd:      cmd}, nil
+}
+
+// GetStateRoot runs the test and returns the stateroot.
+func (evm *T8nVM) GetStateRoot(path string) (root, command string, err error) {
+	data, cmd, err := evm.execute(path, false)
+	if err != nil {
+		return "", cmd, err
+	}
+	root, err = evm.ParseStateRoot(data)
+	return root, cmd, err
+}
+
+// ParseStateRoot reads the stateroot from the raw output, where the
+// t8nOutput is the last line.
+func (evm *T8nVM) ParseStateRoot(data []byte) (string, error) {
+	data = bytes.TrimSpace(data)
+	if i := bytes.LastIndexByte(data, '\n'); i >= 0 {
+		data = data[i+1:]
+	}
+	var output t8nOutput
+	if err := json.Unmarshal(data, &output); err != nil {
+		return "", fmt.Errorf("%v: %w", evm.Name(), err)
+	}
+	root := t8nStateRoot(output.Result)
+	if root == "" {
+		return "", fmt.Errorf("%v: no stateroot found", evm.Name())
+	}
+	return root, nil
+}
+
+// DumpState implements StateDumper. The post-state is the alloc written by the
+// t8n tool.
+func (evm *T8nVM) DumpState(path string) (*state.Dump, string, error) {
+	data, cmd, err := evm.execute(path, false)
+	if err != nil {
+		return nil, cmd, err
+	}
+	var (
+		output t8nOutput
+		alloc  types.GenesisAlloc
+	)
+	if err := json.Unmarshal(bytes.TrimSpace(data), &output); err != nil {
+		return nil, cmd, err
+	}
+	if err := json.Unmarshal(output.Alloc, &alloc); err != nil || alloc == nil {
+		return nil, cmd, fmt.Errorf("%v: no post-state found", evm.Name())
+	}
+	dump := &state.Dump{Root: t8nStateRoot(output.Result), Accounts: make(map[string]state.DumpAccount)}
+	for addr, a := range alloc {
+		acc := state.DumpAccount{Balance: "0", Nonce: a.Nonce, CodeHash: crypto.Keccak256(a.Code), Address: &addr}
+		if a.Balance != nil {
+			acc.Balance = a.Balance.String()
+		}
+		for k, v := range a.Storage {
+			if acc.Storage == nil {
+				acc.Storage = make(map[common.Hash]string)
+			}
+			acc.Storage[k] = v.Hex()
+		}
+		dump.Accounts[addr.Hex()] = acc
+	}
+	return dump, cmd, nil
+}
+
+// t8nAccount is an account of the post-state, in canonical form.
+type t8nAccount struct {
+	Address common.Address              `json:"address"`
+	Balance *hexutil.Big                `json:"balance"`
+	Nonce   hexutil.Uint64              `json:"nonce"`
+	Code    hexutil.Bytes               `json:"code"`
+	Storage map[common.Hash]common.Hash `json:"storage,omitempty"`
+}
+
+// t8nResult is the result of the block, in canonical form. The error messages
+// of the rejected transactions differ between the clients, and are left out.
+type t8nResult struct {
+	TxRoot       common.Hash         `json:"txRoot"`
+	ReceiptsRoot common.Hash         `json:"receiptsRoot"`
+	LogsHash     common.Hash         `json:"logsHash"`
+	GasUsed      math.HexOrDecimal64 `json:"gasUsed"`
+	BlobGasUsed  math.HexOrDecimal64 `json:"blobGasUsed,omitempty"`
+	RequestsHash *common.Hash        `json:"requestsHash,omitempty"`
+	Receipts     []struct {
+		Index             math.HexOrDecimal64 `json:"transactionIndex"`
+		Status            math.HexOrDecimal64 `json:"status"`
+		CumulativeGasUsed math.HexOrDecimal64 `json:"cumulativeGasUsed"`
+		GasUsed           math.HexOrDecimal64 `json:"gasUsed"`
+	} `json:"receipts,omitempty"`
+	Rejected []struct {
+		Index math.HexOrDecimal64 `json:"index"`
+	} `json:"rejected,omitempty"`
+}
+
+// Copy reads the raw output and writes the canonical output. The traces are
+// filtered per transaction, as the statetest vm of the client does. The
+// clients differ in how they encode the numbers, and in whether they list
+// zero slots, so the accounts and the result are re-encoded.
+func (evm *T8nVM) Copy(out io.Writer, input io.Reader) {
+	scanner := NewJsonlScanner(evm.name, input, os.Stderr)
+	defer scanner.Release()
+	var (
+		output *t8nOutput
+		trace  []byte // the trace of the current transaction
+	)
+	for {
+		var line json.RawMessage
+		if err := scanner.Next(&line); err != nil {
+			break
+		}
+		if bytes.HasPrefix(line, []byte(`{"trace":`)) {
+			evm.copyTrace(out, trace)
+			trace = trace[:0]
+			continue
+		}
+		if bytes.HasPrefix(line, []byte(`{"alloc":`)) {
+			evm.copyTrace(out, trace)
+			trace = trace[:0]
+			output = new(t8nOutput)
+			if err := json.Unmarshal(line, output); err != nil {
+				output = nil
+			}
+			continue
+		}
+		// Skip the summaries of the transactions and of the calls, which not
+		// all statetest vms filter out
+		var elem opLog
+		if err := json.Unmarshal(line, &elem); err != nil || elem.Depth == 0 {
+			continue
+		}
+		trace = append(append(trace, line...), '\n')
+	}
+	evm.copyTrace(out, trace)
+	if output == nil {
+		writeStateRoot(out, "")
+		return
+	}
+	var alloc types.GenesisAlloc
+	if err := json.Unmarshal(output.Alloc, &alloc); err == nil {
+		var addrs []common.Address
+		for addr := range alloc {
+			addrs = append(addrs, addr)
+		}
+		slices.SortFunc(addrs, func(a, b common.Address) int { return a.Cmp(b) })
+		for _, addr := range addrs {
+			a := alloc[addr]
+			acc := t8nAccount{Address: addr, Balance: (*hexutil.Big)(a.Balance), Nonce: hexutil.Uint64(a.Nonce), Code: a.Code}
+			if acc.Balance == nil {
+				acc.Balance = new(hexutil.Big)
+			}
+			for k, v := range a.Storage {
+				if v == (common.Hash{}) {
+					continue
+				}
+				if acc.Storage == nil {
+					acc.Storage = make(map[common.Hash]common.Hash)
+				}
+				acc.Storage[k] = v
+			}
+			data, _ := json.Marshal(acc)
+			evm.write(out, data)
+		}
+	}
+	var result *t8nResult
+	if err := json.Unmarshal(output.Result, &result); err == nil && result != nil {
+		data, _ := json.Marshal(result)
+		evm.write(out, data)
+	}
+	writeStateRoot(out, t8nStateRoot(output.Result))
+}
+
+// copyTrace writes the trace of a transaction in canonical form.
+func (evm *T8nVM) copyTrace(out io.Writer, trace []byte) {
+	if len(trace) == 0 {
+		return
+	}
+	buf := new(bytes.Buffer)
+	evm.runner.copyTrace(buf, bytes.NewReader(trace))
+	// The statetest vms end the output with the stateroot, which the trace
+	// does not contain
+	data := bytes.TrimSuffix(buf.Bytes(), []byte(`{"stateRoot":""}`+"\n"))
+	if _, err := out.Write(data); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing to out: %v\n", err)
+	}
+}
+
+// write writes a line of canonical output.
+func (evm *T8nVM) write(out io.Writer, data []byte) {
+	if _, err := out.Write(append(data, '\n')); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing to out: %v\n", err)
+	}
+}
+
+// t8nStateRoot returns the stateroot of the t8n result, or an empty stateroot
+// if there is no result.
+func t8nStateRoot(result json.RawMessage) string {
+	var root stateRoot
+	_ = json.Unmarshal(result, &root)
+	return root.StateRoot
+}
+
+func (evm *T8nVM) Close() {}
+
+func (evm *T8nVM) Stats() []any {
+	return evm.stats.Stats()
+}
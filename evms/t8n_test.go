@@ -0,0 +1,199 @@
+// Copyright 2026 Martin Holst Swende
+// This file is part of the goevmlab library.
+//
+// The library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the goevmlab library. If not, see <http://www.gnu.org/licenses/>.
+
+package evms
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const t8nAccounts = `{"address":"0x00000000000000000000000000000000000000f1","balance":"0x0","nonce":"0x0","code":"0x60","storage":{"0x0000000000000000000000000000000000000000000000000000000000000001":"0x0000000000000000000000000000000000000000000000000000000000000002"}}
+{"address":"0xa94f5374fce5edbc8e2a8697c15331677e6ebf0b","balance":"0xffffffffff","nonce":"0x2","code":"0x"}
+`
+
+const t8nCanonical = `{"depth":1,"pc":0,"gas":21000,"op":"0x60","opName":"PUSH1","stack":[]}
+` + t8nAccounts + `{"txRoot":"0x0000000000000000000000000000000000000000000000000000000000000000","receiptsRoot":"0x0000000000000000000000000000000000000000000000000000000000000000","logsHash":"0x0000000000000000000000000000000000000000000000000000000000000000","gasUsed":"0x5208","receipts":[{"transactionIndex":"0x1","status":"0x1","cumulativeGasUsed":"0x5208","gasUsed":"0x5208"}],"rejected":[{"index":"0x0"}]}
+{"stateRoot":"0x1122"}
+`
+
+// TestT8nCopy checks that the differences in the encoding of the output do
+// not show in the canonical output.
+func TestT8nCopy(t *testing.T) {
+	vm := NewGethT8nVM("", "t8n")
+	for i, raw := range []string{
+		// geth
+		`{"pc":0,"op":96,"gas":"0x5208","gasCost":"0x3","memSize":0,"stack":[],"depth":1,"refund":0,"opName":"PUSH1"}
+{"pc":2,"op":0,"gas":"0x5205","gasCost":"0x0","memSize":0,"stack":["0x1"],"depth":1,"refund":0,"opName":"STOP"}
+{"output":"","gasUsed":"0x3"}
+{"alloc":{"0xa94f5374fce5edbc8e2a8697c15331677e6ebf0b":{"balance":"0xffffffffff","nonce":"0x2"},"0x00000000000000000000000000000000000000f1":{"code":"0x60","storage":{"0x0000000000000000000000000000000000000000000000000000000000000001":"0x0000000000000000000000000000000000000000000000000000000000000002"},"balance":"0x0"}},"result":{"stateRoot":"0x1122","txRoot":"0x0000000000000000000000000000000000000000000000000000000000000000","receipts":[{"type":"0x2","status":"0x1","cumulativeGasUsed":"0x5208","logs":null,"gasUsed":"0x5208","transactionIndex":"0x1"}],"rejected":[{"index":0,"error":"nonce too low: address 0xa94f, tx: 0 state: 1"}],"gasUsed":"0x5208"}}
+`,
+		// Short storage keys, zero slots and decimal numbers
+		`{"pc":0,"op":96,"gas":"21000","gasCost":"3","memSize":0,"stack":[],"depth":1,"opName":"PUSH1"}
+{"alloc":{"00000000000000000000000000000000000000f1":{"code":"0x60","storage":{"0x01":"0x02","0x03":"0x00"},"balance":"0","nonce":"0"},"a94f5374fce5edbc8e2a8697c15331677e6ebf0b":{"balance":"1099511627775","nonce":"2","code":"0x","storage":{}}},"result":{"stateRoot":"0x1122","receipts":[{"status":1,"cumulativeGasUsed":21000,"gasUsed":21000,"transactionIndex":1}],"rejected":[{"index":0,"error":"nonce too low"}],"gasUsed":21000,"blobGasUsed":"0x0"}}
+`,
+	} {
+		out := new(bytes.Buffer)
+		vm.Copy(out, strings.NewReader(raw))
+		if have := out.String(); have != t8nCanonical {
+			t.Errorf("test %d: wrong output\nhave: %v\nwant: %v", i, have, t8nCanonical)
+		}
+	}
+}
+
+// fakeT8n copies the files next to it into the output directory, after
+// checking the arguments. When tracing, it writes the traces of two
+// transactions.
+const fakeT8n = `#!/bin/sh
+[ "$1" = "t8n" ] || exit 1
+dir=$(dirname "$0")
+while [ $# -gt 0 ]; do
+	case "$1" in
+	--output.basedir) out="$2" ;;
+	--state.fork) [ "$2" = "Cancun" ] || exit 1 ;;
+	--trace) trace=1 ;;
+	esac
+	shift
+done
+[ -f "$dir/result.json" ] || exit 3
+mkdir -p "$out"
+cp "$dir/alloc.json" "$dir/result.json" "$out"
+if [ -n "$trace" ]; then
+	echo '{"pc":1,"op":1,"gas":"0x2","depth":1}' > "$out/trace-10-0xbb.jsonl"
+	echo '{"pc":0,"op":1,"gas":"0x1","depth":1}' > "$out/trace-9-0xaa.jsonl"
+fi
+`
+
+func TestT8nVM(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	dir := t.TempDir()
+	bin := filepath.Join(dir, "evm")
+	if err := os.WriteFile(bin, []byte(fakeT8n), 0755); err != nil {
+		t.Fatal(err)
+	}
+	test := filepath.Join(dir, "test.json")
+	if err := os.WriteFile(test, []byte(`{"fork":"Cancun","alloc":{},"env":{},"txs":[]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	alloc := `{"0xa94f5374fce5edbc8e2a8697c15331677e6ebf0b":{"balance":"0xffffffffff","nonce":"0x2"},
+	"0x00000000000000000000000000000000000000f1":{"code":"0x60","storage":{"0x01":"0x02"},"balance":"0x0"}}`
+	if err := os.WriteFile(filepath.Join(dir, "alloc.json"), []byte(alloc), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "result.json"), []byte(`{"stateRoot":"0x1122"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var (
+		vm     = NewGethT8nVM(bin, "getht8n-0")
+		result = `{"txRoot":"0x0000000000000000000000000000000000000000000000000000000000000000","receiptsRoot":"0x0000000000000000000000000000000000000000000000000000000000000000","logsHash":"0x0000000000000000000000000000000000000000000000000000000000000000","gasUsed":"0x0"}
+{"stateRoot":"0x1122"}
+`
+		traces = `{"depth":1,"pc":0,"gas":1,"op":"0x01","opName":"ADD","stack":[]}
+{"depth":1,"pc":1,"gas":2,"op":"0x01","opName":"ADD","stack":[]}
+`
+	)
+	for _, speedTest := range []bool{false, true} {
+		want := t8nAccounts + result
+		if !speedTest {
+			want = traces + want
+		}
+		out := new(bytes.Buffer)
+		if _, err := vm.RunStateTest(test, out, speedTest); err != nil {
+			t.Fatal(err)
+		}
+		if have := out.String(); have != want {
+			t.Errorf("speedtest %v: wrong output\nhave: %v\nwant: %v", speedTest, have, want)
+		}
+	}
+	if root, _, err := vm.GetStateRoot(test); err != nil || root != "0x1122" {
+		t.Errorf("wrong stateroot: %v %v", root, err)
+	}
+	dump, _, err := vm.(StateDumper).DumpState(test)
+	if err != nil {
+		t.Fatal(err)
+	}
+	accounts := dumpAccounts(dump)
+	if have := dumpBalance(accounts[common.HexToAddress("0xa94f5374fce5edbc8e2a8697c15331677e6ebf0b")]); have != "1099511627775" {
+		t.Errorf("wrong balance in dump: %v", have)
+	}
+	if have := dumpStorage(accounts[common.HexToAddress("0xf1")]); len(have) != 1 || have[common.HexToHash("0x01")] != "0x2" {
+		t.Errorf("wrong storage in dump: %v", have)
+	}
+	// An input rejected by the tool yields no output
+	if err := os.Remove(filepath.Join(dir, "result.json")); err != nil {
+		t.Fatal(err)
+	}
+	out := new(bytes.Buffer)
+	if _, err := vm.RunStateTest(test, out, false); err != nil {
+		t.Fatal(err)
+	}
+	if have, want := out.String(), `{"stateRoot":""}`+"\n"; have != want {
+		t.Errorf("wrong output\nhave: %v\nwant: %v", have, want)
+	}
+}
+
+// TestT8nRecorded checks that the recorded output of the t8n tools, in
+// testdata/t8n/<client>, yields the same canonical output.
+func TestT8nRecorded(t *testing.T) {
+	var want string
+	for _, vm := range []Evm{
+		// geth first, its output is the reference
+		NewGethT8nVM("", "geth"),
+		NewBesuT8nVM("", "besu"),
+		NewNethermindT8nVM("", "nethermind"),
+		NewEvmoneT8nVM("", "evmone"),
+		NewEelsT8nVM("", "eels"),
+	} {
+		dir := filepath.Join("testdata", "t8n", vm.Name())
+		if _, err := os.Stat(dir); err != nil {
+			t.Logf("%v: no recording", vm.Name())
+			continue
+		}
+		raw, err := t8nRawOutput(dir, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out := new(bytes.Buffer)
+		vm.Copy(out, bytes.NewReader(raw))
+		have := out.String()
+		if want == "" {
+			want = have
+			// The failing opcode is reported twice by geth, but only once
+			// in the canonical output
+			if n := strings.Count(have, `"opName":"RETURNDATACOPY"`); n != 1 {
+				t.Errorf("%v: have %d RETURNDATACOPY steps, want 1", vm.Name(), n)
+			}
+			if strings.Contains(have, `"opName":"STOP"`) {
+				t.Errorf("%v: STOP not dropped", vm.Name())
+			}
+			if root, err := vm.ParseStateRoot(raw); err != nil || !strings.HasSuffix(have, `{"stateRoot":"`+root+`"}`+"\n") {
+				t.Errorf("%v: wrong stateroot %v: %v", vm.Name(), root, err)
+			}
+			continue
+		}
+		if have != want {
+			t.Errorf("%v: wrong output\nhave: %v\nwant: %v", vm.Name(), have, want)
+		}
+	}
+}
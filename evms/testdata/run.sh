@@ -136,6 +136,17 @@ if [[ -n "$evmone" ]]; then
     cd ..
 fi
 
+# evmone, batch-mode: the paths are fed on stdin, as the EvmoneBatchVM does.
+# Not all evmone builds support this.
+if [[ -n "$evmone" ]]; then
+    echo "evmone (batch)"
+    mkdir -p ./batch
+    cd ./cases
+    ls *.json | grep -v eofcode.json | $evmone --trace \
+         2>../batch/evmone.stderr.txt
+    cd ..
+fi
+
 # retun
 if [[ -n "$revm" ]]; then
     echo "revm"
@@ -158,6 +169,18 @@ if [[ -n "$revm" ]]; then
     cd ..
 fi
 
+# revm, batch-mode: the paths are fed on stdin, as the RethBatchVM does.
+# Not all revme builds support this.
+if [[ -n "$revm" ]]; then
+    echo "revm (batch)"
+    mkdir -p ./batch
+    cd ./cases
+    ls *.json | grep -v eofcode.json | $revm statetest --json \
+         2>../batch/revm.stderr.txt \
+         1>/dev/null
+    cd ..
+fi
+
 # execution-specs
 if [[ -n "$eels" ]]; then
     echo "eels"
@@ -179,3 +202,48 @@ if [[ -n "$eels" ]]; then
     done
     cd ..
 fi
+
+# A statetest with several subtests: each client should emit one trace and
+# one stateroot per subtest, in the order of the test.
+cd ./subtests
+i=variants.json
+[[ -n "$evm" ]] && $evm statetest --trace --trace.format=json --trace.nomemory=true --trace.noreturndata=true $i \
+    2>$i.geth.stderr.txt 1>$i.geth.stdout.txt
+[[ -n "$nethtest" ]] && $nethtest --memory --trace --stateTest --input $i \
+    2>$i.nethermind.stderr.txt 1>$i.nethermind.stdout.txt
+[[ -n "$besuvm" ]] && $besuvm --json --nomemory --notime state-test $i \
+    2>$i.besu.stderr.txt 1>$i.besu.stdout.txt
+[[ -n "$erigonvm" ]] && $erigonvm statetest --json --jsonout --nomemory --noreturndata $i \
+    2>$i.erigon.stderr.txt 1>$i.erigon.stdout.txt
+[[ -n "$nimbus" ]] && $nimbus --json --nomemory --noreturndata --nostorage $i \
+    2>$i.nimbus.stderr.txt 1>$i.nimbus.stdout.txt
+[[ -n "$evmone" ]] && $evmone --trace $i 2>$i.evmone.stderr.txt
+[[ -n "$revm" ]] && $revm statetest --json $i 2>$i.revm.stderr.txt 1>/dev/null
+[[ -n "$eels" ]] && $eels statetest --json --nomemory --noreturndata $i \
+    2>$i.eels.stderr.txt 1>$i.eels.stdout.txt
+cd ..
+
+# A t8n test, executed the way the T8nVM does. The traces of the transactions
+# and the post-state are written to t8n/<client>.
+evmonet8n=$EVMO_T8N_BIN
+cd ./t8n
+input=$(mktemp -d)
+for f in alloc env txs; do
+    jq .$f test.json > $input/$f.json
+done
+t8n() {
+    client=$1
+    shift
+    echo "$client (t8n)"
+    rm -rf ./$client
+    "$@" --input.alloc $input/alloc.json --input.env $input/env.json --input.txs $input/txs.json \
+        --trace --output.basedir ./$client --output.result result.json --output.alloc alloc.json \
+        --state.fork $(jq -r .fork test.json) --state.chainid 1 >/dev/null 2>&1
+}
+[[ -n "$evm" ]] && t8n geth $evm t8n
+[[ -n "$nethtest" ]] && t8n nethermind $nethtest t8n
+[[ -n "$besuvm" ]] && t8n besu $besuvm t8n
+[[ -n "$evmonet8n" ]] && t8n evmone $evmonet8n
+[[ -n "$eels" ]] && t8n eels $eels t8n
+rm -rf $input
+cd ..
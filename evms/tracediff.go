@@ -0,0 +1,284 @@
+// Copyright 2026 Martin Holst Swende
+// This file is part of the goevmlab library.
+//
+// The library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the goevmlab library. If not, see <http://www.gnu.org/licenses/>.
+
+package evms
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// TraceStep is one line of canonical output, as produced by CustomMarshal. The
+// final line of a canonical trace only carries the StateRoot.
+type TraceStep struct {
+	Depth     int      `json:"depth"`
+	Pc        uint64   `json:"pc"`
+	Gas       uint64   `json:"gas"`
+	Op        string   `json:"op"`
+	OpName    string   `json:"opName"`
+	Stack     []string `json:"stack"`
+	Error     string   `json:"error"`
+	StateRoot string   `json:"stateRoot"`
+}
+
+// IsStateRoot returns true if the step is the final stateroot-line.
+func (s *TraceStep) IsStateRoot() bool {
+	return s.Op == "" && s.StateRoot != ""
+}
+
+// ParseTraceStep parses a line of canonical output.
+func ParseTraceStep(line []byte) (*TraceStep, error) {
+	var step TraceStep
+	if err := json.Unmarshal(line, &step); err != nil {
+		return nil, err
+	}
+	return &step, nil
+}
+
+// Divergence describes the first point where the canonical outputs of a set
+// of vms disagree.
+type Divergence struct {
+	Step    int      // Step is the (zero-based) line index where the outputs diverge
+	Field   string   // Field which differs, e.g. "gas", "pc", "stack[0]" or "stateRoot"
+	OpName  string   // OpName of the diverging step, if known
+	Depth   int      // Depth of the diverging step, if known
+	Names   []string // Names of the vms
+	Values  []string // Values of the diverging field, one per vm
+	Lines   []string // Lines is the raw canonical output at the diverging step, one per vm
+	Context []string // Context holds the (agreed-upon) lines leading up to the divergence
+}
+
+// String returns a one-line summary of the divergence, naming the vms which
+// differ from the majority, e.g.
+// "besu-0 disagrees on gas at step 1412 (SSTORE, depth 2)". If there is no
+// majority, all vms are named: "besu-0 and geth-0 disagree on ...".
+func (d *Divergence) String() string {
+	var (
+		names = d.Dissenters()
+		verb  = "disagree"
+		who   string
+	)
+	switch n := len(names); n {
+	case 0:
+	case 1:
+		who, verb = names[0], "disagrees"
+	default:
+		who = strings.Join(names[:n-1], ", ") + " and " + names[n-1]
+	}
+	msg := fmt.Sprintf("%v %v on %v at step %d", who, verb, d.Field, d.Step)
+	if d.OpName != "" {
+		msg += fmt.Sprintf(" (%v, depth %d)", d.OpName, d.Depth)
+	}
+	return msg
+}
+
+// Dissenters returns the names of the vms whose output differs from the
+// majority, as determined by Vote, in the order of the vms. If there is no
+// majority, all vms are returned.
+func (d *Divergence) Dissenters() []string {
+	groups, tie := Vote(d.Lines)
+	if tie || len(groups) == 0 || len(groups[0]) == 1 {
+		return d.Names
+	}
+	var names []string
+	for i, name := range d.Names {
+		if !slices.Contains(groups[0], i) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Vote groups the outputs of a set of clients by identical output, and
+// returns the groups of client indices, largest first. Groups of the same
+// size are ordered by first appearance. If there is no single largest group,
+// tie is set.
+func Vote(outputs []string) (groups [][]int, tie bool) {
+	index := make(map[string]int)
+	for i, out := range outputs {
+		if idx, ok := index[out]; ok {
+			groups[idx] = append(groups[idx], i)
+			continue
+		}
+		index[out] = len(groups)
+		groups = append(groups, []int{i})
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		return len(groups[i]) > len(groups[j])
+	})
+	return groups, len(groups) > 1 && len(groups[0]) == len(groups[1])
+}
+
+// Report returns a multi-line report of the divergence, listing the context
+// leading up to the divergence, the differing values and the raw lines.
+func (d *Divergence) Report() string {
+	var out = new(strings.Builder)
+	fmt.Fprintf(out, "%v\n", d)
+	fmt.Fprintf(out, "-------\n")
+	for i, line := range d.Context {
+		fmt.Fprintf(out, "%4d: %15v: %v\n", d.Step-len(d.Context)+i, "all", line)
+	}
+	for i, name := range d.Names {
+		fmt.Fprintf(out, "%4d: %15v: %v\n", d.Step, name, d.Lines[i])
+	}
+	fmt.Fprintf(out, "-------\n")
+	for i, name := range d.Names {
+		fmt.Fprintf(out, "%15v: %v = %v\n", name, d.Field, d.Values[i])
+	}
+	return out.String()
+}
+
+// DiffFiles compares the canonical outputs of the given vms, and returns
+// a description of the first divergence, or nil if the outputs are identical.
+// The contextLines parameter sets how many of the preceding lines to keep
+// in the report.
+func DiffFiles(vms []Evm, readers []io.Reader, contextLines int) *Divergence {
+	var scanners []*bufio.Scanner
+	for _, r := range readers {
+		scanner := bufio.NewScanner(r)
+		buf := bufferPool.Get().([]byte)
+		//lint:ignore SA6002: argument should be pointer-like to avoid allocations.
+		defer bufferPool.Put(buf)
+		scanner.Buffer(buf, len(buf))
+		scanners = append(scanners, scanner)
+	}
+	var (
+		context  []string
+		curLines = make([]string, len(scanners))
+	)
+	for step := 0; ; step++ {
+		var (
+			diffFound = false
+			allDone   = true
+		)
+		for i, scanner := range scanners {
+			if !scanner.Scan() {
+				curLines[i] = "EOF"
+			} else {
+				curLines[i] = scanner.Text()
+				allDone = false
+			}
+			diffFound = diffFound || (curLines[i] != curLines[0])
+		}
+		if allDone {
+			return nil
+		}
+		if diffFound {
+			d := diffLines(curLines)
+			d.Step = step
+			d.Context = context
+			for _, vm := range vms {
+				d.Names = append(d.Names, vm.Name())
+			}
+			return d
+		}
+		if contextLines > 0 {
+			if len(context) == contextLines {
+				context = context[1:]
+			}
+			context = append(context, curLines[0])
+		}
+	}
+}
+
+// diffLines determines which field differs in the given set of lines. The
+// fields are inspected in the order which is most useful to a human, so that
+// e.g. a differing pc is reported before the gas which follows from it.
+func diffLines(lines []string) *Divergence {
+	d := &Divergence{
+		Lines:  append([]string(nil), lines...),
+		Values: make([]string, len(lines)),
+	}
+	var steps = make([]*TraceStep, len(lines))
+	for i, line := range lines {
+		if line == "EOF" {
+			continue
+		}
+		step, err := ParseTraceStep([]byte(line))
+		if err != nil {
+			continue
+		}
+		steps[i] = step
+		if d.OpName == "" && !step.IsStateRoot() {
+			d.OpName, d.Depth = step.OpName, step.Depth
+		}
+	}
+	// setField sets the values of the given field, and returns true if they differ.
+	var setField = func(field string, fn func(i int, step *TraceStep) string) bool {
+		for i, step := range steps {
+			d.Values[i] = fn(i, step)
+		}
+		for _, v := range d.Values[1:] {
+			if v != d.Values[0] {
+				d.Field = field
+				return true
+			}
+		}
+		return false
+	}
+	// Check for traces ending prematurely, or lines not being parseable.
+	if setField("trace length", func(i int, step *TraceStep) string {
+		switch {
+		case lines[i] == "EOF":
+			return "EOF"
+		case step == nil:
+			return "invalid output"
+		case step.IsStateRoot():
+			return "end of trace"
+		}
+		return "step"
+	}) {
+		return d
+	}
+	if steps[0] == nil {
+		// None of the lines could be parsed, report them raw.
+		setField("output", func(i int, step *TraceStep) string { return lines[i] })
+		return d
+	}
+	// All lines are parseable, and either all are stateroots or all are steps.
+	if steps[0].IsStateRoot() {
+		setField("stateRoot", func(i int, step *TraceStep) string { return step.StateRoot })
+		return d
+	}
+	if setField("depth", func(i int, step *TraceStep) string { return strconv.Itoa(step.Depth) }) ||
+		setField("pc", func(i int, step *TraceStep) string { return strconv.FormatUint(step.Pc, 10) }) ||
+		setField("op", func(i int, step *TraceStep) string { return step.OpName }) ||
+		setField("gas", func(i int, step *TraceStep) string { return strconv.FormatUint(step.Gas, 10) }) ||
+		setField("stack size", func(i int, step *TraceStep) string { return strconv.Itoa(len(step.Stack)) }) {
+		return d
+	}
+	// Stack items are reported top-first, so stack[0] is the top of the stack.
+	for pos := 0; pos < len(steps[0].Stack); pos++ {
+		if setField(fmt.Sprintf("stack[%d]", pos), func(i int, step *TraceStep) string {
+			return step.Stack[len(step.Stack)-1-pos]
+		}) {
+			return d
+		}
+	}
+	if setField("error", func(i int, step *TraceStep) string { return step.Error }) {
+		return d
+	}
+	// Some field which is not part of the canonical format differs, report
+	// the raw lines.
+	setField("output", func(i int, step *TraceStep) string { return lines[i] })
+	return d
+}
@@ -0,0 +1,123 @@
+// Copyright 2026 Martin Holst Swende
+// This file is part of the goevmlab library.
+//
+// The library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the goevmlab library. If not, see <http://www.gnu.org/licenses/>.
+
+package evms
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestDiffFiles(t *testing.T) {
+	var (
+		l0   = `{"depth":1,"pc":0,"gas":100,"op":"0x60","opName":"PUSH1","stack":[]}`
+		l1   = `{"depth":1,"pc":2,"gas":97,"op":"0x60","opName":"PUSH1","stack":["0x1"]}`
+		l2   = `{"depth":1,"pc":4,"gas":94,"op":"0x55","opName":"SSTORE","stack":["0x1","0x2"]}`
+		root = `{"stateRoot":"0xaa"}`
+	)
+	vms := []Evm{NewGethEVM("", "geth-0"), NewBesuVM("", "besu-0")}
+	for i, tc := range []struct {
+		a, b    string
+		field   string
+		step    int
+		values  []string
+		summary string
+	}{
+		{
+			a:       strings.Join([]string{l0, l1, l2, root}, "\n"),
+			b:       strings.Join([]string{l0, l1, strings.Replace(l2, `"gas":94`, `"gas":93`, 1), root}, "\n"),
+			field:   "gas",
+			step:    2,
+			values:  []string{"94", "93"},
+			summary: "geth-0 and besu-0 disagree on gas at step 2 (SSTORE, depth 1)",
+		},
+		{
+			a:      strings.Join([]string{l0, l1, l2, root}, "\n"),
+			b:      strings.Join([]string{l0, l1, strings.Replace(l2, `"0x1","0x2"`, `"0x1","0x3"`, 1), root}, "\n"),
+			field:  "stack[0]",
+			step:   2,
+			values: []string{"0x2", "0x3"},
+		},
+		{
+			a:      strings.Join([]string{l0, l1, l2, root}, "\n"),
+			b:      strings.Join([]string{l0, l1, l2, `{"stateRoot":"0xbb"}`}, "\n"),
+			field:  "stateRoot",
+			step:   3,
+			values: []string{"0xaa", "0xbb"},
+		},
+		{
+			a:      strings.Join([]string{l0, l1, l2, root}, "\n"),
+			b:      strings.Join([]string{l0, l1, root}, "\n"),
+			field:  "trace length",
+			step:   2,
+			values: []string{"step", "end of trace"},
+		},
+		{
+			a:      strings.Join([]string{l0, l1, l2, root}, "\n"),
+			b:      strings.Join([]string{l0, l1, strings.Replace(l2, `"stack"`, `"error":"out of gas","stack"`, 1), root}, "\n"),
+			field:  "error",
+			step:   2,
+			values: []string{"", "out of gas"},
+		},
+	} {
+		div := DiffFiles(vms, []io.Reader{strings.NewReader(tc.a), strings.NewReader(tc.b)}, 2)
+		if div == nil {
+			t.Fatalf("test %d: expected divergence", i)
+		}
+		if div.Field != tc.field {
+			t.Errorf("test %d: wrong field, have %q want %q", i, div.Field, tc.field)
+		}
+		if div.Step != tc.step {
+			t.Errorf("test %d: wrong step, have %d want %d", i, div.Step, tc.step)
+		}
+		if have, want := strings.Join(div.Values, ","), strings.Join(tc.values, ","); have != want {
+			t.Errorf("test %d: wrong values, have %q want %q", i, have, want)
+		}
+		if have := len(div.Context); have != 2 {
+			t.Errorf("test %d: wrong context size, have %d want %d", i, have, 2)
+		}
+		if tc.summary != "" && div.String() != tc.summary {
+			t.Errorf("test %d: wrong summary, have %q want %q", i, div.String(), tc.summary)
+		}
+	}
+	// Identical outputs
+	a := strings.Join([]string{l0, l1, l2, root}, "\n")
+	if div := DiffFiles(vms, []io.Reader{strings.NewReader(a), strings.NewReader(a)}, 2); div != nil {
+		t.Fatalf("expected no divergence, got %v", div)
+	}
+	// Only the vms differing from the majority are named
+	var (
+		b    = strings.Join([]string{l0, l1, strings.Replace(l2, `"gas":94`, `"gas":93`, 1), root}, "\n")
+		vms3 = append(vms, NewNethermindVM("", "nethermind-0"))
+	)
+	for i, tc := range []struct {
+		outputs []string
+		want    string
+	}{
+		{[]string{a, a, b}, "nethermind-0 disagrees on gas at step 2 (SSTORE, depth 1)"},
+		{[]string{b, a, a}, "geth-0 disagrees on gas at step 2 (SSTORE, depth 1)"},
+		{[]string{a, b, strings.Replace(b, `"gas":93`, `"gas":92`, 1)}, "geth-0, besu-0 and nethermind-0 disagree on gas at step 2 (SSTORE, depth 1)"},
+	} {
+		var readers []io.Reader
+		for _, out := range tc.outputs {
+			readers = append(readers, strings.NewReader(out))
+		}
+		if have := DiffFiles(vms3, readers, 2).String(); have != tc.want {
+			t.Errorf("majority test %d: wrong summary, have %q want %q", i, have, tc.want)
+		}
+	}
+}
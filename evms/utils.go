@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"errors"
 	"os/exec"
+	"time"
 )
 
 const (
@@ -29,13 +30,18 @@ const (
 	ClearGascost = true
 )
 
-// StdErrOutput runs the command and returns its standard error.
+// StdErrOutput runs the command and returns its standard error. The process
+// is killed if it does not finish within the ExecTimeout.
 func StdErrOutput(c *exec.Cmd) ([]byte, error) {
 	if c.Stderr != nil {
 		return nil, errors.New("exec: Stderr already set")
 	}
 	var b bytes.Buffer
 	c.Stderr = &b
-	err := c.Run()
+	c.WaitDelay = time.Second
+	if err := c.Start(); err != nil {
+		return nil, err
+	}
+	err := waitTimed(c, nil, ExecTimeout, nil)
 	return b.Bytes(), err
 }
@@ -0,0 +1,54 @@
+// Copyright 2026 Martin Holst Swende
+// This file is part of the goevmlab library.
+//
+// The library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the goevmlab library. If not, see <http://www.gnu.org/licenses/>.
+
+package evms
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// ClientInfo is implemented by vms which execute a known client. It is used
+// to tell different versions of the same client apart.
+type ClientInfo interface {
+	// Kind returns the kind of client, e.g. "geth". Batch-mode and single-shot
+	// vms for the same client are of the same kind.
+	Kind() string
+	// Binary returns the path of the client binary, or the empty string if
+	// the client is executed in-process.
+	Binary() string
+	// Version returns the version of the client, as reported by the binary.
+	Version() (string, error)
+}
+
+// binaryVersion executes the command, typically "<binary> --version", and
+// returns the first non-empty line of the output.
+func binaryVersion(cmd *exec.Cmd) (string, error) {
+	out, err := outputTimed(cmd, true, ExecTimeout)
+	if err != nil {
+		return "", fmt.Errorf("%v: %w", cmd, err)
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			return line, nil
+		}
+	}
+	return "", fmt.Errorf("%v: no version in output", cmd)
+}
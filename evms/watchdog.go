@@ -0,0 +1,177 @@
+// Copyright 2026 Martin Holst Swende
+// This file is part of the goevmlab library.
+//
+// The library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the goevmlab library. If not, see <http://www.gnu.org/licenses/>.
+
+package evms
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"time"
+)
+
+// ExecTimeout is the maximum time a vm may spend on a single test. When
+// exceeded, the process is killed, and the test is reported as a timeout.
+// Batch-mode vms restart the 'master' process on the next test.
+// Zero means no limit.
+var ExecTimeout = 5 * time.Minute
+
+// CrashError is returned when a client process exits or hangs while executing
+// a test. The output of such a test is incomplete, and should not be compared
+// against the output of other clients.
+type CrashError struct {
+	Cmd     string // the command which crashed
+	Timeout bool   // true if the process hung, and was killed
+	Err     error  // the exit status of the process, if any
+}
+
+func (e *CrashError) Error() string {
+	if e.Timeout {
+		return fmt.Sprintf("client timed out: %v", e.Cmd)
+	}
+	if e.Err == nil {
+		return fmt.Sprintf("client crashed: %v (no stateroot)", e.Cmd)
+	}
+	return fmt.Sprintf("client crashed: %v (%v)", e.Cmd, e.Err)
+}
+
+func (e *CrashError) Unwrap() error {
+	return e.Err
+}
+
+// execBatched feeds the path to the master process of a batch-mode vm, and
+// invokes copyFn to read the output for the test. The copying is aborted if
+// it takes longer than the given timeout.
+// If the copyFn does not yield a stateroot, the master process is assumed to
+// have exited (or to be in an unknown state). In that case, and on timeout,
+// the process is killed and a CrashError is returned. The caller must then
+// discard the process, and start a new one for the next test.
+func execBatched(cmd *exec.Cmd, stdin io.Writer, procOut io.Closer, path string,
+	timeout time.Duration, copyFn func() stateRoot) (stateRoot, error) {
+	if _, err := fmt.Fprintf(stdin, "%v\n", path); err != nil {
+		// The process is gone, the stdin pipe is broken.
+		_ = cmd.Process.Kill()
+		return stateRoot{}, &CrashError{Cmd: cmd.String(), Err: cmd.Wait()}
+	}
+	done := make(chan stateRoot, 1)
+	go func() {
+		done <- copyFn()
+	}()
+	var timer <-chan time.Time
+	if timeout > 0 {
+		t := time.NewTimer(timeout)
+		defer t.Stop()
+		timer = t.C
+	}
+	select {
+	case root := <-done:
+		if root.StateRoot != "" {
+			return root, nil
+		}
+		_ = cmd.Process.Kill()
+		return root, &CrashError{Cmd: cmd.String(), Err: cmd.Wait()}
+	case <-timer:
+		// Kill the process, and close the pipe: the process may have spawned
+		// children of its own, which keep the pipe open.
+		_ = cmd.Process.Kill()
+		_ = procOut.Close()
+		<-done
+		_ = cmd.Wait()
+		return stateRoot{}, &CrashError{Cmd: cmd.String(), Timeout: true}
+	}
+}
+
+// checkExit classifies the exit of a client process which executed a single
+// test, the same way execBatched does for batch-mode vms: if the process did
+// not yield a stateroot, it crashed, whatever its exit status. Otherwise, a
+// non-zero exit status is ignored: clients exit with an error e.g. when the
+// stateroot does not match the test.
+func checkExit(cmd *exec.Cmd, root stateRoot, err error) error {
+	var (
+		crash   *CrashError
+		exitErr *exec.ExitError
+	)
+	switch {
+	case errors.As(err, &crash):
+		return err
+	case err != nil && !errors.As(err, &exitErr):
+		return err // not an exit status, e.g. the process failed to start
+	case root.StateRoot == "":
+		return &CrashError{Cmd: cmd.String(), Err: err}
+	}
+	return nil
+}
+
+// checkRoot parses the stateroot from the output of a client process, and
+// classifies its exit like checkExit.
+func checkRoot(cmd *exec.Cmd, data []byte, err error, parse func([]byte) (string, error)) (string, error) {
+	root, _ := parse(data)
+	if err := checkExit(cmd, stateRoot{StateRoot: root}, err); err != nil {
+		return "", err
+	}
+	return root, nil
+}
+
+// waitTimed invokes fn, which is expected to consume the output of the
+// command, and then waits for the command to exit. If that takes longer than
+// the timeout, the process is killed, the output pipe closed, and a
+// CrashError is returned. Otherwise, the error from cmd.Wait is returned.
+func waitTimed(cmd *exec.Cmd, procOut io.Closer, timeout time.Duration, fn func()) error {
+	done := make(chan error, 1)
+	go func() {
+		if fn != nil {
+			fn()
+		}
+		done <- cmd.Wait()
+	}()
+	var timer <-chan time.Time
+	if timeout > 0 {
+		t := time.NewTimer(timeout)
+		defer t.Stop()
+		timer = t.C
+	}
+	select {
+	case err := <-done:
+		return err
+	case <-timer:
+		_ = cmd.Process.Kill()
+		if procOut != nil {
+			_ = procOut.Close()
+		}
+		<-done
+		return &CrashError{Cmd: cmd.String(), Timeout: true}
+	}
+}
+
+// outputTimed is like cmd.Output, or cmd.CombinedOutput if combined is set,
+// but kills the process if it does not finish within the timeout.
+func outputTimed(cmd *exec.Cmd, combined bool, timeout time.Duration) ([]byte, error) {
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	if combined {
+		cmd.Stderr = &out
+	}
+	// If the process is killed, don't wait for any children holding the
+	// pipes open.
+	cmd.WaitDelay = time.Second
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	err := waitTimed(cmd, nil, timeout, nil)
+	return out.Bytes(), err
+}
@@ -0,0 +1,151 @@
+// Copyright 2026 Martin Holst Swende
+// This file is part of the goevmlab library.
+//
+// The library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the goevmlab library. If not, see <http://www.gnu.org/licenses/>.
+
+package evms
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// fakeMaster is a batch-mode 'vm', which crashes or hangs depending on the
+// name of the test.
+const fakeMaster = `#!/bin/sh
+while read path; do
+	case "$path" in
+	*crash*) echo '{"depth":1,"pc":0,"gas":100,"op":"0x0","opName":"STOP","stack":[]}' >&2; exit 3 ;;
+	*hang*) sleep 30 ;;
+	*) echo '{"stateRoot": "0x01"}' >&2 ;;
+	esac
+done
+`
+
+func TestBatchWatchdog(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	bin := filepath.Join(t.TempDir(), "fakevm")
+	if err := os.WriteFile(bin, []byte(fakeMaster), 0755); err != nil {
+		t.Fatal(err)
+	}
+	defer func(timeout time.Duration) { ExecTimeout = timeout }(ExecTimeout)
+	ExecTimeout = 500 * time.Millisecond
+
+	vm := NewGethBatchVM(bin, "fake")
+	defer vm.Close()
+	run := func(path string) *CrashError {
+		t.Helper()
+		_, err := vm.RunStateTest(path, new(bytes.Buffer), false)
+		if err == nil {
+			return nil
+		}
+		var crash *CrashError
+		if !errors.As(err, &crash) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return crash
+	}
+	if crash := run("ok.json"); crash != nil {
+		t.Fatalf("unexpected crash: %v", crash)
+	}
+	if crash := run("crash.json"); crash == nil || crash.Timeout {
+		t.Fatalf("expected crash, got %v", crash)
+	}
+	// The master should be restarted
+	if crash := run("ok.json"); crash != nil {
+		t.Fatalf("unexpected crash after restart: %v", crash)
+	}
+	if crash := run("hang.json"); crash == nil || !crash.Timeout {
+		t.Fatalf("expected timeout, got %v", crash)
+	}
+	if root, _, err := vm.GetStateRoot("ok.json"); err != nil || root != "0x01" {
+		t.Fatalf("unexpected result after restart: root %v, err %v", root, err)
+	}
+}
+
+// fakeVM is a single-test 'vm', which crashes, hangs or fails the test
+// depending on the name of the test.
+const fakeVM = `#!/bin/sh
+case "$*" in
+*crash*) echo '{"depth":1,"pc":0,"gas":100,"op":"0x0","opName":"STOP","stack":[]}' >&2; exit 3 ;;
+*hang*) sleep 30 ;;
+*mismatch*) echo '{"stateRoot": "0x0000000000000000000000000000000000000000000000000000000000000002"}' | tee /dev/stderr; exit 1 ;;
+esac
+echo '{"stateRoot": "0x0000000000000000000000000000000000000000000000000000000000000001"}' >&2
+`
+
+func TestExecTimeout(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	bin := filepath.Join(t.TempDir(), "fakevm")
+	if err := os.WriteFile(bin, []byte(fakeVM), 0755); err != nil {
+		t.Fatal(err)
+	}
+	defer func(timeout time.Duration) { ExecTimeout = timeout }(ExecTimeout)
+	ExecTimeout = 500 * time.Millisecond
+
+	vm := NewGethEVM(bin, "fake")
+	if _, err := vm.RunStateTest("ok.json", new(bytes.Buffer), false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var crash *CrashError
+	if _, err := vm.RunStateTest("hang.json", new(bytes.Buffer), false); !errors.As(err, &crash) || !crash.Timeout {
+		t.Fatalf("expected timeout, got %v", err)
+	}
+	if _, _, err := vm.GetStateRoot("hang.json"); !errors.As(err, &crash) || !crash.Timeout {
+		t.Fatalf("expected timeout, got %v", err)
+	}
+}
+
+// TestExitClassification checks that a process which exits without yielding a
+// stateroot is a crash, both in batch mode and otherwise, while a non-zero
+// exit status after the stateroot is not.
+func TestExitClassification(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	dir := t.TempDir()
+	single, batch := filepath.Join(dir, "fakevm"), filepath.Join(dir, "fakemaster")
+	if err := os.WriteFile(single, []byte(fakeVM), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(batch, []byte(fakeMaster), 0755); err != nil {
+		t.Fatal(err)
+	}
+	vms := []Evm{NewGethEVM(single, "single"), NewGethBatchVM(batch, "batch")}
+	for _, vm := range vms {
+		defer vm.Close()
+		var crash *CrashError
+		if _, err := vm.RunStateTest("crash.json", new(bytes.Buffer), false); !errors.As(err, &crash) || crash.Timeout {
+			t.Errorf("%v: expected crash, got %v", vm.Name(), err)
+		}
+		if _, _, err := vm.GetStateRoot("crash.json"); !errors.As(err, &crash) || crash.Timeout {
+			t.Errorf("%v: expected crash, got %v", vm.Name(), err)
+		}
+		if _, err := vm.RunStateTest("mismatch.json", new(bytes.Buffer), false); err != nil {
+			t.Errorf("%v: unexpected error: %v", vm.Name(), err)
+		}
+	}
+	if root, _, err := vms[0].GetStateRoot("mismatch.json"); err != nil || root != "0x0000000000000000000000000000000000000000000000000000000000000002" {
+		t.Errorf("unexpected result: root %v, err %v", root, err)
+	}
+}
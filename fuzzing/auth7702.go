@@ -8,7 +8,6 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/holiman/uint256"
@@ -60,7 +59,7 @@ func (h *authHelper) consumeNonce(addr common.Address) uint64 {
 	return cur
 }
 
-func fill7702(gst *GstMaker, fork string) {
+func fill7702(rng *rand.Rand, gst *GstMaker, fork string) {
 	h := newHelper()
 	contracts := []common.Address{
 		common.HexToAddress("0xF1"),
@@ -80,14 +79,14 @@ func fill7702(gst *GstMaker, fork string) {
 	// Add the empty-addr (acts as a clearing-marker)
 	allAddresses = append(allAddresses, common.Address{})
 	// Also add precompile-addresses
-	allAddresses = append(allAddresses, vm.PrecompiledAddressesPrague...)
+	allAddresses = append(allAddresses, precompilesPrague...)
 
 	// each contract does a bit calling within the global set
 	for _, addr := range contracts {
 		gst.AddAccount(addr, GenesisAccount{
-			Code:    RandCall2200(allAddresses),
+			Code:    RandCall2200(rng, allAddresses),
 			Balance: new(big.Int),
-			Storage: RandStorage(15, 20),
+			Storage: RandStorage(rng, 15, 20),
 		})
 	}
 
@@ -100,9 +99,9 @@ func fill7702(gst *GstMaker, fork string) {
 	}
 	//
 	var authList []*stAuthorization
-	for i := 0; i < 1+rand.Intn(25); i++ {
-		source := h.addrs[rand.Int()%len(h.addrs)]
-		dest := allAddresses[rand.Int()%len(allAddresses)]
+	for i := 0; i < 1+rng.Intn(25); i++ {
+		source := h.addrs[rng.Int()%len(h.addrs)]
+		dest := allAddresses[rng.Int()%len(allAddresses)]
 
 		nonce := h.consumeNonce(source)
 		unsigned := types.SetCodeAuthorization{
@@ -110,13 +109,13 @@ func fill7702(gst *GstMaker, fork string) {
 			Address: dest,
 			Nonce:   nonce,
 		}
-		switch rand.Intn(20) {
+		switch rng.Intn(20) {
 		case 0:
 			// Random chain id
-			unsigned.ChainID = randU256()
+			unsigned.ChainID = randU256(rng)
 		case 1:
 			// Random nonce
-			unsigned.Nonce = rand.Uint64()
+			unsigned.Nonce = rng.Uint64()
 		}
 		a, err := types.SignSetCode(h.keys[source], unsigned)
 		//		a, err := h.makeAuth(source, dest)
@@ -140,11 +139,11 @@ func fill7702(gst *GstMaker, fork string) {
 			// 8M gaslimit
 			GasLimit:             []uint64{8000000},
 			Nonce:                0,
-			Value:                []string{randHex(4)},
-			Data:                 []string{randHex(100)},
+			Value:                []string{randHex(rng, 4)},
+			Data:                 []string{randHex(rng, 100)},
 			MaxFeePerGas:         big.NewInt(0x10),
 			MaxPriorityFeePerGas: big.NewInt(0x10),
-			To:                   allAddresses[rand.Int()%len(allAddresses)].Hex(),
+			To:                   allAddresses[rng.Int()%len(allAddresses)].Hex(),
 			Sender:               sender,
 			PrivateKey:           hexutil.MustDecode("0x45a915e4d060149eb4365960e6a7a45f334393093061116b197e3240065ff2d8"),
 			AuthorizationList:    authList,
@@ -153,19 +152,19 @@ func fill7702(gst *GstMaker, fork string) {
 	}
 }
 
-func randU256() uint256.Int {
+func randU256(rng *rand.Rand) uint256.Int {
 	var a uint256.Int
-	if rand.Int()%2 == 0 {
-		a[0] = rand.Uint64()
+	if rng.Int()%2 == 0 {
+		a[0] = rng.Uint64()
 	}
-	if rand.Int()%2 == 0 {
-		a[1] = rand.Uint64()
+	if rng.Int()%2 == 0 {
+		a[1] = rng.Uint64()
 	}
-	if rand.Int()%2 == 0 {
-		a[2] = rand.Uint64()
+	if rng.Int()%2 == 0 {
+		a[2] = rng.Uint64()
 	}
-	if rand.Int()%2 == 0 {
-		a[3] = rand.Uint64()
+	if rng.Int()%2 == 0 {
+		a[3] = rng.Uint64()
 	}
 	return a
 }
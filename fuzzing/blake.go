@@ -2,15 +2,16 @@ package fuzzing
 
 import (
 	"math/big"
+	"math/rand"
 
 	"github.com/ethereum/go-ethereum/common"
 )
 
-func fillBlake(gst *GstMaker, fork string) {
+func fillBlake(rng *rand.Rand, gst *GstMaker, fork string) {
 	// Add a contract which calls blake
 	dest := common.HexToAddress("0x0000ca1100b1a7e")
 	gst.AddAccount(dest, GenesisAccount{
-		Code:    RandCallBlake(),
+		Code:    RandCallBlake(rng),
 		Balance: big.NewInt(10_000_000),
 		Storage: make(map[common.Hash]common.Hash),
 	})
@@ -18,8 +19,8 @@ func fillBlake(gst *GstMaker, fork string) {
 	gst.SetTx(&StTransaction{
 		// 8M gaslimit
 		GasLimit:   []uint64{8000000},
-		Value:      []string{randHex(4)},
-		Data:       []string{randHex(100)},
+		Value:      []string{randHex(rng, 4)},
+		Data:       []string{randHex(rng, 100)},
 		GasPrice:   big.NewInt(0x10),
 		To:         dest.Hex(),
 		Sender:     sender,
@@ -0,0 +1,197 @@
+// Copyright 2026 Martin Holst Swende
+// This file is part of the goevmlab library.
+//
+// The library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the goevmlab library. If not, see <http://www.gnu.org/licenses/>.
+
+package fuzzing
+
+import (
+	"math/big"
+	"math/rand"
+	"slices"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/core/vm/program"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// blockFillers is a mapping of names to functions that can fill a blockchain
+// test. As with the statetest fillers, all randomness is drawn from the given
+// source.
+var blockFillers = map[string]func(*rand.Rand, *BtMaker){
+	"blocks": fillBlocks,
+}
+
+// GenerateBlockTest generates a blockchain test using the named engine, or
+// returns nil if there is no such engine. The same engine and seed always
+// produce the same test.
+func GenerateBlockTest(name, fork string, seed int64) *BtMaker {
+	filler, ok := blockFillers[name]
+	if !ok {
+		return nil
+	}
+	bt := NewBtMaker(fork)
+	filler(rand.New(rand.NewSource(seed)), bt)
+	bt.info = &stInfo{Engine: name, Seed: seed}
+	return bt
+}
+
+// SeededBlockFactory is like SeededFactory, but for blockchain tests.
+func SeededBlockFactory(name, fork string, seed int64) func() *BtMaker {
+	if g := NewSeededBlockGenerator(name, fork, seed); g != nil {
+		return g.Generate
+	}
+	return nil
+}
+
+// NewSeededBlockGenerator is like NewSeededGenerator, but for blockchain
+// tests.
+func NewSeededBlockGenerator(name, fork string, seed int64) *SeededGenerator[*BtMaker] {
+	if _, ok := blockFillers[name]; !ok {
+		return nil
+	}
+	return &SeededGenerator[*BtMaker]{
+		generate: func(seed int64) *BtMaker { return GenerateBlockTest(name, fork, seed) },
+		seed:     seed,
+	}
+}
+
+// BlockFactoryNames returns the names of the available blockchain test factories.
+func BlockFactoryNames() []string {
+	var names []string
+	for k := range blockFillers {
+		names = append(names, k)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// The contract which probes the block context
+var blockProbe = common.HexToAddress("0x00000000000000000000000000000000000b10c0")
+
+// fillBlocks creates a chain of a few blocks, where each block contains
+// transactions that probe the block context and the system contracts, plus
+// some value transfers, withdrawals and requests to the system contracts.
+func fillBlocks(rng *rand.Rand, bt *BtMaker) {
+	cancun := isForkOrLater(bt.fork, "Cancun")
+	bt.SetCode(blockProbe, randBlockProbe(rng, cancun))
+	recipients := []common.Address{
+		blockProbe,
+		common.HexToAddress("0x00000000000000000000000000000000000000f1"),
+		common.HexToAddress("0x00000000000000000000000000000000000000f2"),
+		params.BeaconRootsAddress,
+		params.HistoryStorageAddress,
+	}
+	nBlocks := 1 + rng.Intn(8)
+	for i := 0; i < nBlocks; i++ {
+		block := bt.AddBlock()
+		_, _ = rng.Read(block.BeaconRoot[:])
+		nTxs := rng.Intn(5)
+		for j := 0; j < nTxs; j++ {
+			var tx *BtTx
+			switch r := chance(rng.Intn(100)); {
+			case r.between(0, 60):
+				// Probe the context; the calldata distinguishes the txs of a block
+				tx = &BtTx{To: &blockProbe, Data: common.LeftPadBytes([]byte{byte(j)}, 32), GasLimit: 2_000_000}
+			case r.between(60, 75):
+				to := recipients[rng.Intn(len(recipients))]
+				tx = &BtTx{To: &to, Value: big.NewInt(int64(rng.Intn(1000))), GasLimit: 100_000}
+			case r.between(75, 85):
+				// A withdrawal request: 48 bytes pubkey and 8 bytes amount
+				data := make([]byte, 56)
+				_, _ = rng.Read(data)
+				tx = &BtTx{To: &params.WithdrawalQueueAddress, Data: data, Value: big.NewInt(1), GasLimit: 500_000}
+			case r.between(85, 95):
+				// A consolidation request: 48 bytes source and target pubkeys
+				data := make([]byte, 96)
+				_, _ = rng.Read(data)
+				tx = &BtTx{To: &params.ConsolidationQueueAddress, Data: data, Value: big.NewInt(1), GasLimit: 500_000}
+			default:
+				// Deploy a copy of the probe
+				ctor := program.New().ReturnViaCodeCopy(randBlockProbe(rng, cancun))
+				tx = &BtTx{Data: ctor.Bytes(), GasLimit: 2_000_000}
+			}
+			block.AddTx(tx)
+		}
+		if isForkOrLater(bt.fork, "Shanghai") {
+			for j := rng.Intn(3); j > 0; j-- {
+				to := recipients[rng.Intn(len(recipients))]
+				block.AddWithdrawal(uint64(rng.Intn(100)), to, uint64(rng.Intn(1_000_000)))
+			}
+		}
+	}
+}
+
+// randBlockProbe creates code which reads the block context and the system
+// contracts, and stores the results. The results of each block and tx are
+// stored in separate slots, so they all end up in the post-state.
+func randBlockProbe(rng *rand.Rand, cancun bool) []byte {
+	p := program.New()
+	slot := 0
+	store := func() {
+		// key: number << 16 | tx << 8 | slot
+		p.Push(slot).Push(0).Op(vm.CALLDATALOAD).Push(8).Op(vm.SHL, vm.OR)
+		p.Op(vm.NUMBER).Push(16).Op(vm.SHL, vm.OR)
+		p.Op(vm.SSTORE)
+		slot++
+	}
+	// Transient storage must be empty at the start of each tx
+	p.Push(0).Op(vm.TLOAD)
+	store()
+	p.Tstore(0, 1)
+	// Query the system contracts for a recent block
+	query := func(addr common.Address, key vm.OpCode, dist int) {
+		p.Push(dist).Op(key, vm.SUB)
+		p.Push(0).Op(vm.MSTORE)
+		p.Push(0).Push(32).Op(vm.MSTORE)
+		p.StaticCall(nil, addr, 0, 32, 32, 32)
+		store()
+		p.Push(32).Op(vm.MLOAD)
+		store()
+	}
+	for n := 4 + rng.Intn(12); n > 0; n-- {
+		switch rng.Intn(6) {
+		case 0:
+			dists := []int{0, 1, 2, 255, 256, 257, rng.Intn(300)}
+			p.Push(dists[rng.Intn(len(dists))]).Op(vm.NUMBER, vm.SUB, vm.BLOCKHASH)
+			store()
+		case 1:
+			ops := []vm.OpCode{vm.NUMBER, vm.TIMESTAMP, vm.BASEFEE, vm.GASLIMIT, vm.COINBASE,
+				vm.PREVRANDAO, vm.CHAINID, vm.SELFBALANCE}
+			if cancun {
+				ops = append(ops, vm.BLOBBASEFEE)
+			}
+			p.Op(ops[rng.Intn(len(ops))])
+			store()
+		case 2:
+			query(params.BeaconRootsAddress, vm.TIMESTAMP, 10*rng.Intn(4))
+		case 3:
+			query(params.HistoryStorageAddress, vm.NUMBER, 1+rng.Intn(4))
+		case 4:
+			addrs := []common.Address{btCoinbase, params.SystemAddress,
+				common.HexToAddress("0x00000000000000000000000000000000000000f1"),
+				common.HexToAddress("0x00000000000000000000000000000000000000f2")}
+			p.Push(addrs[rng.Intn(len(addrs))]).Op(vm.BALANCE)
+			store()
+		default:
+			// Read back a result of a previous block
+			p.Push(rng.Intn(4)).Push(0).Op(vm.CALLDATALOAD).Push(8).Op(vm.SHL, vm.OR)
+			p.Push(1).Op(vm.NUMBER, vm.SUB).Push(16).Op(vm.SHL, vm.OR)
+			p.Op(vm.SLOAD)
+			store()
+		}
+	}
+	return p.Bytes()
+}
@@ -0,0 +1,568 @@
+// Copyright 2026 Martin Holst Swende
+// This file is part of the goevmlab library.
+//
+// The library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the goevmlab library. If not, see <http://www.gnu.org/licenses/>.
+
+package fuzzing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/beacon"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/consensus/misc/eip1559"
+	"github.com/ethereum/go-ethereum/consensus/misc/eip4844"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/tests"
+	"github.com/ethereum/go-ethereum/triedb"
+	"github.com/holiman/uint256"
+)
+
+// btGasLimit is the gas limit of the blocks in a blockchain test.
+const btGasLimit = 30_000_000
+
+// The coinbase of the blocks in a blockchain test
+var btCoinbase = common.HexToAddress("b94f5374fce5edbc8e2a8697c15331677e6ebf0b")
+
+// BlockTest is a file of blockchain tests, by name.
+type BlockTest map[string]*btJSON
+
+// btJSON is the blockchain test format, as read by the blocktest runners of
+// the clients.
+type btJSON struct {
+	Info       *stInfo               `json:"_info,omitempty"`
+	Blocks     []btBlock             `json:"blocks"`
+	Genesis    *btHeader             `json:"genesisBlockHeader"`
+	GenesisRLP hexutil.Bytes         `json:"genesisRLP"`
+	Pre        GenesisAlloc          `json:"pre"`
+	PostHash   common.Hash           `json:"postStateHash"`
+	BestBlock  common.UnprefixedHash `json:"lastblockhash"`
+	Network    string                `json:"network"`
+	SealEngine string                `json:"sealEngine"`
+}
+
+type btBlock struct {
+	Header       *btHeader           `json:"blockHeader"`
+	Rlp          hexutil.Bytes       `json:"rlp"`
+	UncleHeaders []*btHeader         `json:"uncleHeaders"`
+	Withdrawals  []*types.Withdrawal `json:"withdrawals,omitempty"`
+}
+
+type btHeader struct {
+	ParentHash       common.Hash      `json:"parentHash"`
+	UncleHash        common.Hash      `json:"uncleHash"`
+	Coinbase         common.Address   `json:"coinbase"`
+	StateRoot        common.Hash      `json:"stateRoot"`
+	TransactionsTrie common.Hash      `json:"transactionsTrie"`
+	ReceiptTrie      common.Hash      `json:"receiptTrie"`
+	Bloom            types.Bloom      `json:"bloom"`
+	Difficulty       *hexutil.Big     `json:"difficulty"`
+	Number           *hexutil.Big     `json:"number"`
+	GasLimit         hexutil.Uint64   `json:"gasLimit"`
+	GasUsed          hexutil.Uint64   `json:"gasUsed"`
+	Timestamp        hexutil.Uint64   `json:"timestamp"`
+	ExtraData        hexutil.Bytes    `json:"extraData"`
+	MixHash          common.Hash      `json:"mixHash"`
+	Nonce            types.BlockNonce `json:"nonce"`
+	BaseFee          *hexutil.Big     `json:"baseFeePerGas,omitempty"`
+	WithdrawalsRoot  *common.Hash     `json:"withdrawalsRoot,omitempty"`
+	BlobGasUsed      *hexutil.Uint64  `json:"blobGasUsed,omitempty"`
+	ExcessBlobGas    *hexutil.Uint64  `json:"excessBlobGas,omitempty"`
+	ParentBeaconRoot *common.Hash     `json:"parentBeaconBlockRoot,omitempty"`
+	RequestsHash     *common.Hash     `json:"requestsHash,omitempty"`
+	Hash             common.Hash      `json:"hash"`
+}
+
+func newBtHeader(h *types.Header) *btHeader {
+	return &btHeader{
+		ParentHash:       h.ParentHash,
+		UncleHash:        h.UncleHash,
+		Coinbase:         h.Coinbase,
+		StateRoot:        h.Root,
+		TransactionsTrie: h.TxHash,
+		ReceiptTrie:      h.ReceiptHash,
+		Bloom:            h.Bloom,
+		Difficulty:       (*hexutil.Big)(h.Difficulty),
+		Number:           (*hexutil.Big)(h.Number),
+		GasLimit:         hexutil.Uint64(h.GasLimit),
+		GasUsed:          hexutil.Uint64(h.GasUsed),
+		Timestamp:        hexutil.Uint64(h.Time),
+		ExtraData:        h.Extra,
+		MixHash:          h.MixDigest,
+		Nonce:            h.Nonce,
+		BaseFee:          (*hexutil.Big)(h.BaseFee),
+		WithdrawalsRoot:  h.WithdrawalsHash,
+		BlobGasUsed:      (*hexutil.Uint64)(h.BlobGasUsed),
+		ExcessBlobGas:    (*hexutil.Uint64)(h.ExcessBlobGas),
+		ParentBeaconRoot: h.ParentBeaconRoot,
+		RequestsHash:     h.RequestsHash,
+		Hash:             h.Hash(),
+	}
+}
+
+// BtBlock is a block of a blockchain test.
+type BtBlock struct {
+	Txs         []*BtTx
+	Withdrawals []*types.Withdrawal // from Shanghai; the index is assigned when filling
+	BeaconRoot  common.Hash         // the parent beacon block root, from Cancun
+}
+
+// BtTx is a transaction of a blockchain test. It is sent by the sender of
+// the test; the nonce, fees and signature are assigned when filling.
+type BtTx struct {
+	To       *common.Address // nil for contract creation
+	Data     []byte
+	Value    *big.Int
+	GasLimit uint64
+}
+
+// AddTx adds a transaction to the block.
+func (b *BtBlock) AddTx(tx *BtTx) {
+	b.Txs = append(b.Txs, tx)
+}
+
+// AddWithdrawal adds a withdrawal of the given amount (in gwei) to the block.
+func (b *BtBlock) AddWithdrawal(validator uint64, address common.Address, amount uint64) {
+	b.Withdrawals = append(b.Withdrawals, &types.Withdrawal{
+		Validator: validator,
+		Address:   address,
+		Amount:    amount,
+	})
+}
+
+// BtMaker is a construct to generate blockchain tests: a chain of blocks on
+// top of a genesis, each block with any number of transactions and
+// withdrawals. In contrast to a statetest, the block context and the state
+// is carried over between the transactions and blocks.
+type BtMaker struct {
+	fork   string
+	pre    *GenesisAlloc
+	blocks []*BtBlock
+	info   *stInfo
+
+	filled *btJSON // the filled test, nil until filled
+}
+
+// NewBtMaker returns a maker of blockchain tests for the fork, where the
+// sender is funded. The system contracts of the fork are deployed in the
+// genesis.
+func NewBtMaker(fork string) *BtMaker {
+	alloc := make(GenesisAlloc)
+	bt := &BtMaker{
+		fork: fork,
+		pre:  &alloc,
+	}
+	bt.AddAccount(sender, GenesisAccount{
+		Balance: new(big.Int).Lsh(big.NewInt(1), 100),
+		Storage: make(map[common.Hash]common.Hash),
+	})
+	for addr, a := range systemContracts(fork) {
+		bt.AddAccount(addr, a)
+	}
+	return bt
+}
+
+// systemContracts returns the system contracts of the fork, as deployed on
+// mainnet: the block context they maintain is updated by the clients at the
+// start and end of each block.
+func systemContracts(fork string) GenesisAlloc {
+	alloc := make(GenesisAlloc)
+	for _, sc := range []struct {
+		fork string
+		addr common.Address
+		code []byte
+	}{
+		{"Cancun", params.BeaconRootsAddress, params.BeaconRootsCode},
+		{"Prague", params.HistoryStorageAddress, params.HistoryStorageCode},
+		{"Prague", params.WithdrawalQueueAddress, params.WithdrawalQueueCode},
+		{"Prague", params.ConsolidationQueueAddress, params.ConsolidationQueueCode},
+	} {
+		if isForkOrLater(fork, sc.fork) {
+			alloc[sc.addr] = GenesisAccount{
+				Code:    sc.code,
+				Nonce:   1,
+				Balance: new(big.Int),
+				Storage: make(map[common.Hash]common.Hash),
+			}
+		}
+	}
+	return alloc
+}
+
+// isForkOrLater reports whether the rules of the fork include the other fork.
+func isForkOrLater(fork, other string) bool {
+	a, b := tests.Forks[fork], tests.Forks[other]
+	if a == nil || b == nil {
+		return false
+	}
+	switch other {
+	case "Shanghai":
+		return a.ShanghaiTime != nil
+	case "Cancun":
+		return a.CancunTime != nil
+	case "Prague":
+		return a.PragueTime != nil
+	}
+	return false
+}
+
+// Fork returns the fork of the test.
+func (bt *BtMaker) Fork() string {
+	return bt.fork
+}
+
+// AddAccount adds an account to the genesis.
+func (bt *BtMaker) AddAccount(address common.Address, a GenesisAccount) {
+	// See GstMaker.AddAccount
+	if DisallowEOF && len(a.Code) > 0 && a.Code[0] == 0xEF {
+		a.Code[0] = 0xEE
+	}
+	(*bt.pre)[address] = a
+	bt.filled = nil
+}
+
+// SetCode sets the code at the given address in the genesis (creating the
+// account if it did not previously exist).
+func (bt *BtMaker) SetCode(address common.Address, code []byte) {
+	account, exist := (*bt.pre)[address]
+	if !exist {
+		account = GenesisAccount{
+			Storage: make(map[common.Hash]common.Hash),
+			Balance: new(big.Int),
+		}
+	}
+	account.Code = code
+	bt.AddAccount(address, account)
+}
+
+// AddBlock appends an empty block to the chain, and returns it.
+func (bt *BtMaker) AddBlock() *BtBlock {
+	b := new(BtBlock)
+	bt.blocks = append(bt.blocks, b)
+	bt.filled = nil
+	return b
+}
+
+// Blocks returns the blocks of the chain.
+func (bt *BtMaker) Blocks() []*BtBlock {
+	return bt.blocks
+}
+
+// btChain serves the headers of the chain being built to the EVM (for
+// BLOCKHASH) and to the consensus engine.
+type btChain struct {
+	config  *params.ChainConfig
+	engine  consensus.Engine
+	headers []*types.Header // by number
+}
+
+func (c *btChain) Config() *params.ChainConfig { return c.config }
+func (c *btChain) Engine() consensus.Engine    { return c.engine }
+
+func (c *btChain) CurrentHeader() *types.Header {
+	return c.headers[len(c.headers)-1]
+}
+
+func (c *btChain) GetHeaderByNumber(number uint64) *types.Header {
+	if number >= uint64(len(c.headers)) {
+		return nil
+	}
+	return c.headers[number]
+}
+
+func (c *btChain) GetHeader(hash common.Hash, number uint64) *types.Header {
+	if h := c.GetHeaderByNumber(number); h != nil && h.Hash() == hash {
+		return h
+	}
+	return nil
+}
+
+func (c *btChain) GetHeaderByHash(hash common.Hash) *types.Header {
+	for _, h := range c.headers {
+		if h.Hash() == hash {
+			return h
+		}
+	}
+	return nil
+}
+
+// Fill uses go-ethereum internally to build the chain of blocks, and
+// determines the headers and the post-state of the test. The chain is
+// built on top of a post-merge genesis; pre-merge forks are not supported.
+func (bt *BtMaker) Fill() (err error) {
+	config, ok := tests.Forks[bt.fork]
+	if !ok {
+		return tests.UnsupportedForkError{Name: bt.fork}
+	}
+	if ttd := config.TerminalTotalDifficulty; ttd == nil || ttd.Sign() != 0 {
+		return fmt.Errorf("fork %v: blockchain tests need a post-merge fork", bt.fork)
+	}
+	if len(bt.blocks) == 0 {
+		return errors.New("no blocks")
+	}
+	key, err := crypto.ToECDSA(pKey)
+	if err != nil {
+		return err
+	}
+	// Don't let a panic in the block processing take down the fuzzer
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("failed to build chain: %v", r)
+		}
+	}()
+	alloc := make(types.GenesisAlloc)
+	for addr, a := range *bt.pre {
+		alloc[addr] = types.Account{
+			Code:    a.Code,
+			Storage: a.Storage,
+			Balance: a.Balance,
+			Nonce:   a.Nonce,
+		}
+	}
+	genesis := &core.Genesis{
+		Config:     config,
+		Alloc:      alloc,
+		GasLimit:   btGasLimit,
+		BaseFee:    big.NewInt(params.InitialBaseFee),
+		Difficulty: new(big.Int),
+	}
+	// The blocks are built directly on top of the state database, rather than
+	// through a core.BlockChain, which logs to the global logger.
+	tdb := triedb.NewDatabase(rawdb.NewMemoryDatabase(), triedb.HashDefaults)
+	defer tdb.Close()
+	sdb := state.NewDatabase(tdb, nil)
+	gblock := genesis.ToBlock()
+	if err := commitAlloc(sdb, alloc, gblock.Root()); err != nil {
+		return err
+	}
+	var (
+		chain  = &btChain{config: config, engine: beacon.New(ethash.NewFaker()), headers: []*types.Header{gblock.Header()}}
+		signer = types.LatestSigner(config)
+		blocks []*types.Block
+		wIndex uint64
+	)
+	for _, spec := range bt.blocks {
+		parent := chain.CurrentHeader()
+		header := &types.Header{
+			ParentHash: parent.Hash(),
+			Coinbase:   btCoinbase,
+			Difficulty: new(big.Int),
+			Number:     new(big.Int).Add(parent.Number, common.Big1),
+			GasLimit:   parent.GasLimit,
+			Time:       parent.Time + 12,
+			BaseFee:    eip1559.CalcBaseFee(config, parent),
+		}
+		if config.IsCancun(header.Number, header.Time) {
+			excess := eip4844.CalcExcessBlobGas(config, parent, header.Time)
+			header.ExcessBlobGas = &excess
+			header.BlobGasUsed = new(uint64)
+			header.ParentBeaconRoot = &spec.BeaconRoot
+		}
+		statedb, err := state.New(parent.Root, sdb)
+		if err != nil {
+			return err
+		}
+		evm := vm.NewEVM(core.NewEVMBlockContext(header, chain, nil), statedb, config, vm.Config{})
+		if header.ParentBeaconRoot != nil {
+			core.ProcessBeaconBlockRoot(*header.ParentBeaconRoot, evm)
+		}
+		if config.IsPrague(header.Number, header.Time) {
+			core.ProcessParentBlockHash(header.ParentHash, evm)
+		}
+		var (
+			gp       = core.NewGasPool(header.GasLimit)
+			txs      []*types.Transaction
+			receipts []*types.Receipt
+			logs     []*types.Log
+		)
+		for i, tx := range spec.Txs {
+			value := tx.Value
+			if value == nil {
+				value = new(big.Int)
+			}
+			tip := big.NewInt(1)
+			signed, err := types.SignNewTx(key, signer, &types.DynamicFeeTx{
+				ChainID:   config.ChainID,
+				Nonce:     statedb.GetNonce(sender),
+				GasTipCap: tip,
+				GasFeeCap: new(big.Int).Add(new(big.Int).Mul(header.BaseFee, big.NewInt(2)), tip),
+				Gas:       min(tx.GasLimit, gp.Gas()),
+				To:        tx.To,
+				Value:     value,
+				Data:      tx.Data,
+			})
+			if err != nil {
+				return err
+			}
+			statedb.SetTxContext(signed.Hash(), i)
+			receipt, err := core.ApplyTransaction(evm, gp, statedb, header, signed)
+			if err != nil {
+				return fmt.Errorf("block %d, tx %d: %w", header.Number, i, err)
+			}
+			txs = append(txs, signed)
+			receipts = append(receipts, receipt)
+			logs = append(logs, receipt.Logs...)
+		}
+		header.GasUsed = gp.Used()
+		if config.IsPrague(header.Number, header.Time) {
+			requests := [][]byte{}
+			if err := core.ParseDepositLogs(&requests, logs, config); err != nil {
+				return err
+			}
+			if err := core.ProcessWithdrawalQueue(&requests, evm); err != nil {
+				return err
+			}
+			if err := core.ProcessConsolidationQueue(&requests, evm); err != nil {
+				return err
+			}
+			reqHash := types.CalcRequestsHash(requests)
+			header.RequestsHash = &reqHash
+		}
+		body := &types.Body{Transactions: txs}
+		if config.IsShanghai(header.Number, header.Time) {
+			for _, w := range spec.Withdrawals {
+				body.Withdrawals = append(body.Withdrawals, &types.Withdrawal{
+					Index:     wIndex,
+					Validator: w.Validator,
+					Address:   w.Address,
+					Amount:    w.Amount,
+				})
+				wIndex++
+			}
+		}
+		block, err := chain.engine.FinalizeAndAssemble(context.Background(), chain, header, statedb, body, receipts)
+		if err != nil {
+			return err
+		}
+		root, err := statedb.Commit(header.Number.Uint64(), config.IsEIP158(header.Number), config.IsCancun(header.Number, header.Time))
+		if err != nil {
+			return err
+		}
+		if err := tdb.Commit(root, false); err != nil {
+			return err
+		}
+		chain.headers = append(chain.headers, block.Header())
+		blocks = append(blocks, block)
+	}
+	genesisRLP, err := rlp.EncodeToBytes(gblock)
+	if err != nil {
+		return err
+	}
+	filled := &btJSON{
+		Info:       bt.info,
+		Genesis:    newBtHeader(gblock.Header()),
+		GenesisRLP: genesisRLP,
+		Pre:        *bt.pre,
+		Network:    bt.fork,
+		SealEngine: "NoProof",
+	}
+	for _, block := range blocks {
+		enc, err := rlp.EncodeToBytes(block)
+		if err != nil {
+			return err
+		}
+		filled.Blocks = append(filled.Blocks, btBlock{
+			Header:       newBtHeader(block.Header()),
+			Rlp:          enc,
+			UncleHeaders: []*btHeader{},
+			Withdrawals:  block.Withdrawals(),
+		})
+	}
+	last := blocks[len(blocks)-1]
+	filled.PostHash = last.Root()
+	filled.BestBlock = common.UnprefixedHash(last.Hash())
+	bt.filled = filled
+	return nil
+}
+
+// commitAlloc writes the genesis alloc to the state database.
+func commitAlloc(sdb state.Database, alloc types.GenesisAlloc, want common.Hash) error {
+	statedb, err := state.New(types.EmptyRootHash, sdb)
+	if err != nil {
+		return err
+	}
+	for addr, a := range alloc {
+		if a.Balance != nil {
+			statedb.SetBalance(addr, uint256.MustFromBig(a.Balance), tracing.BalanceIncreaseGenesisBalance)
+		}
+		statedb.SetCode(addr, a.Code, tracing.CodeChangeGenesis)
+		statedb.SetNonce(addr, a.Nonce, tracing.NonceChangeGenesis)
+		for k, v := range a.Storage {
+			statedb.SetState(addr, k, v)
+		}
+	}
+	root, err := statedb.Commit(0, false, false)
+	if err != nil {
+		return err
+	}
+	if root != want {
+		return fmt.Errorf("genesis root mismatch: have %x, want %x", root, want)
+	}
+	return sdb.TrieDB().Commit(root, false)
+}
+
+// ToBlockTest returns the filled test, by the given name.
+func (bt *BtMaker) ToBlockTest(name string) (*BlockTest, error) {
+	if bt.filled == nil {
+		if err := bt.Fill(); err != nil {
+			return nil, err
+		}
+	}
+	test := BlockTest{name: bt.filled}
+	return &test, nil
+}
+
+// ToGethBlockTest returns the filled test, as a go-ethereum blockchain test.
+func (bt *BtMaker) ToGethBlockTest() (*tests.BlockTest, error) {
+	test, err := bt.ToBlockTest("test")
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal((*test)["test"])
+	if err != nil {
+		return nil, err
+	}
+	var gethTest tests.BlockTest
+	if err := json.Unmarshal(data, &gethTest); err != nil {
+		return nil, err
+	}
+	return &gethTest, nil
+}
+
+// FromBlockTest reads a file of blockchain tests.
+func FromBlockTest(name string) (*BlockTest, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	bt := make(BlockTest)
+	err = json.Unmarshal(data, &bt)
+	return &bt, err
+}
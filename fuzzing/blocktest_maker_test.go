@@ -0,0 +1,67 @@
+// Copyright 2026 Martin Holst Swende
+// This file is part of the goevmlab library.
+//
+// The library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the goevmlab library. If not, see <http://www.gnu.org/licenses/>.
+
+package fuzzing
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/rawdb"
+)
+
+// TestBlockTests checks that the generated blockchain tests pass the
+// blocktest runner of go-ethereum.
+func TestBlockTests(t *testing.T) {
+	for _, fork := range []string{"Shanghai", "Cancun", "Prague", "Osaka"} {
+		for _, name := range BlockFactoryNames() {
+			factory := SeededBlockFactory(name, fork, 1)
+			for i := range 10 {
+				bt := factory()
+				test, err := bt.ToGethBlockTest()
+				if err != nil {
+					t.Fatalf("%v %v test %d: %v", fork, name, i, err)
+				}
+				if err := test.Run(false, rawdb.HashScheme, false, nil, nil); err != nil {
+					t.Fatalf("%v %v test %d: %v", fork, name, i, err)
+				}
+			}
+		}
+	}
+}
+
+// TestBlockTestSeeded checks that the blockchain tests are determined by the
+// engine and the seed.
+func TestBlockTestSeeded(t *testing.T) {
+	gen := func(seed int64) []byte {
+		test, err := GenerateBlockTest("blocks", "Prague", seed).ToBlockTest("test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := json.Marshal(test)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+	if a, b := gen(3), gen(3); !bytes.Equal(a, b) {
+		t.Fatal("same seed produced different tests")
+	}
+	if a, b := gen(3), gen(4); bytes.Equal(a, b) {
+		t.Fatal("different seeds produced the same test")
+	}
+}
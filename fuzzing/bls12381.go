@@ -32,7 +32,7 @@ var modulo, _ = big.NewInt(0).SetString("1a0111ea397fe69a4b1ba7b6434bacd764774b8
 
 type blsPrec struct {
 	addr    int
-	newData func() []byte
+	newData func(*rand.Rand) []byte
 	outsize int
 }
 
@@ -46,10 +46,10 @@ var precompilesBLS = []blsPrec{
 	{0x11, newFP2toG2, 256}, // FP2 to G2
 }
 
-func fillBls(gst *GstMaker, fork string) {
+func fillBls(rng *rand.Rand, gst *GstMaker, fork string) {
 	// Add a contract which calls BLS
 	dest := common.HexToAddress("0x00ca110b15012381")
-	code := RandCallBLS()
+	code := RandCallBLS(rng)
 	gst.AddAccount(dest, GenesisAccount{
 		Code:    code,
 		Balance: big.NewInt(10_000_000),
@@ -60,8 +60,8 @@ func fillBls(gst *GstMaker, fork string) {
 		// 8M gaslimit
 		GasLimit:   []uint64{8000000},
 		Nonce:      0,
-		Value:      []string{randHex(4)},
-		Data:       []string{randHex(100)},
+		Value:      []string{randHex(rng, 4)},
+		Data:       []string{randHex(rng, 100)},
 		GasPrice:   big.NewInt(0x10),
 		To:         dest.Hex(),
 		Sender:     sender,
@@ -70,22 +70,22 @@ func fillBls(gst *GstMaker, fork string) {
 }
 
 // mutate does some bit-twiddling.
-func mutate(data []byte) {
+func mutate(rng *rand.Rand, data []byte) {
 	if len(data) == 0 {
 		return
 	}
-	for rand.Intn(2) == 0 {
-		bit := rand.Intn(len(data) * 8) // // 13
+	for rng.Intn(2) == 0 {
+		bit := rng.Intn(len(data) * 8) // // 13
 		data[bit/8] = data[bit/8] ^ (1 << (bit % 8))
 	}
 }
 
-func RandCallBLS() []byte {
+func RandCallBLS(rng *rand.Rand) []byte {
 	p := program.New()
 	offset := 0
 	for _, precompile := range precompilesBLS {
-		data := precompile.newData()
-		mutate(data) // don't always use valid data
+		data := precompile.newData(rng)
+		mutate(rng, data) // don't always use valid data
 		p.Mstore(data, 0)
 		memInFn := func() (offset, size any) {
 			offset, size = 0, len(data)
@@ -99,7 +99,7 @@ func RandCallBLS() []byte {
 		addrGen := func() any {
 			return precompile.addr
 		}
-		p2 := RandCall(GasRandomizer(), addrGen, ValueRandomizer(), memInFn, memOutFn)
+		p2 := RandCall(rng, GasRandomizer(rng), addrGen, ValueRandomizer(rng), memInFn, memOutFn)
 		p.Append(p2)
 		// pop the ret value
 		p.Op(vm.POP)
@@ -110,51 +110,51 @@ func RandCallBLS() []byte {
 	return p.Bytes()
 }
 
-func newG1Add() []byte {
-	a := makeBadG1()
-	b := makeBadG1()
+func newG1Add(rng *rand.Rand) []byte {
+	a := makeBadG1(rng)
+	b := makeBadG1(rng)
 	return append(a, b...)
 }
 
-func newG1MSM() []byte {
-	k := 1 + randInt64()
+func newG1MSM(rng *rand.Rand) []byte {
+	k := 1 + randInt64(rng)
 	var res []byte
 	for i := 0; i < int(k); i++ {
-		a := makeBadG1()
+		a := makeBadG1(rng)
 		res = append(res, a...)
 		mul := make([]byte, 32)
-		_, _ = crand.Read(mul)
+		_, _ = rng.Read(mul)
 		res = append(res, mul...)
 	}
 	return res
 }
 
-func newG2Add() []byte {
-	a := makeBadG2()
-	b := makeBadG2()
+func newG2Add(rng *rand.Rand) []byte {
+	a := makeBadG2(rng)
+	b := makeBadG2(rng)
 	return append(a, b...)
 }
 
-func newG2MSM() []byte {
-	k := 1 + randInt64()
+func newG2MSM(rng *rand.Rand) []byte {
+	k := 1 + randInt64(rng)
 	var res []byte
 	for i := 0; i < int(k); i++ {
-		a := makeBadG2()
+		a := makeBadG2(rng)
 		res = append(res, a...)
 		mul := make([]byte, 32)
-		_, _ = crand.Read(mul)
+		_, _ = rng.Read(mul)
 		res = append(res, mul...)
 	}
 	return res
 }
 
-func newFPtoG1() []byte {
-	return newFieldElement()
+func newFPtoG1(rng *rand.Rand) []byte {
+	return newFieldElement(rng)
 }
 
-func newFP2toG2() []byte {
-	a := newFieldElement()
-	b := newFieldElement()
+func newFP2toG2(rng *rand.Rand) []byte {
+	a := newFieldElement(rng)
+	b := newFieldElement(rng)
 	return append(a, b...)
 }
 
@@ -162,16 +162,16 @@ func newFP2toG2() []byte {
 // With 3% probability it outputs 0
 // With 92% probability it outputs a number [0..30)
 // With 5% probability it outputs a number [0..150)
-func randInt64() int64 {
-	b := rand.Int31n(100)
+func randInt64(rng *rand.Rand) int64 {
+	b := rng.Int31n(100)
 	// Zero or not?
 	if b < 3 {
 		return 0
 	}
 	if b < 95 {
-		return rand.Int63n(30)
+		return rng.Int63n(30)
 	}
-	return rand.Int63n(150)
+	return rng.Int63n(150)
 }
 
 // newPairing creates a new valid pairing.
@@ -180,27 +180,27 @@ func randInt64() int64 {
 // with s = sum(x: 1 -> n: (aMulx * bMulx))
 //
 // https://github.com/ethereum/EIPs/blob/master/EIPS/eip-2537.md#abi-for-pairing-check
-func newPairing() []byte {
+func newPairing(rng *rand.Rand) []byte {
 	_, _, _, genG2 := gnark.Generators()
-	pairs := 1 + randInt64()
+	pairs := 1 + randInt64(rng)
 	var res []byte
 	target := new(big.Int)
 	// LHS: sum(x: 1->n: e(aMulx * G1, bMulx * G2))
 	for k := 0; k < int(pairs); k++ {
-		aMul := randScalar()
+		aMul := randScalar(rng)
 		g1 := new(gnark.G1Affine).ScalarMultiplicationBase(aMul)
 
-		bMul := randScalar()
+		bMul := randScalar(rng)
 		g2 := new(gnark.G2Affine).ScalarMultiplication(&genG2, bMul)
 
-		if rand.Intn(10) == 0 {
-			data := makeBadG1()
+		if rng.Intn(10) == 0 {
+			data := makeBadG1(rng)
 			res = append(res, data...)
 		} else {
 			res = append(res, encodePointG1(g1)...)
 		}
-		if rand.Intn(10) == 0 {
-			data := makeBadG2()
+		if rng.Intn(10) == 0 {
+			data := makeBadG2(rng)
 			res = append(res, data...)
 		} else {
 			res = append(res, encodePointG2(g2)...)
@@ -217,8 +217,8 @@ func newPairing() []byte {
 	return res
 }
 
-func randScalar() *big.Int {
-	switch rand.Intn(50) {
+func randScalar(rng *rand.Rand) *big.Int {
+	switch rng.Intn(50) {
 	case 0: // zero
 		return new(big.Int)
 	case 1: // at modulo
@@ -229,12 +229,12 @@ func randScalar() *big.Int {
 		return ret
 	case 3: // no holds barred
 		v := make([]byte, 256)
-		_, _ = crand.Read(v)
+		_, _ = rng.Read(v)
 		ret := new(big.Int)
 		ret.SetBytes(v)
 		return ret
 	default:
-		ret, err := crand.Int(crand.Reader, modulo)
+		ret, err := crand.Int(rng, modulo)
 		if err != nil {
 			panic(err)
 		}
@@ -242,8 +242,8 @@ func randScalar() *big.Int {
 	}
 }
 
-func newFieldElement() []byte {
-	bytes := randScalar().Bytes()
+func newFieldElement(rng *rand.Rand) []byte {
+	bytes := randScalar(rng).Bytes()
 	buf := make([]byte, 64)
 	if len(bytes) > 48 {
 		copy(buf[16:], bytes)
@@ -254,9 +254,9 @@ func newFieldElement() []byte {
 }
 
 // newG1Point generates a random G1 and returns it as a 128-byte slice.
-func newG1Point() []byte {
+func newG1Point(rng *rand.Rand) []byte {
 	// sample a random scalar
-	s := randScalar()
+	s := randScalar(rng)
 	// compute a random point
 	cp := new(gnark.G1Affine)
 	_, _, g1Gen, _ := gnark.Generators()
@@ -264,12 +264,12 @@ func newG1Point() []byte {
 	return encodePointG1(cp)
 }
 
-func makeBadG1() []byte {
+func makeBadG1(rng *rand.Rand) []byte {
 	var retval []byte
-	if c := rand.Intn(10); c == 0 {
+	if c := rng.Intn(10); c == 0 {
 		// Produces crappy G1s which are (usually not) on curve
 		retval = make([]byte, 128)
-		_, _ = crand.Read(retval)
+		_, _ = rng.Read(retval)
 		//zero out x and y top portions
 		for i := range 16 {
 			retval[i] = 0
@@ -280,13 +280,12 @@ func makeBadG1() []byte {
 		retval[64+16] &= 0x1f
 	} else if c == 1 || c == 2 {
 		//  Wrong subgroup
-		f, _ := new(fp.Element).SetRandom()
-		g1Jac := gnark.GeneratePointNotInG1(*f)
+		g1Jac := gnark.GeneratePointNotInG1(randFp(rng))
 		g1aff := new(gnark.G1Affine).FromJacobian(&g1Jac)
 		retval = encodePointG1(g1aff)
 	} else if c == 3 || c == 4 {
 		// Passes subgroup check, but wrong curve
-		t := generatePointOnTwistedCurve()
+		t := generatePointOnTwistedCurve(rng)
 		g1aff := &gnark.G1Affine{
 			X: t.x,
 			Y: t.y,
@@ -294,23 +293,23 @@ func makeBadG1() []byte {
 		retval = encodePointG1(g1aff)
 	} else { // 5-10
 		// Produce a mostly correct G1
-		aMul := randScalar()
+		aMul := randScalar(rng)
 		g1 := new(gnark.G1Affine).ScalarMultiplicationBase(aMul)
 		retval = encodePointG1(g1)
 	}
 	// Potentially mutate it a bit
-	if rand.Intn(10) == 0 {
-		retval[rand.Intn(len(retval))] = byte(rand.Int())
+	if rng.Intn(10) == 0 {
+		retval[rng.Intn(len(retval))] = byte(rng.Int())
 	}
 	return retval
 }
 
-func makeBadG2() []byte {
+func makeBadG2(rng *rand.Rand) []byte {
 	var retval []byte
-	if c := rand.Intn(10); c == 0 {
+	if c := rng.Intn(10); c == 0 {
 		// Produces crappy G2s which are (usually not) on curve
 		retval = make([]byte, 256)
-		_, _ = crand.Read(retval)
+		_, _ = rng.Read(retval)
 		//zero out x and y top portions
 		for i := range 16 {
 			retval[i] = 0
@@ -325,20 +324,18 @@ func makeBadG2() []byte {
 		retval[192+16] &= 0x1f
 	} else if c == 1 || c == 2 {
 		//  Wrong subgroup
-		f1, _ := new(fp.Element).SetRandom()
-		f2, _ := new(fp.Element).SetRandom()
-		g2Jac := gnark.GeneratePointNotInG2(gnark.E2{A0: *f1, A1: *f2})
+		g2Jac := gnark.GeneratePointNotInG2(gnark.E2{A0: randFp(rng), A1: randFp(rng)})
 		g2aff := new(gnark.G2Affine).FromJacobian(&g2Jac)
 		retval = encodePointG2(g2aff)
 	} else {
 		// Produce a mostly correct G1
-		aMul := randScalar()
+		aMul := randScalar(rng)
 		g2 := new(gnark.G2Affine).ScalarMultiplicationBase(aMul)
 		retval = encodePointG2(g2)
 	}
 	// Potentially mutate it a bit
-	if rand.Intn(10) == 0 {
-		retval[rand.Intn(len(retval))] = byte(rand.Int())
+	if rng.Intn(10) == 0 {
+		retval[rng.Intn(len(retval))] = byte(rng.Int())
 	}
 	return retval
 }
@@ -352,8 +349,8 @@ func encodePointG1(p *gnark.G1Affine) []byte {
 }
 
 // newG2Point generates a random G2 and returns it as a 256-byte byte slice.
-func newG2Point() []byte {
-	s := randScalar()
+func newG2Point(rng *rand.Rand) []byte {
+	s := randScalar(rng)
 	_, _, _, g2gen := gnark.Generators()
 	cp := new(gnark.G2Affine)
 	cp.ScalarMultiplication(&g2gen, s)
@@ -18,7 +18,7 @@ package fuzzing
 
 import (
 	"math/big"
-	"math/rand/v2"
+	"math/rand"
 
 	"github.com/consensys/gnark-crypto/ecc/bls12-381/fp"
 )
@@ -27,7 +27,7 @@ import (
 
 // specialElement returns an fp.Element which is possibly invalid,
 // covering some edgecases
-func specialElement() fp.Element {
+func specialElement(rng *rand.Rand) fp.Element {
 	// Field modulus q
 	const (
 		q0 = 13402431016077863595
@@ -41,16 +41,16 @@ func specialElement() fp.Element {
 	el := fp.Element{
 		q0, q1, q2, q3, q4, q5,
 	}
-	switch rand.IntN(3) {
+	switch rng.Intn(3) {
 	case 4:
 		el.SetZero()
 	case 3:
 		el.SetOne()
 	case 2:
-		index := rand.IntN(6)
+		index := rng.Intn(6)
 		el[index] = el[index] - 1 // valid
 	case 1:
-		index := rand.IntN(6)
+		index := rng.Intn(6)
 		el[index] = el[index] + 1 // not valid
 	default:
 		// no-op, at modulus
@@ -59,14 +59,23 @@ func specialElement() fp.Element {
 }
 
 // randomElement returns a well-formed fp.Element in most cases
-func randomElement() fp.Element {
-	var x fp.Element
+func randomElement(rng *rand.Rand) fp.Element {
 	// In 9/10 cases, generate random but otherwise ok
-	if rand.IntN(10) > 0 {
-		_, _ = x.SetRandom()
-		return x
+	if rng.Intn(10) > 0 {
+		return randFp(rng)
 	}
-	return specialElement()
+	return specialElement(rng)
+}
+
+// randFp returns a random fp.Element.
+func randFp(rng *rand.Rand) fp.Element {
+	var (
+		b [fp.Bytes]byte
+		x fp.Element
+	)
+	_, _ = rng.Read(b[:])
+	x.SetBytes(b[:])
+	return x
 }
 
 // Point structure for the twisted curve
@@ -75,7 +84,7 @@ type twistedPoint struct {
 }
 
 // Generate a point on the twisted curve Et: y² = x³ + 24
-func generatePointOnTwistedCurve() twistedPoint {
+func generatePointOnTwistedCurve(rng *rand.Rand) twistedPoint {
 
 	// Define the cofactor
 	var h big.Int
@@ -87,7 +96,7 @@ func generatePointOnTwistedCurve() twistedPoint {
 	b.SetUint64(24)
 
 	for {
-		x := randomElement()
+		x := randomElement(rng)
 		// Calculate y² = x³ + 24
 		tmp.Square(&x)    // x²
 		tmp.Mul(&tmp, &x) // x³
@@ -19,6 +19,7 @@ package fuzzing
 
 import (
 	"math/big"
+	"math/rand"
 	"testing"
 
 	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
@@ -90,11 +91,12 @@ func isPointOnBLS12381(x, y fp.Element) bool {
 }
 
 func TestGenerateOffCurve(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
 	// Create a twisted curve (Et) with a = 0, b = 24 (instead of a = 0, b = 4 for E1)
 	// For the twisted curve Et, we create points manually
 
 	// Generate a point on the twisted curve (Et)
-	pt := generatePointOnTwistedCurve()
+	pt := generatePointOnTwistedCurve(rng)
 	t.Logf("Generated point on twisted curve (Et): {x: %s, y: %s} ", pt.x.String(), pt.y.String())
 	// Check if Pt satisfies the endomorphism check
 	if passesTwisted := checkEndomorphismTwisted(pt); !passesTwisted {
@@ -107,9 +109,10 @@ func TestGenerateOffCurve(t *testing.T) {
 }
 
 func BenchmarkGenerateOffCurve(b *testing.B) {
+	rng := rand.New(rand.NewSource(1))
 	for i := 0; i < b.N; i++ {
 		// Generate a point on the twisted curve (Et)
-		pt := generatePointOnTwistedCurve()
+		pt := generatePointOnTwistedCurve(rng)
 		// Check if Pt satisfies the endomorphism check
 		if passesTwisted := checkEndomorphismTwisted(pt); !passesTwisted {
 			b.Fatalf("Pt does not pass BLS12-381 endomorphism check")
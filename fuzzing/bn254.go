@@ -17,7 +17,6 @@
 package fuzzing
 
 import (
-	crand "crypto/rand"
 	"math/big"
 	"math/rand"
 
@@ -29,7 +28,7 @@ import (
 
 type prec struct {
 	addr    int
-	newData func() []byte
+	newData func(*rand.Rand) []byte
 	outsize int
 }
 
@@ -43,10 +42,10 @@ var precompilesBn254 = []prec{
 	{0x8, newBnPairing, 32},
 }
 
-func fillBn254(gst *GstMaker, fork string) {
+func fillBn254(rng *rand.Rand, gst *GstMaker, fork string) {
 	// Add a contract which calls the Bn precompiles
 	dest := common.HexToAddress("0x00ca110b15012381")
-	code := RandCallBn()
+	code := RandCallBn(rng)
 	gst.AddAccount(dest, GenesisAccount{
 		Code:    code,
 		Balance: big.NewInt(10_000_000),
@@ -57,8 +56,8 @@ func fillBn254(gst *GstMaker, fork string) {
 		// 8M gaslimit
 		GasLimit:   []uint64{16_000_000},
 		Nonce:      0,
-		Value:      []string{randHex(2)},
-		Data:       []string{randHex(2)},
+		Value:      []string{randHex(rng, 2)},
+		Data:       []string{randHex(rng, 2)},
 		GasPrice:   big.NewInt(0x10),
 		To:         dest.Hex(),
 		Sender:     sender,
@@ -66,12 +65,12 @@ func fillBn254(gst *GstMaker, fork string) {
 	})
 }
 
-func RandCallBn() []byte {
+func RandCallBn(rng *rand.Rand) []byte {
 	p := program.New()
 	offset := 0
 	for _, precompile := range precompilesBn254 {
-		data := precompile.newData()
-		mutate(data) // don't always use valid data
+		data := precompile.newData(rng)
+		mutate(rng, data) // don't always use valid data
 		p.Mstore(data, 0)
 		memInFn := func() (offset, size any) {
 			offset, size = 0, len(data)
@@ -85,7 +84,7 @@ func RandCallBn() []byte {
 		addrGen := func() any {
 			return precompile.addr
 		}
-		p2 := RandCall(GasRandomizer(), addrGen, ValueRandomizer(), memInFn, memOutFn)
+		p2 := RandCall(rng, GasRandomizer(rng), addrGen, ValueRandomizer(rng), memInFn, memOutFn)
 		p.Append(p2)
 		// pop the ret value
 		p.Op(vm.POP)
@@ -96,71 +95,71 @@ func RandCallBn() []byte {
 	return p.Bytes()
 }
 
-func newBnAdd() []byte {
+func newBnAdd(rng *rand.Rand) []byte {
 	// Takes two 64-byte points as inputs
-	a := makeBadBn254G1()
-	b := makeBadBn254G1()
+	a := makeBadBn254G1(rng)
+	b := makeBadBn254G1(rng)
 	return append(a, b...)
 }
 
-func newBnScalarMul() []byte {
+func newBnScalarMul(rng *rand.Rand) []byte {
 	// Takes one 64-byte point, and one 32-byte scalar as input
-	a := makeBadBn254G1()
+	a := makeBadBn254G1(rng)
 	b := make([]byte, 32)
-	_, _ = crand.Read(b)
+	_, _ = rng.Read(b)
 	return append(a, b...)
 }
 
-func newBnPairing() []byte {
+func newBnPairing(rng *rand.Rand) []byte {
 	// Input is multiple of 192 (bn256.G1: 64 byte, bn256.G2: 128 byte). Output is
 	// 32 bytes, boolean true or false.
-	k := 1 + randInt64()
+	k := 1 + randInt64(rng)
 	var res []byte
 	for i := 0; i < int(k); i++ {
-		a := makeBadBn254G1()
+		a := makeBadBn254G1(rng)
 		res = append(res, a...)
-		b := makeBadBn254G2()
+		b := makeBadBn254G2(rng)
 		res = append(res, b...)
 	}
 	return res
 }
 
-func makeBadBn254G1() []byte {
+func makeBadBn254G1(rng *rand.Rand) []byte {
 	var retval []byte
-	if c := rand.Intn(10); c == 0 {
+	if c := rng.Intn(10); c == 0 {
 		// Produces crappy G1s which are (usually not) on curve
 		retval = make([]byte, 64)
-		_, _ = crand.Read(retval)
+		_, _ = rng.Read(retval)
 	} else {
-		_, g1, err := bn2562.RandomG1(crand.Reader)
+		_, g1, err := bn2562.RandomG1(rng)
 		if err != nil {
 			panic(err)
 		}
 		retval = g1.Marshal()
 	}
 	// Potentially mutate it a bit
-	if rand.Intn(10) == 0 {
-		retval[rand.Intn(len(retval))] = byte(rand.Int())
+	if rng.Intn(10) == 0 {
+		retval[rng.Intn(len(retval))] = byte(rng.Int())
 	}
 	return retval
 }
 
-func makeBadBn254G2() []byte {
+func makeBadBn254G2(rng *rand.Rand) []byte {
 	var retval []byte
-	if c := rand.Intn(10); c == 0 {
+	if c := rng.Intn(10); c == 0 {
 		// Produces crappy G2s which are (usually not) on curve
 		retval = make([]byte, 128)
-		_, _ = crand.Read(retval)
+		_, _ = rng.Read(retval)
 	} else {
-		_, g2, err := bn2562.RandomG2(crand.Reader)
+		_, g2, err := bn2562.RandomG2(rng)
 		if err != nil {
 			panic(err)
 		}
 		retval = g2.Marshal()
 	}
 	// Potentially mutate it a bit
-	if rand.Intn(10) == 0 {
-		retval[rand.Intn(len(retval))] = byte(rand.Int())
+	if rng.Intn(10) == 0 {
+		retval[rng.Intn(len(retval))] = byte(rng.Int())
 	}
 	return retval
 }
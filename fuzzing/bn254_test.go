@@ -1,29 +1,33 @@
 package fuzzing
 
 import (
-	bn256 "github.com/ethereum/go-ethereum/crypto/bn256/cloudflare"
+	"math/rand"
 	"testing"
+
+	bn256 "github.com/ethereum/go-ethereum/crypto/bn256/cloudflare"
 )
 
 func TestBn254(t *testing.T) {
-	if have, want := len(newBnAdd()), 128; have != want {
+	rng := rand.New(rand.NewSource(1))
+	if have, want := len(newBnAdd(rng)), 128; have != want {
 		t.Errorf("Generated input wrong, have %d want %d", have, want)
 	}
-	if have, want := len(newBnScalarMul()), 96; have != want {
+	if have, want := len(newBnScalarMul(rng)), 96; have != want {
 		t.Errorf("Generated input wrong, have %d want %d", have, want)
 	}
 	// 160 * K
 	// k slices each of them being a byte concatenation of encoding of a
 	// G1 point (128 bytes) and encoding of a scalar value (32 bytes)
-	if have, multiple := len(newBnPairing()), 192; have%multiple != 0 {
+	if have, multiple := len(newBnPairing(rng)), 192; have%multiple != 0 {
 		t.Errorf("Generated input wrong, have %d want multiple of %d", have, multiple)
 	}
 }
 
 func TestBn254ErrorTypes(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
 	var ok = 0
 	for range 1000 {
-		err := decodeBn254G1(makeBadBn254G1())
+		err := decodeBn254G1(makeBadBn254G1(rng))
 		if err == nil {
 			ok++
 			//}else{
@@ -35,7 +39,7 @@ func TestBn254ErrorTypes(t *testing.T) {
 
 	ok = 0
 	for range 1000 {
-		err := decodeBn254G2(makeBadBn254G2())
+		err := decodeBn254G2(makeBadBn254G2(rng))
 		if err == nil {
 			ok++
 			//}else{
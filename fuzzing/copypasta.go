@@ -107,6 +107,7 @@ func (t *StateTest) UnmarshalJSON(in []byte) error {
 }
 
 type stJSON struct {
+	Info   *stInfo                  `json:"_info,omitempty"`
 	Env    stEnv                    `json:"env"`
 	Pre    GenesisAlloc             `json:"pre"`
 	Config stConfig                 `json:"config"`
@@ -115,6 +116,15 @@ type stJSON struct {
 	Post   map[string][]stPostState `json:"post"`
 }
 
+// stInfo describes how a test was generated. The same engine and seed always
+// produce the same test; mutated tests also refer to the corpus entry they
+// were mutated from.
+type stInfo struct {
+	Engine string `json:"engine"`
+	Seed   int64  `json:"seed"`
+	Parent string `json:"parent,omitempty"`
+}
+
 type stPostState struct {
 	Root    common.Hash `json:"hash"`
 	Logs    common.Hash `json:"logs"`
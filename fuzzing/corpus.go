@@ -0,0 +1,196 @@
+// Copyright 2026 Martin Holst Swende
+// This file is part of the goevmlab library.
+//
+// The library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the goevmlab library. If not, see <http://www.gnu.org/licenses/>.
+
+package fuzzing
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/bits"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/holiman/goevmlab/evms"
+)
+
+// CorpusEngine is the name of the coverage-guided engine.
+const CorpusEngine = "corpus"
+
+// corpusCoverageFile is the name of the file in the corpus directory, which
+// holds the coverage of the corpus.
+const corpusCoverageFile = "coverage.json"
+
+// Corpus is a coverage-guided corpus of tests, stored on disk. Tests which hit
+// new (opcode, depth, error, gas-bucket) tuples are added to the corpus, and
+// new tests are produced by mutating the entries.
+type Corpus struct {
+	dir  string
+	fork string
+	auth *authHelper
+
+	mu       sync.Mutex
+	entries  []string       // paths of the tests in the corpus
+	coverage *evms.Coverage // coverage of the tests in the corpus
+}
+
+// NewCorpus opens the corpus in the given directory, creating it if needed.
+// The tests produced are for the given fork.
+func NewCorpus(dir, fork string) (*Corpus, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	c := &Corpus{
+		dir:      dir,
+		fork:     fork,
+		auth:     newHelper(),
+		coverage: evms.NewCoverage(),
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		if filepath.Base(file) != corpusCoverageFile {
+			c.entries = append(c.entries, file)
+		}
+	}
+	sort.Strings(c.entries)
+	if data, err := os.ReadFile(filepath.Join(dir, corpusCoverageFile)); err == nil {
+		if err := json.Unmarshal(data, c.coverage); err != nil {
+			return nil, fmt.Errorf("corrupt corpus coverage: %w", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	log.Info("Opened corpus", "dir", dir, "entries", len(c.entries), "tuples", len(c.coverage.Ops))
+	return c, nil
+}
+
+// Len returns the number of tests in the corpus.
+func (c *Corpus) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// newFeatures returns the number of gas buckets and precompiles in cov which
+// the corpus does not yet cover.
+func (c *Corpus) newFeatures(cov *evms.Coverage) int {
+	var n int
+	for k, buckets := range cov.GasBuckets {
+		n += bits.OnesCount64(buckets &^ c.coverage.GasBuckets[k])
+	}
+	for addr := range cov.Precompiles {
+		if _, ok := c.coverage.Precompiles[addr]; !ok {
+			n++
+		}
+	}
+	return n
+}
+
+// Observe is to be called with the coverage of an executed test, while the
+// test file still exists. If the test produced new coverage, it is copied
+// into the corpus.
+func (c *Corpus) Observe(path string, cov *evms.Coverage) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := c.newFeatures(cov)
+	if n == 0 {
+		return
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Warn("Failed reading test for corpus", "path", path, "err", err)
+		return
+	}
+	entry := filepath.Join(c.dir, fmt.Sprintf("%x.json", crypto.Keccak256(data)[:8]))
+	if _, err := os.Stat(entry); err != nil {
+		if err := os.WriteFile(entry, data, 0644); err != nil {
+			log.Warn("Failed adding test to corpus", "path", entry, "err", err)
+			return
+		}
+		c.entries = append(c.entries, entry)
+	}
+	c.coverage.Merge(cov)
+	if err := c.saveCoverage(); err != nil {
+		log.Warn("Failed writing corpus coverage", "err", err)
+	}
+	log.Debug("Added test to corpus", "path", entry, "new", n, "size", len(c.entries))
+}
+
+// saveCoverage writes the coverage of the corpus to disk.
+func (c *Corpus) saveCoverage() error {
+	data, err := json.Marshal(c.coverage)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(c.dir, corpusCoverageFile)
+	if err := os.WriteFile(path+".tmp", data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// Generate produces a new test, by mutating a random entry of the corpus. As
+// long as the corpus is empty, the tests are produced by the other engines.
+// Each test is generated from a random seed, which is recorded in the test
+// along with the entry it was mutated from.
+func (c *Corpus) Generate() *GstMaker {
+	var (
+		seed = NewSeed()
+		rng  = rand.New(rand.NewSource(seed))
+	)
+	c.mu.Lock()
+	var entry string
+	if len(c.entries) > 0 {
+		entry = c.entries[rng.Intn(len(c.entries))]
+	}
+	c.mu.Unlock()
+	if entry == "" {
+		return c.seed(rng)
+	}
+	gst, err := FromGeneralStateTest(entry)
+	if err != nil || len(*gst) == 0 {
+		log.Warn("Failed loading corpus entry", "path", entry, "err", err)
+		return c.seed(rng)
+	}
+	var st *stJSON
+	for _, st = range *gst {
+		break
+	}
+	for range 1 + rng.Intn(4) {
+		c.mutate(rng, st)
+	}
+	g := NewGstMaker()
+	g.pre = &st.Pre
+	g.env = &st.Env
+	g.tx = st.Tx
+	g.info = &stInfo{Engine: CorpusEngine, Seed: seed, Parent: filepath.Base(entry)}
+	g.EnableFork(c.fork)
+	return g
+}
+
+// seed produces a test using a random one of the generational engines.
+func (c *Corpus) seed(rng *rand.Rand) *GstMaker {
+	names := FactoryNames()
+	sort.Strings(names)
+	return Generate(names[rng.Intn(len(names))], c.fork, rng.Int63())
+}
@@ -0,0 +1,84 @@
+// Copyright 2026 Martin Holst Swende
+// This file is part of the goevmlab library.
+//
+// The library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the goevmlab library. If not, see <http://www.gnu.org/licenses/>.
+
+package fuzzing
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/holiman/goevmlab/evms"
+)
+
+func TestCorpus(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "corpus")
+	corpus, err := NewCorpus(dir, "Prague")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Seed the corpus with a 7702-test, so all mutators apply
+	seed := Generate("auth", "Prague", 1)
+	data, err := json.Marshal(seed.ToGeneralStateTest("seed"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "seed.json")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	key := evms.CoverageKey{Op: "CALL", Depth: 1}
+	cov := evms.NewCoverage()
+	cov.Ops[key] = 1
+	cov.GasBuckets[key] = 1 << evms.GasBucket(1000)
+	corpus.Observe(path, cov)
+	if n := corpus.Len(); n != 1 {
+		t.Fatalf("wrong corpus size: %d", n)
+	}
+	// Known coverage is not added
+	corpus.Observe(path, cov)
+	if n := corpus.Len(); n != 1 {
+		t.Fatalf("wrong corpus size: %d", n)
+	}
+	for range 100 {
+		gst := corpus.Generate()
+		if _, err := json.Marshal(gst.ToGeneralStateTest("mutated")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The corpus survives a restart, including the coverage
+	corpus, err = NewCorpus(dir, "Prague")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := corpus.Len(); n != 1 {
+		t.Fatalf("wrong corpus size after reopening: %d", n)
+	}
+	corpus.Observe(path, cov)
+	if n := corpus.Len(); n != 1 {
+		t.Fatalf("wrong corpus size: %d", n)
+	}
+	// A new gas bucket counts as new coverage
+	cov.GasBuckets[key] = 1 << evms.GasBucket(1000000)
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		t.Fatal(err)
+	}
+	corpus.Observe(path, cov)
+	if n := corpus.Len(); n != 2 {
+		t.Fatalf("wrong corpus size: %d", n)
+	}
+}
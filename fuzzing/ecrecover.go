@@ -17,7 +17,6 @@
 package fuzzing
 
 import (
-	crand "crypto/rand"
 	"math/big"
 	"math/rand"
 
@@ -26,11 +25,11 @@ import (
 	"github.com/ethereum/go-ethereum/core/vm/program"
 )
 
-func fillEcRecover(gst *GstMaker, fork string) {
+func fillEcRecover(rng *rand.Rand, gst *GstMaker, fork string) {
 	// Add a contract which calls BLS
 	dest := common.HexToAddress("0x00ca11ec5ec04e5")
 	gst.AddAccount(dest, GenesisAccount{
-		Code:    randCallECRecover(),
+		Code:    randCallECRecover(rng),
 		Balance: big.NewInt(10_000_000),
 		Storage: make(map[common.Hash]common.Hash),
 	})
@@ -39,8 +38,8 @@ func fillEcRecover(gst *GstMaker, fork string) {
 		// 8M gaslimit
 		GasLimit:   []uint64{8000000},
 		Nonce:      0,
-		Value:      []string{randHex(4)},
-		Data:       []string{randHex(100)},
+		Value:      []string{randHex(rng, 4)},
+		Data:       []string{randHex(rng, 100)},
 		GasPrice:   big.NewInt(0x10),
 		To:         dest.Hex(),
 		Sender:     sender,
@@ -48,12 +47,12 @@ func fillEcRecover(gst *GstMaker, fork string) {
 	})
 }
 
-func randCallECRecover() []byte {
+func randCallECRecover(rng *rand.Rand) []byte {
 	p := program.New()
 	offset := 0
 	for range int32(100) {
 		data := make([]byte, 128)
-		_, _ = crand.Read(data)
+		_, _ = rng.Read(data)
 		p.Mstore(data, 0)
 		memInFn := func() (offset, size any) {
 			offset, size = 0, 128
@@ -68,12 +67,12 @@ func randCallECRecover() []byte {
 			return 1
 		}
 		gasRand := func() any {
-			return big.NewInt(rand.Int63n(100000))
+			return big.NewInt(rng.Int63n(100000))
 		}
 		oneOrZero := func() any {
-			return rand.Int() & 0x1
+			return rng.Int() & 0x1
 		}
-		p2 := RandCall(gasRand, addrGen, oneOrZero, memInFn, memOutFn)
+		p2 := RandCall(rng, gasRand, addrGen, oneOrZero, memInFn, memOutFn)
 		p.Append(p2)
 		// pop the ret value
 		p.Op(vm.POP)
@@ -18,12 +18,13 @@ package fuzzing
 
 import (
 	"math/big"
+	"math/rand"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 )
 
-func fillSstore(gst *GstMaker, fork string) {
+func fillSstore(rng *rand.Rand, gst *GstMaker, fork string) {
 	// The accounts which we want to be able to invoke
 	addrs := []common.Address{
 		common.HexToAddress("0xF1"),
@@ -66,9 +67,9 @@ func fillSstore(gst *GstMaker, fork string) {
 	}
 	for _, addr := range addrs {
 		gst.AddAccount(addr, GenesisAccount{
-			Code:    RandCall2200(allAddrs),
+			Code:    RandCall2200(rng, allAddrs),
 			Balance: new(big.Int),
-			Storage: RandStorage(15, 20),
+			Storage: RandStorage(rng, 15, 20),
 		})
 	}
 	// The transaction
@@ -77,8 +78,8 @@ func fillSstore(gst *GstMaker, fork string) {
 			// 8M gaslimit
 			GasLimit:   []uint64{8000000},
 			Nonce:      0,
-			Value:      []string{randHex(4)},
-			Data:       []string{randHex(100)},
+			Value:      []string{randHex(rng, 4)},
+			Data:       []string{randHex(rng, 100)},
 			GasPrice:   big.NewInt(0x10),
 			To:         addrs[0].Hex(),
 			Sender:     sender,
@@ -26,8 +26,8 @@ import (
 	program2 "github.com/holiman/goevmlab/program"
 )
 
-func oneOf(cases ...any) any {
-	return cases[rand.Intn(len(cases))]
+func oneOf(rng *rand.Rand, cases ...any) any {
+	return cases[rng.Intn(len(cases))]
 }
 
 func asBig(in string) *big.Int {
@@ -39,7 +39,7 @@ func asBig(in string) *big.Int {
 	return a
 }
 
-func GenerateCallFProgram(maxSections int) ([]byte, int) {
+func GenerateCallFProgram(rng *rand.Rand, maxSections int) ([]byte, int) {
 
 	// The section is comprised of a list of metadata where the metadata index in
 	// the type section corresponds to a code section index.
@@ -57,9 +57,9 @@ func GenerateCallFProgram(maxSections int) ([]byte, int) {
 	maxStack := 0
 	curStack := 0
 	//for {
-	switch oneOf(1, 2, 3, 4, 5, 6, 7) {
+	switch oneOf(rng, 1, 2, 3, 4, 5, 6, 7) {
 	case 1:
-		program2.CallF(p, uint16(rand.Intn(maxSections)))
+		program2.CallF(p, uint16(rng.Intn(maxSections)))
 		p.Op(vm.STOP)
 	case 2:
 		program2.RetF(p)
@@ -84,8 +84,8 @@ func GenerateCallFProgram(maxSections int) ([]byte, int) {
 	default:
 		//p.Push0()
 
-		len := rand.Intn(255)
-		p.Push(oneOf(
+		len := rng.Intn(255)
+		p.Push(oneOf(rng,
 			asBig("0xFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF"),
 			asBig("0x1000000000000000000000000000000000000000000000000000000000000000"),
 			big.NewInt(int64(len)),
@@ -93,7 +93,7 @@ func GenerateCallFProgram(maxSections int) ([]byte, int) {
 			big.NewInt(int64(len+1)),
 		))
 		dests := make([]uint16, len)
-		if len > 0 && rand.Intn(4) != 0 {
+		if len > 0 && rng.Intn(4) != 0 {
 			dests[len-1] = uint16(0x10000 - 2*len - 2)
 		}
 		program2.RJumpV(p, dests)
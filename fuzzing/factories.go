@@ -17,8 +17,16 @@
 // Package fuzzing contains various fuzzers and utilities for generating testcases.
 package fuzzing
 
-// fillers is a mapping of names to functions that can fill a statetest.
-var fillers = map[string]func(*GstMaker, string){
+import (
+	"math/rand"
+	"slices"
+	"sync/atomic"
+)
+
+// fillers is a mapping of names to functions that can fill a statetest. All
+// randomness is drawn from the given source, so a test is determined by the
+// seed of the source.
+var fillers = map[string]func(*rand.Rand, *GstMaker, string){
 	"ecrecover":    fillEcRecover,
 	"naive":        fillNaive,
 	"blake":        fillBlake,
@@ -35,22 +43,137 @@ var fillers = map[string]func(*GstMaker, string){
 	"kzg":          fillPointEvaluation4844,
 }
 
+// Generate generates a test using the named engine, or returns nil if there
+// is no such engine. The same engine and seed always produce the same test.
+// The engine and seed are recorded in the _info section of the test.
+func Generate(name, fork string, seed int64) *GstMaker {
+	filler, ok := fillers[name]
+	if !ok {
+		return nil
+	}
+	gst := BasicStateTest(fork)
+	filler(rand.New(rand.NewSource(seed)), gst, fork)
+	gst.info = &stInfo{Engine: name, Seed: seed}
+	return gst
+}
+
+// Factory returns a function which generates tests using the named engine,
+// each with a random seed.
 func Factory(name, fork string) func() *GstMaker {
-	if filler, ok := fillers[name]; ok {
-		return func() *GstMaker {
-			gst := BasicStateTest(fork)
-			filler(gst, fork)
-			return gst
-		}
+	if _, ok := fillers[name]; !ok {
+		return nil
+	}
+	return func() *GstMaker {
+		return Generate(name, fork, NewSeed())
+	}
+}
+
+// SeededGenerator generates the tests of an engine, where the n:th test
+// generated (counting from zero) has the seed seed+n. It is safe for
+// concurrent use.
+type SeededGenerator[T any] struct {
+	generate func(seed int64) T
+	seed     int64
+	n        atomic.Uint64
+}
+
+// Generate generates the next test.
+func (g *SeededGenerator[T]) Generate() T {
+	return g.generate(g.seed + int64(g.n.Add(1)-1))
+}
+
+// Resume continues the sequence of an earlier run, which generated n tests.
+// It must be called before any test is generated.
+func (g *SeededGenerator[T]) Resume(n uint64) {
+	g.n.Store(n)
+}
+
+// Generated returns the number of tests generated, including the ones of the
+// earlier run.
+func (g *SeededGenerator[T]) Generated() uint64 {
+	return g.n.Load()
+}
+
+// NewSeededGenerator returns a generator of tests using the named engine, or
+// nil if there is no such engine.
+func NewSeededGenerator(name, fork string, seed int64) *SeededGenerator[*GstMaker] {
+	if _, ok := fillers[name]; !ok {
+		return nil
+	}
+	return &SeededGenerator[*GstMaker]{
+		generate: func(seed int64) *GstMaker { return Generate(name, fork, seed) },
+		seed:     seed,
+	}
+}
+
+// SeededFactory returns a function which generates tests using the named
+// engine, where the n:th test generated (counting from zero) has the seed
+// seed+n. The function is safe for concurrent use.
+func SeededFactory(name, fork string, seed int64) func() *GstMaker {
+	if g := NewSeededGenerator(name, fork, seed); g != nil {
+		return g.Generate
 	}
 	return nil
 }
 
-// FactoryNames returns the names of the available factories
+// WithForks wraps the factory, so the tests it generates have a post-state for
+// each of the given forks, in that order, in addition to the fork they were
+// generated for.
+func WithForks(factory func() *GstMaker, forks []string) func() *GstMaker {
+	return func() *GstMaker {
+		gst := factory()
+		enabled := gst.forks
+		gst.forks = nil
+		for _, fork := range append(slices.Clone(forks), enabled...) {
+			gst.EnableFork(fork)
+		}
+		return gst
+	}
+}
+
+// WithVariants wraps the factory, so the transaction of each test it generates
+// has n additional variants of the calldata, gas limit or value. The variants
+// are derived from the seed of the test.
+func WithVariants(factory func() *GstMaker, n int) func() *GstMaker {
+	if n <= 0 {
+		return factory
+	}
+	return func() *GstMaker {
+		gst := factory()
+		seed := NewSeed()
+		if gst.info != nil {
+			seed = variantSeed(gst.info.Seed)
+		}
+		addVariants(rand.New(rand.NewSource(seed)), gst, n)
+		return gst
+	}
+}
+
+// variantSeed derives the seed of the variants from the seed of the test, so
+// the variants do not repeat the random values the filler drew.
+func variantSeed(seed int64) int64 {
+	// The splitmix64 finalizer
+	z := uint64(seed) + 0x9e3779b97f4a7c15
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return int64((z ^ (z >> 31)) >> 1)
+}
+
+// NewSeed returns a random, non-zero, seed.
+func NewSeed() int64 {
+	for {
+		if seed := rand.Int63(); seed != 0 {
+			return seed
+		}
+	}
+}
+
+// FactoryNames returns the names of the available factories, sorted
 func FactoryNames() []string {
 	var names []string
 	for k := range fillers {
 		names = append(names, k)
 	}
+	slices.Sort(names)
 	return names
 }
@@ -16,8 +16,21 @@
 package fuzzing
 
 import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"math/rand"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
 	"testing"
 	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/tests"
 )
 
 // TestGenerateStatetests is a sanity-check that the test-generators do not croak
@@ -33,3 +46,151 @@ func TestGenerateStatetests(t *testing.T) {
 		t.Logf("Engine %v took %v", name, time.Since(t0))
 	}
 }
+
+// TestSeededGeneration checks that the tests are determined by the engine and
+// the seed. The tests are also generated in a subprocess, since the tests
+// must not depend on the map ordering of the process.
+func TestSeededGeneration(t *testing.T) {
+	gen := func(name string, seed int64) []byte {
+		gst := Generate(name, "Osaka", seed)
+		if err := gst.Fill(nil, 0); err != nil {
+			t.Fatalf("%v: %v", name, err)
+		}
+		data, err := json.Marshal(gst.ToGeneralStateTest("test"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+	genAll := func() map[string][]byte {
+		tests := make(map[string][]byte)
+		for _, name := range FactoryNames() {
+			tests[name] = gen(name, 1337)
+		}
+		return tests
+	}
+	if file := os.Getenv("GOEVMLAB_SEEDED_OUTPUT"); file != "" {
+		data, _ := json.Marshal(genAll())
+		if err := os.WriteFile(file, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	file := filepath.Join(t.TempDir(), "tests.json")
+	cmd := exec.Command(os.Args[0], "-test.run=^TestSeededGeneration$")
+	cmd.Env = append(os.Environ(), "GOEVMLAB_SEEDED_OUTPUT="+file)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("subprocess failed: %v\n%s", err, out)
+	}
+	var other map[string][]byte
+	if data, err := os.ReadFile(file); err != nil {
+		t.Fatal(err)
+	} else if err := json.Unmarshal(data, &other); err != nil {
+		t.Fatal(err)
+	}
+	for name, a := range genAll() {
+		if !bytes.Equal(a, other[name]) {
+			t.Errorf("%v: same seed produced different tests in another process", name)
+		}
+		if !bytes.Contains(a, []byte(`"_info":{"engine":"`+name+`","seed":1337}`)) {
+			t.Errorf("%v: seed not recorded", name)
+		}
+		if bytes.Equal(a, gen(name, 1338)) {
+			t.Errorf("%v: other seed produced same test", name)
+		}
+	}
+	if names := FactoryNames(); !slices.IsSorted(names) {
+		t.Errorf("factory names not sorted: %v", names)
+	}
+	// The n:th test of a seeded factory has seed+n
+	factory := SeededFactory("naive", "Osaka", 100)
+	for i := range int64(3) {
+		if have := factory().info.Seed; have != 100+i {
+			t.Errorf("wrong seed, have %d want %d", have, 100+i)
+		}
+	}
+}
+
+// TestMultiFork checks that a test for several forks is filled for each fork.
+func TestMultiFork(t *testing.T) {
+	forks := []string{"Cancun", "Prague", "Osaka"}
+	gst := WithForks(SeededFactory("sstore_sload", "Osaka", 1), forks)()
+	if have := gst.Forks(); !slices.Equal(have, forks) {
+		t.Fatalf("wrong forks: %v", have)
+	}
+	if err := gst.Fill(nil, 0); err != nil {
+		t.Fatal(err)
+	}
+	test, err := gst.ToStateTest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	subtests := test.Subtests()
+	if len(subtests) != len(forks) {
+		t.Fatalf("wrong number of subtests: %d", len(subtests))
+	}
+	// The post-states are verified by the go-ethereum test runner
+	for _, st := range subtests {
+		if err := test.Run(st, vm.Config{}, false, rawdb.HashScheme, func(error, *tests.StateTestState) {}); err != nil {
+			t.Errorf("%v: %v", st.Fork, err)
+		}
+	}
+	if post := gst.ForFork("Prague").ToSubTest().Post; len(post) != 1 || len(post["Prague"]) != 1 {
+		t.Errorf("wrong post-state of single fork: %v", post)
+	}
+}
+
+// TestMultiIndex checks that a test with several data, gas and value variants
+// has a post-state for each combination, which is filled.
+func TestMultiIndex(t *testing.T) {
+	gst := WithForks(SeededFactory("sstore_sload", "Osaka", 1), []string{"Prague"})()
+	gst.AddData(nil)
+	gst.AddGasLimit(30_000)
+	gst.AddValue(new(big.Int))
+	if have := gst.Subtests(); have != 8 {
+		t.Fatalf("wrong number of subtests: %d", have)
+	}
+	if err := gst.Fill(nil, 0); err != nil {
+		t.Fatal(err)
+	}
+	test, err := gst.ToStateTest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	subtests := test.Subtests()
+	if len(subtests) != 16 {
+		t.Fatalf("wrong number of subtests: %d", len(subtests))
+	}
+	roots := make(map[common.Hash]bool)
+	for _, st := range subtests {
+		err := test.Run(st, vm.Config{}, false, rawdb.HashScheme, func(_ error, s *tests.StateTestState) {
+			roots[s.StateDB.IntermediateRoot(false)] = true
+		})
+		if err != nil {
+			t.Errorf("%v/%d: %v", st.Fork, st.Index, err)
+		}
+	}
+	if len(roots) < 2 {
+		t.Errorf("variants all have the same post-state")
+	}
+	post := gst.ToSubTest().Post["Osaka"]
+	if have, want := post[5].Indexes, (stIndex{Data: 1, Gas: 0, Value: 1}); have != want {
+		t.Errorf("wrong indexes: have %v want %v", have, want)
+	}
+	// Variants are derived from the seed of the test
+	gen := func() *stJSON {
+		return WithVariants(SeededFactory("naive", "Osaka", 1), 5)().ToSubTest()
+	}
+	a, _ := json.Marshal(gen())
+	b, _ := json.Marshal(gen())
+	if !bytes.Equal(a, b) {
+		t.Errorf("same seed produced different variants")
+	}
+	if have := len(gen().Post["Osaka"]); have < 2 {
+		t.Errorf("no variants added")
+	}
+	// ... but do not draw the same random values as the filler
+	if seed := int64(1); rand.New(rand.NewSource(variantSeed(seed))).Int63() == rand.New(rand.NewSource(seed)).Int63() {
+		t.Errorf("variants use the seed of the filler")
+	}
+}
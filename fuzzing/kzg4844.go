@@ -19,6 +19,7 @@ package fuzzing
 import (
 	"crypto/sha256"
 	"math/big"
+	"math/rand"
 
 	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
 	gokzg4844 "github.com/crate-crypto/go-kzg-4844"
@@ -28,10 +29,10 @@ import (
 	"github.com/ethereum/go-ethereum/crypto/kzg4844"
 )
 
-func fillPointEvaluation4844(gst *GstMaker, fork string) {
+func fillPointEvaluation4844(rng *rand.Rand, gst *GstMaker, fork string) {
 	// Add a contract which calls the Bn precompiles
 	dest := common.HexToAddress("0x00ca11004844")
-	code := RandCallPointEval()
+	code := RandCallPointEval(rng)
 	gst.AddAccount(dest, GenesisAccount{
 		Code:    code,
 		Balance: big.NewInt(10_000_000),
@@ -42,8 +43,8 @@ func fillPointEvaluation4844(gst *GstMaker, fork string) {
 		// 8M gaslimit
 		GasLimit:   []uint64{16_000_000},
 		Nonce:      0,
-		Value:      []string{randHex(2)},
-		Data:       []string{randHex(2)},
+		Value:      []string{randHex(rng, 2)},
+		Data:       []string{randHex(rng, 2)},
 		GasPrice:   big.NewInt(0x10),
 		To:         dest.Hex(),
 		Sender:     sender,
@@ -51,10 +52,10 @@ func fillPointEvaluation4844(gst *GstMaker, fork string) {
 	})
 }
 
-func RandCallPointEval() []byte {
+func RandCallPointEval(rng *rand.Rand) []byte {
 	p := program.New()
-	data := makeData()
-	mutate(data) // don't always use valid data
+	data := makeData(rng)
+	mutate(rng, data) // don't always use valid data
 	p.Mstore(data, 0)
 	memInFn := func() (offset, size any) {
 		offset, size = 0, len(data)
@@ -66,7 +67,7 @@ func RandCallPointEval() []byte {
 		return
 	}
 	addrGen := func() any { return []byte{0x0a} }
-	p2 := RandCall(GasRandomizer(), addrGen, ValueRandomizer(), memInFn, memOutFn)
+	p2 := RandCall(rng, GasRandomizer(rng), addrGen, ValueRandomizer(rng), memInFn, memOutFn)
 	p.Append(p2)
 	// pop the ret value
 	p.Op(vm.POP)
@@ -87,9 +88,9 @@ func RandCallPointEval() []byte {
 //	y = input[64:96] (claim)
 //	commitment = input[96:144]
 //	proof = input[144:192]
-func makeData() []byte {
+func makeData(rng *rand.Rand) []byte {
 
-	blob := randBlob()
+	blob := randBlob(rng)
 	b2 := (*kzg4844.Blob)(blob)
 
 	commitment, err := kzg4844.BlobToCommitment(b2)
@@ -98,7 +99,7 @@ func makeData() []byte {
 	}
 	var (
 		versionedHash   = kZGToVersionedHash(commitment)
-		point           = randFieldElement()
+		point           = randFieldElement(rng)
 		proof, claim, _ = kzg4844.ComputeProof(b2, kzg4844.Point(point))
 	)
 	var data []byte
@@ -118,16 +119,20 @@ func kZGToVersionedHash(kzg kzg4844.Commitment) common.Hash {
 	return h
 }
 
-func randFieldElement() gokzg4844.Scalar {
-	var r fr.Element
-	_, _ = r.SetRandom()
+func randFieldElement(rng *rand.Rand) gokzg4844.Scalar {
+	var (
+		b [fr.Bytes]byte
+		r fr.Element
+	)
+	_, _ = rng.Read(b[:])
+	r.SetBytes(b[:])
 	return gokzg4844.SerializeScalar(r)
 }
 
-func randBlob() *gokzg4844.Blob {
+func randBlob(rng *rand.Rand) *gokzg4844.Blob {
 	var blob gokzg4844.Blob
 	for i := 0; i < len(blob); i += gokzg4844.SerializedScalarSize {
-		fieldElementBytes := randFieldElement()
+		fieldElementBytes := randFieldElement(rng)
 		copy(blob[i:i+gokzg4844.SerializedScalarSize], fieldElementBytes[:])
 	}
 	return &blob
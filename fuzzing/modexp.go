@@ -17,7 +17,6 @@
 package fuzzing
 
 import (
-	crand "crypto/rand"
 	"math/big"
 	"math/rand"
 
@@ -27,11 +26,11 @@ import (
 	"github.com/holiman/uint256"
 )
 
-func fillModexp(gst *GstMaker, fork string) {
+func fillModexp(rng *rand.Rand, gst *GstMaker, fork string) {
 	// Add a contract which calls BLS
 	dest := common.HexToAddress("0x00ca1130de4f")
 	gst.AddAccount(dest, GenesisAccount{
-		Code:    randCallModexp(),
+		Code:    randCallModexp(rng),
 		Balance: big.NewInt(10_000_000),
 		Storage: make(map[common.Hash]common.Hash),
 	})
@@ -39,8 +38,8 @@ func fillModexp(gst *GstMaker, fork string) {
 	gst.SetTx(&StTransaction{
 		GasLimit:   []uint64{16_000_000},
 		Nonce:      0,
-		Value:      []string{randHex(4)},
-		Data:       []string{randHex(100)},
+		Value:      []string{randHex(rng, 4)},
+		Data:       []string{randHex(rng, 100)},
 		GasPrice:   big.NewInt(0x10),
 		To:         dest.Hex(),
 		Sender:     sender,
@@ -48,30 +47,30 @@ func fillModexp(gst *GstMaker, fork string) {
 	})
 }
 
-func randModexpInt() *big.Int {
+func randModexpInt(rng *rand.Rand) *big.Int {
 	b := make([]byte, 4)
-	_, _ = crand.Read(b)
+	_, _ = rng.Read(b)
 	// 32/256 chance of zero
 	if b[0] < 32 {
 		return big.NewInt(0)
 	}
 	// 128/256 chance of a uint64
 	if b[1] < 128 {
-		return big.NewInt(0).SetUint64(rand.Uint64())
+		return big.NewInt(0).SetUint64(rng.Uint64())
 	}
 	// Random size, up to 2048 in size
-	size := rand.Intn(2846)
+	size := rng.Intn(2846)
 	val := make([]byte, size)
-	_, _ = crand.Read(val)
+	_, _ = rng.Read(val)
 	return (new(big.Int)).SetBytes(val)
 }
 
-func randCallModexp() []byte {
+func randCallModexp(rng *rand.Rand) []byte {
 	p := program.New()
 
-	base := randModexpInt()
-	exp := randModexpInt()
-	mod := randModexpInt()
+	base := randModexpInt(rng)
+	exp := randModexpInt(rng)
+	mod := randModexpInt(rng)
 
 	// 32 bytes each for baselen, expLen and modlen
 	buf := make([]byte, 0)
@@ -85,7 +84,7 @@ func randCallModexp() []byte {
 	buf = append(buf, mod.Bytes()...)
 
 	// Now mutate it randomly a bit
-	mutate(buf)
+	mutate(rng, buf)
 
 	p.Mstore(buf, 0)
 
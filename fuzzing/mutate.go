@@ -0,0 +1,313 @@
+// Copyright 2026 Martin Holst Swende
+// This file is part of the goevmlab library.
+//
+// The library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the goevmlab library. If not, see <http://www.gnu.org/licenses/>.
+
+package fuzzing
+
+import (
+	"bytes"
+	"math/big"
+	"math/rand"
+	"slices"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+)
+
+// interestingWords are values which are likely to hit edge-cases.
+var interestingWords = []common.Hash{
+	{},
+	common.HexToHash("0x01"),
+	common.HexToHash("0x20"),
+	common.HexToHash("0xff"),
+	common.HexToHash("0x7fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff"),
+	common.HexToHash("0x8000000000000000000000000000000000000000000000000000000000000000"),
+	common.HexToHash("0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff"),
+}
+
+// randWord returns a random word, biased towards interesting values.
+func randWord(rng *rand.Rand) common.Hash {
+	if rng.Intn(2) == 0 {
+		return interestingWords[rng.Intn(len(interestingWords))]
+	}
+	var h common.Hash
+	_, _ = rng.Read(h[32-1-rng.Intn(32):])
+	return h
+}
+
+// mutate applies a random mutation to the test.
+func (c *Corpus) mutate(rng *rand.Rand, st *stJSON) {
+	mutators := []func(*rand.Rand, *stJSON) bool{
+		mutateCode,
+		mutateStorage,
+		mutateTx,
+		c.mutateAuthList,
+	}
+	// Not all mutators apply to every test, e.g. if it has no auth list
+	for range 10 {
+		if mutators[rng.Intn(len(mutators))](rng, st) {
+			return
+		}
+	}
+}
+
+// sortedAddresses returns the addresses of the accounts for which the filter
+// returns true, in a stable order.
+func sortedAddresses(alloc GenesisAlloc, filter func(GenesisAccount) bool) []common.Address {
+	var addrs []common.Address
+	for addr, acc := range alloc {
+		if filter(acc) {
+			addrs = append(addrs, addr)
+		}
+	}
+	slices.SortFunc(addrs, func(a, b common.Address) int { return bytes.Compare(a[:], b[:]) })
+	return addrs
+}
+
+// mutateBytes applies a random byte-level mutation: flipping a bit, replacing,
+// inserting or removing bytes, or duplicating a chunk.
+func mutateBytes(rng *rand.Rand, data []byte, maxSize int) []byte {
+	data = slices.Clone(data)
+	if len(data) == 0 {
+		data = make([]byte, 1+rng.Intn(32))
+		_, _ = rng.Read(data)
+		return data
+	}
+	switch i := rng.Intn(len(data)); rng.Intn(5) {
+	case 0:
+		data[i] ^= 1 << rng.Intn(8)
+	case 1:
+		data[i] = byte(rng.Intn(256))
+	case 2:
+		data = slices.Insert(data, i, byte(rng.Intn(256)))
+	case 3:
+		data = slices.Delete(data, i, i+1+rng.Intn(len(data)-i))
+	case 4:
+		chunk := slices.Clone(data[i : i+1+rng.Intn(min(len(data)-i, 32))])
+		data = slices.Insert(data, rng.Intn(len(data)), chunk...)
+	}
+	if len(data) > maxSize {
+		data = data[:maxSize]
+	}
+	return data
+}
+
+// mutateCode mutates the code of an account, or replaces it with the code of
+// another account.
+func mutateCode(rng *rand.Rand, st *stJSON) bool {
+	addrs := sortedAddresses(st.Pre, func(acc GenesisAccount) bool { return len(acc.Code) > 0 })
+	if len(addrs) == 0 {
+		return false
+	}
+	var (
+		addr = addrs[rng.Intn(len(addrs))]
+		acc  = st.Pre[addr]
+	)
+	if rng.Intn(10) == 0 {
+		acc.Code = slices.Clone(st.Pre[addrs[rng.Intn(len(addrs))]].Code)
+	} else {
+		acc.Code = mutateBytes(rng, acc.Code, params.MaxCodeSize)
+	}
+	// See https://github.com/holiman/goevmlab/issues/127
+	if DisallowEOF && len(acc.Code) > 0 && acc.Code[0] == 0xEF {
+		acc.Code[0] = 0xEE
+	}
+	st.Pre[addr] = acc
+	return true
+}
+
+// mutateStorage sets or clears a storage slot of an account.
+func mutateStorage(rng *rand.Rand, st *stJSON) bool {
+	addrs := sortedAddresses(st.Pre, func(GenesisAccount) bool { return true })
+	if len(addrs) == 0 {
+		return false
+	}
+	var (
+		addr  = addrs[rng.Intn(len(addrs))]
+		acc   = st.Pre[addr]
+		slots []common.Hash
+	)
+	storage := make(map[common.Hash]common.Hash, len(acc.Storage))
+	for k, v := range acc.Storage {
+		storage[k] = v
+		slots = append(slots, k)
+	}
+	slices.SortFunc(slots, func(a, b common.Hash) int { return bytes.Compare(a[:], b[:]) })
+	slot := common.BigToHash(big.NewInt(int64(rng.Intn(16))))
+	if len(slots) > 0 && rng.Intn(2) == 0 {
+		slot = slots[rng.Intn(len(slots))]
+	}
+	if rng.Intn(4) == 0 {
+		delete(storage, slot)
+	} else {
+		storage[slot] = randWord(rng)
+	}
+	acc.Storage = storage
+	st.Pre[addr] = acc
+	return true
+}
+
+// mutateTx mutates the gas limit, value, calldata or destination of the
+// transaction. Of the gas limit, value and calldata, a random variant is
+// mutated.
+func mutateTx(rng *rand.Rand, st *stJSON) bool {
+	tx := &st.Tx
+	switch rng.Intn(4) {
+	case 0:
+		if len(tx.GasLimit) == 0 {
+			return false
+		}
+		i := rng.Intn(len(tx.GasLimit))
+		gas := tx.GasLimit[i]
+		switch rng.Intn(3) {
+		case 0:
+			gas *= 2
+		case 1:
+			gas /= 2
+		case 2:
+			gas = uint64(rng.Int63n(int64(min(max(st.Env.GasLimit, 1), 30_000_000))))
+		}
+		tx.GasLimit[i] = max(gas, params.TxGas)
+	case 1:
+		if len(tx.Value) == 0 {
+			return false
+		}
+		tx.Value[rng.Intn(len(tx.Value))] = hexutil.EncodeBig(new(big.Int).SetBytes(randWord(rng).Bytes()[24:]))
+	case 2:
+		if len(tx.Data) == 0 {
+			return false
+		}
+		i := rng.Intn(len(tx.Data))
+		data, err := hexutil.Decode(tx.Data[i])
+		if err != nil {
+			return false
+		}
+		tx.Data[i] = hexutil.Encode(mutateBytes(rng, data, params.MaxInitCodeSize))
+	case 3:
+		if tx.To == "" {
+			return false // leave creations as they are
+		}
+		addrs := sortedAddresses(st.Pre, func(GenesisAccount) bool { return true })
+		tx.To = addrs[rng.Intn(len(addrs))].Hex()
+	}
+	return true
+}
+
+// maxVariants is the maximum number of variants of the calldata, gas limit
+// and value, respectively, added to a transaction.
+const maxVariants = 4
+
+// addVariants adds n variants of the calldata, gas limit or value to the
+// transaction of the test, each of which yields additional subtests.
+func addVariants(rng *rand.Rand, gst *GstMaker, n int) {
+	tx := &gst.tx
+	for range n {
+		switch rng.Intn(3) {
+		case 0:
+			if len(tx.Data) == 0 || len(tx.Data) >= maxVariants {
+				continue
+			}
+			data, err := hexutil.Decode(tx.Data[0])
+			if err != nil {
+				continue
+			}
+			if rng.Intn(4) == 0 {
+				data = nil
+			} else {
+				data = mutateBytes(rng, data, params.MaxInitCodeSize)
+			}
+			gst.AddData(data)
+		case 1:
+			if len(tx.GasLimit) == 0 || len(tx.GasLimit) >= maxVariants {
+				continue
+			}
+			gas := tx.GasLimit[0]
+			if rng.Intn(2) == 0 {
+				gas /= 2
+			} else {
+				gas = uint64(rng.Int63n(int64(max(gas, 1))))
+			}
+			gst.AddGasLimit(max(gas, params.TxGas))
+		case 2:
+			if len(tx.Value) == 0 || len(tx.Value) >= maxVariants {
+				continue
+			}
+			value := new(big.Int)
+			if rng.Intn(2) == 0 {
+				value.SetBytes(randWord(rng).Bytes()[28:])
+			}
+			gst.AddValue(value)
+		}
+	}
+}
+
+// mutateAuthList drops, duplicates, reorders or modifies authorizations. The
+// modified authorizations are re-signed, if the key of the signer is known.
+func (c *Corpus) mutateAuthList(rng *rand.Rand, st *stJSON) bool {
+	list := st.Tx.AuthorizationList
+	if len(list) == 0 {
+		return false
+	}
+	i := rng.Intn(len(list))
+	switch rng.Intn(4) {
+	case 0:
+		st.Tx.AuthorizationList = CopyAndDropAuth(list, i)
+	case 1:
+		cpy := *list[i]
+		st.Tx.AuthorizationList = slices.Insert(slices.Clone(list), rng.Intn(len(list)+1), &cpy)
+	case 2:
+		cpy := slices.Clone(list)
+		j := rng.Intn(len(cpy))
+		cpy[i], cpy[j] = cpy[j], cpy[i]
+		st.Tx.AuthorizationList = cpy
+	case 3:
+		auth := *list[i]
+		if rng.Intn(2) == 0 {
+			addrs := sortedAddresses(st.Pre, func(GenesisAccount) bool { return true })
+			auth.Address = addrs[rng.Intn(len(addrs))]
+		} else {
+			auth.Nonce = uint64(int64(auth.Nonce) + int64(rng.Intn(3)) - 1)
+		}
+		c.resign(&auth)
+		cpy := slices.Clone(list)
+		cpy[i] = &auth
+		st.Tx.AuthorizationList = cpy
+	}
+	return true
+}
+
+// resign signs the authorization with the key of the signer, if it is known.
+// Otherwise the signature is left as is, and will recover to another signer.
+func (c *Corpus) resign(auth *stAuthorization) {
+	if auth.Signer == nil || auth.ChainID == nil {
+		return
+	}
+	key, ok := c.auth.keys[*auth.Signer]
+	if !ok {
+		return
+	}
+	signed, err := types.SignSetCode(key, types.SetCodeAuthorization{
+		ChainID: *uint256.MustFromBig(auth.ChainID),
+		Address: auth.Address,
+		Nonce:   auth.Nonce,
+	})
+	if err != nil {
+		return
+	}
+	auth.V, auth.R, auth.S = signed.V, signed.R.ToBig(), signed.S.ToBig()
+}
@@ -1,8 +1,8 @@
 package fuzzing
 
 import (
-	"crypto/rand"
 	"math/big"
+	"math/rand"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/vm"
@@ -10,7 +10,7 @@ import (
 	"github.com/holiman/goevmlab/ops"
 )
 
-func fillNaive(gst *GstMaker, fork string) {
+func fillNaive(rng *rand.Rand, gst *GstMaker, fork string) {
 	// The accounts which we want to be able to invoke
 	addrs := []common.Address{
 		common.HexToAddress("0xF1"),
@@ -22,9 +22,9 @@ func fillNaive(gst *GstMaker, fork string) {
 
 	for _, addr := range addrs {
 		gst.AddAccount(addr, GenesisAccount{
-			Code:    randomBytecode(forkDef),
+			Code:    randomBytecode(rng, forkDef),
 			Balance: new(big.Int),
-			Storage: RandStorage(15, 20),
+			Storage: RandStorage(rng, 15, 20),
 		})
 	}
 	// The transaction
@@ -32,8 +32,8 @@ func fillNaive(gst *GstMaker, fork string) {
 		// 8M gaslimit
 		GasLimit:   []uint64{8000000},
 		Nonce:      0,
-		Value:      []string{randHex(4)},
-		Data:       []string{randHex(100)},
+		Value:      []string{randHex(rng, 4)},
+		Data:       []string{randHex(rng, 100)},
 		GasPrice:   big.NewInt(0x10),
 		To:         addrs[0].Hex(),
 		Sender:     sender,
@@ -42,15 +42,15 @@ func fillNaive(gst *GstMaker, fork string) {
 }
 
 // randomBytecode returns a pretty simplistic bytecode, 1024 ops.
-func randomBytecode(f *ops.Fork) []byte {
+func randomBytecode(rng *rand.Rand, f *ops.Fork) []byte {
 	b := make([]byte, 1024)
-	_, _ = rand.Read(b)
+	_, _ = rng.Read(b)
 	i := 0
 	var next = func() byte {
 		x := b[i]
 		i++
 		if i >= len(b) {
-			_, _ = rand.Read(b)
+			_, _ = rng.Read(b)
 			i = 0
 		}
 		return x
@@ -1,20 +1,20 @@
 package fuzzing
 
 import (
-	crand "crypto/rand"
 	"math/big"
 	"math/rand"
+	"slices"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/core/vm/program"
 )
 
-func fillPrecompileTest(gst *GstMaker, fork string) {
+func fillPrecompileTest(rng *rand.Rand, gst *GstMaker, fork string) {
 	// Add a contract which calls a precompile
 	dest := common.HexToAddress("0x0000ca1100b1a7e")
 	gst.AddAccount(dest, GenesisAccount{
-		Code:    randCallPrecompile(),
+		Code:    randCallPrecompile(rng),
 		Balance: big.NewInt(10_000_000),
 		Storage: make(map[common.Hash]common.Hash),
 	})
@@ -23,7 +23,7 @@ func fillPrecompileTest(gst *GstMaker, fork string) {
 		// 8M gaslimit
 		GasLimit:   []uint64{8000000},
 		Nonce:      0,
-		Value:      []string{randHex(4)},
+		Value:      []string{randHex(rng, 4)},
 		Data:       []string{""},
 		GasPrice:   big.NewInt(0x20),
 		To:         dest.Hex(),
@@ -36,24 +36,38 @@ func fillPrecompileTest(gst *GstMaker, fork string) {
 // With 3% probability it outputs 0
 // With 92% probability it outputs a number [0..256)
 // With 5% probability it outputs a number [0..1024)
-func randSize() int64 {
-	b := rand.Int31n(100)
+func randSize(rng *rand.Rand) int64 {
+	b := rng.Int31n(100)
 	// Zero or not?
 	if b < 3 {
 		return 0
 	}
 	if b < 95 { // Make it a multiple of 32, up to 512 (16x)
-		return (1 + rand.Int63n(16)) * 32
+		return (1 + rng.Int63n(16)) * 32
 	}
-	return rand.Int63n(1024)
+	return rng.Int63n(1024)
 }
 
-func randCallPrecompile() []byte {
+// The addresses of the precompiles, sorted. Geth builds its lists from a map,
+// so their order differs between processes, and the tests would not be
+// determined by the seed.
+var (
+	precompilesPrague = sortAddresses(vm.PrecompiledAddressesPrague)
+	precompilesOsaka  = sortAddresses(vm.PrecompiledAddressesOsaka)
+)
+
+func sortAddresses(addrs []common.Address) []common.Address {
+	addrs = slices.Clone(addrs)
+	slices.SortFunc(addrs, func(a, b common.Address) int { return a.Cmp(b) })
+	return addrs
+}
+
+func randCallPrecompile(rng *rand.Rand) []byte {
 	// fill the memory
 	p := program.New()
-	size := randSize()
+	size := randSize(rng)
 	data := make([]byte, size)
-	_, _ = crand.Read(data)
+	_, _ = rng.Read(data)
 	p.Mstore(data, 0)
 	memInFn := func() (offset, size any) {
 		return 0, len(data)
@@ -63,9 +77,9 @@ func randCallPrecompile() []byte {
 		return
 	}
 	addrGen := func() any {
-		return vm.PrecompiledAddressesOsaka[rand.Int()%len(vm.PrecompiledAddressesOsaka)]
+		return precompilesOsaka[rng.Int()%len(precompilesOsaka)]
 	}
-	p2 := RandCall(GasRandomizer(), addrGen, ValueRandomizer(), memInFn, memOutFn)
+	p2 := RandCall(rng, GasRandomizer(rng), addrGen, ValueRandomizer(rng), memInFn, memOutFn)
 	p.Append(p2)
 	// store the returnvalue ot slot 1337
 	p.Push(0x1337)
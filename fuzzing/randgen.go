@@ -17,7 +17,6 @@
 package fuzzing
 
 import (
-	crand "crypto/rand"
 	"encoding/binary"
 	"math"
 	"math/big"
@@ -33,20 +32,20 @@ type memFunc func() (offset, size any)
 type valFunc func() any
 
 // randHex produces some random hex data
-func randHex(maxSize int) string {
-	size := rand.Intn(maxSize)
+func randHex(rng *rand.Rand, maxSize int) string {
+	size := rng.Intn(maxSize)
 	b := make([]byte, size)
-	_, _ = crand.Read(b)
+	_, _ = rng.Read(b)
 	return hexutil.Encode(b)
 }
 
 // randInt returns a valFunc which spits out bigints,
 // - Chance of zero, expressed as N out of 255.
 // - Chance of small value (< 255 ), expressed as N out of 255.
-func randInt(chanceOfZero, chanceOfSmall byte) valFunc {
+func randInt(rng *rand.Rand, chanceOfZero, chanceOfSmall byte) valFunc {
 	return func() any {
 		b := make([]byte, 4)
-		_, _ = crand.Read(b)
+		_, _ = rng.Read(b)
 		// Zero or not?
 		if b[0] < chanceOfZero {
 			return big.NewInt(0)
@@ -55,48 +54,48 @@ func randInt(chanceOfZero, chanceOfSmall byte) valFunc {
 			return (new(big.Int)).SetBytes(b[2:3])
 		}
 		val := make([]byte, 32)
-		_, _ = crand.Read(val)
+		_, _ = rng.Read(val)
 		return (new(big.Int)).SetBytes(val)
 	}
 }
 
 // addressRandomizer randomizes from the given addresses
-func addressRandomizer(addrs []common.Address) valFunc {
+func addressRandomizer(rng *rand.Rand, addrs []common.Address) valFunc {
 	return func() any {
-		return addrs[rand.Intn(len(addrs))]
+		return addrs[rng.Intn(len(addrs))]
 	}
 }
 
-func ValueRandomizer() valFunc {
+func ValueRandomizer(rng *rand.Rand) valFunc {
 	// every 16th is zero
 	// Most are small, but every 16th is unbounded
-	return randInt(0x0f, 0xef)
+	return randInt(rng, 0x0f, 0xef)
 }
 
-func MemRandomizer() memFunc {
+func MemRandomizer(rng *rand.Rand) memFunc {
 	// half are zero
 	// most are small
-	v := randInt(0x70, 0xef)
+	v := randInt(rng, 0x70, 0xef)
 	memFn := func() (offset, size any) {
 		return v(), v()
 	}
 	return memFn
 }
-func GasRandomizer() valFunc {
+func GasRandomizer(rng *rand.Rand) valFunc {
 	// Very few are zero,
 	// 1/16th are small,
 	// most are huge
-	return randInt(0x02, 0x0f)
+	return randInt(rng, 0x02, 0x0f)
 
 }
 
 var callTypes = []vm.OpCode{vm.CALL, vm.CALLCODE, vm.DELEGATECALL, vm.STATICCALL}
 
-func randCallType() vm.OpCode {
-	return callTypes[rand.Intn(len(callTypes))]
+func randCallType(rng *rand.Rand) vm.OpCode {
+	return callTypes[rng.Intn(len(callTypes))]
 }
 
-func RandCall(gas, addr, val valFunc, memIn, memOut memFunc) []byte {
+func RandCall(rng *rand.Rand, gas, addr, val valFunc, memIn, memOut memFunc) []byte {
 	p := program.New()
 	if memOut != nil {
 		memOutOffset, memOutSize := memOut()
@@ -114,7 +113,7 @@ func RandCall(gas, addr, val valFunc, memIn, memOut memFunc) []byte {
 		p.Push(0)
 		p.Push(0)
 	}
-	op := randCallType()
+	op := randCallType(rng)
 	if op == vm.CALL || op == vm.CALLCODE {
 		if val != nil {
 			p.Push(val()) //value
@@ -132,14 +131,14 @@ func RandCall(gas, addr, val valFunc, memIn, memOut memFunc) []byte {
 	return p.Bytes()
 }
 
-func randomBlakeArgs() []byte {
+func randomBlakeArgs(rng *rand.Rand) []byte {
 	//params are
 	var rounds uint32
 	data := make([]byte, 214)
-	_, _ = crand.Read(data)
+	_, _ = rng.Read(data)
 	// Now, modify the rounds, and the 'f'
 	// rounds should be below 1024 for the most part
-	rounds = uint32(math.Abs(1024 * rand.ExpFloat64()))
+	rounds = uint32(math.Abs(1024 * rng.ExpFloat64()))
 	binary.BigEndian.PutUint32(data, rounds)
 	x := data[213]
 	switch {
@@ -155,10 +154,10 @@ func randomBlakeArgs() []byte {
 	return data[0:213]
 }
 
-func RandCallBlake() []byte {
+func RandCallBlake(rng *rand.Rand) []byte {
 	// fill the memory
 	p := program.New()
-	data := randomBlakeArgs()
+	data := randomBlakeArgs(rng)
 	p.Mstore(data, 0)
 	memInFn := func() (offset, size any) {
 		// todo:make mem generator which mostly outputs 0:213
@@ -173,7 +172,7 @@ func RandCallBlake() []byte {
 	addrGen := func() any {
 		return 9
 	}
-	p2 := RandCall(GasRandomizer(), addrGen, ValueRandomizer(), memInFn, memOutFn)
+	p2 := RandCall(rng, GasRandomizer(rng), addrGen, ValueRandomizer(rng), memInFn, memOutFn)
 	p.Append(p2)
 	// pop the ret value
 	p.Op(vm.POP)
@@ -22,29 +22,29 @@ package fuzzing
 /*
 
 func TestValueGen(t *testing.T) {
-	gen := ValueRandomizer()
+	gen := ValueRandomizer(rng)
 	for i := 0; i < 100; i++ {
 		fmt.Printf("%x\n", gen())
 	}
 }
 func TestMemGen(t *testing.T) {
-	memFn := MemRandomizer()
+	memFn := MemRandomizer(rng)
 	for i := 0; i < 100; i++ {
 		loc, size := memFn()
 		fmt.Printf("%v %v\n", loc, size)
 	}
 }
 func TestGasGen(t *testing.T) {
-	gen := GasRandomizer()
+	gen := GasRandomizer(rng)
 	for i := 0; i < 100; i++ {
 		fmt.Printf("%x\n", gen())
 	}
 }
 func TestRandCall(t *testing.T) {
-	addrGen := addressRandomizer([]common.Address{
+	addrGen := addressRandomizer(rng, []common.Address{
 		common.HexToAddress("0x1337"), common.HexToAddress("0x1338"),
 	})
-	memFn := MemRandomizer()
-	fmt.Printf("%x\n", RandCall(GasRandomizer(), addrGen, ValueRandomizer(), memFn, memFn))
+	memFn := MemRandomizer(rng)
+	fmt.Printf("%x\n", RandCall(rng, GasRandomizer(rng), addrGen, ValueRandomizer(rng), memFn, memFn))
 }
 */
@@ -17,10 +17,13 @@
 package fuzzing
 
 import (
+	"crypto"
 	"crypto/ecdsa"
 	"crypto/elliptic"
-	crand "crypto/rand"
+	_ "crypto/sha256" // for deterministic signatures
+	"encoding/asn1"
 	"math/big"
+	"math/rand"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/vm"
@@ -28,11 +31,11 @@ import (
 	"github.com/holiman/uint256"
 )
 
-func fillSecp256R(gst *GstMaker, fork string) {
+func fillSecp256R(rng *rand.Rand, gst *GstMaker, fork string) {
 	// Add a contract which calls BLS
 	dest := common.HexToAddress("0x00ca115ec9")
 	gst.AddAccount(dest, GenesisAccount{
-		Code:    randCallSecp256R(),
+		Code:    randCallSecp256R(rng),
 		Balance: big.NewInt(10_000_000),
 		Storage: make(map[common.Hash]common.Hash),
 	})
@@ -40,8 +43,8 @@ func fillSecp256R(gst *GstMaker, fork string) {
 	gst.SetTx(&StTransaction{
 		GasLimit:   []uint64{16_000_000},
 		Nonce:      0,
-		Value:      []string{randHex(4)},
-		Data:       []string{randHex(100)},
+		Value:      []string{randHex(rng, 4)},
+		Data:       []string{randHex(rng, 100)},
 		GasPrice:   big.NewInt(0x10),
 		To:         dest.Hex(),
 		Sender:     sender,
@@ -49,15 +52,15 @@ func fillSecp256R(gst *GstMaker, fork string) {
 	})
 }
 
-func randCallSecp256R() []byte {
+func randCallSecp256R(rng *rand.Rand) []byte {
 	p := program.New()
 	// Cramming in 100 makes the size of p roughly 20k
 	offset := 0
 	for range int32(100) {
 		hash := make([]byte, 32)
-		_, _ = crand.Read(hash)
-		privKey, _ := ecdsa.GenerateKey(elliptic.P256(), crand.Reader)
-		r, s, _ := ecdsa.Sign(crand.Reader, privKey, hash)
+		_, _ = rng.Read(hash)
+		privKey := randP256Key(rng)
+		r, s := signP256(privKey, hash)
 		data := append([]byte{}, hash...)
 
 		data = append(data, uint256.MustFromBig(r).PaddedBytes(32)...)
@@ -67,7 +70,7 @@ func randCallSecp256R() []byte {
 		data = append(data, uint256.MustFromBig(privKey.PublicKey.Y).PaddedBytes(32)...)
 
 		// Mutate it randomly a bit
-		mutate(data)
+		mutate(rng, data)
 		p.Mstore(data, 0)
 		p.Call(nil, 0x100, 0, 0, len(data), 0, 32)
 		p.Op(vm.POP) // pop the ret value
@@ -77,3 +80,29 @@ func randCallSecp256R() []byte {
 	}
 	return p.Bytes()
 }
+
+// randP256Key returns a random secp256r1 key. The key generation of the
+// standard library does not draw from the given source, so the key is
+// instead created from random bytes.
+func randP256Key(rng *rand.Rand) *ecdsa.PrivateKey {
+	for {
+		b := make([]byte, 32)
+		_, _ = rng.Read(b)
+		if key, err := ecdsa.ParseRawPrivateKey(elliptic.P256(), b); err == nil {
+			return key
+		}
+	}
+}
+
+// signP256 signs the hash deterministically, as per RFC 6979.
+func signP256(key *ecdsa.PrivateKey, hash []byte) (r, s *big.Int) {
+	sig, err := key.Sign(nil, hash, crypto.SHA256)
+	if err != nil {
+		panic(err)
+	}
+	var parsed struct{ R, S *big.Int }
+	if _, err := asn1.Unmarshal(sig, &parsed); err != nil {
+		panic(err)
+	}
+	return parsed.R, parsed.S
+}
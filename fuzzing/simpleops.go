@@ -27,14 +27,14 @@ import (
 	"github.com/holiman/goevmlab/ops"
 )
 
-func fillSimple(gst *GstMaker, fork string) {
+func fillSimple(rng *rand.Rand, gst *GstMaker, fork string) {
 	dest := common.HexToAddress("0xd0de")
 	forkDef := ops.LookupFork(fork)
 	if forkDef == nil {
 		panic(fmt.Sprintf("bad fork %v", fork))
 	}
 	gst.AddAccount(dest, GenesisAccount{
-		Code:    generateSimpleOpsProgram(forkDef),
+		Code:    generateSimpleOpsProgram(rng, forkDef),
 		Balance: big.NewInt(10_000_000),
 		Storage: make(map[common.Hash]common.Hash),
 	})
@@ -43,8 +43,8 @@ func fillSimple(gst *GstMaker, fork string) {
 		// 8M gaslimit
 		GasLimit:   []uint64{16000000},
 		Nonce:      0,
-		Value:      []string{randHex(4)},
-		Data:       []string{randHex(100)},
+		Value:      []string{randHex(rng, 4)},
+		Data:       []string{randHex(rng, 100)},
 		GasPrice:   big.NewInt(0x10),
 		To:         dest.Hex(),
 		Sender:     sender,
@@ -52,10 +52,10 @@ func fillSimple(gst *GstMaker, fork string) {
 	})
 }
 
-func fillMemOps(gst *GstMaker, fork string) {
+func fillMemOps(rng *rand.Rand, gst *GstMaker, fork string) {
 	dest := common.HexToAddress("0xd0de")
 	gst.AddAccount(dest, GenesisAccount{
-		Code:    generateMemoryInteractingOpsProgram(fork),
+		Code:    generateMemoryInteractingOpsProgram(rng, fork),
 		Balance: big.NewInt(10_000_000),
 		Storage: make(map[common.Hash]common.Hash),
 	})
@@ -64,8 +64,8 @@ func fillMemOps(gst *GstMaker, fork string) {
 		// 8M gaslimit
 		GasLimit:   []uint64{16000000},
 		Nonce:      0,
-		Value:      []string{randHex(4)},
-		Data:       []string{randHex(100)},
+		Value:      []string{randHex(rng, 4)},
+		Data:       []string{randHex(rng, 100)},
 		GasPrice:   big.NewInt(0x10),
 		To:         dest.Hex(),
 		Sender:     sender,
@@ -103,24 +103,24 @@ var operations = []ops.OpCode{
 
 // generateSimpleOpsProgram generates non-erroring programs with some degree
 // of interestingness on inputs for various arithmetic ops.
-func generateSimpleOpsProgram(forkDef *ops.Fork) []byte {
+func generateSimpleOpsProgram(rng *rand.Rand, forkDef *ops.Fork) []byte {
 
 	var p = program.New()
 	var stackdepth = 0
 
 	for range 10000 {
-		op := ops.OpCode(operations[rand.Intn(len(operations))])
+		op := ops.OpCode(operations[rng.Intn(len(operations))])
 
 		if stackdepth < len(op.Pops()) {
 			for i := 0; i < len(op.Pops()); i++ {
-				idx := rand.Intn(len(integers))
+				idx := rng.Intn(len(integers))
 				a, _ := big.NewInt(0).SetString(integers[idx], 16)
 				p.Push(a)
 				stackdepth++
 			}
 		}
 		// stack depth is sufficient now
-		if stackdepth > 1 && rand.Uint32()%2 == 0 {
+		if stackdepth > 1 && rng.Uint32()%2 == 0 {
 			p.Op(vm.SWAP1)
 		}
 		p.Op(vm.OpCode(op))
@@ -148,7 +148,7 @@ var memOps = []ops.OpCode{
 // generateMemoryInteractingOpsProgram generates potentially erroring programs with some degree
 // of interestingness on inputs for various arithmetic ops. These operations include
 // memory access ops, which may be OOG.
-func generateMemoryInteractingOpsProgram(fork string) []byte {
+func generateMemoryInteractingOpsProgram(rng *rand.Rand, fork string) []byte {
 
 	var p = program.New()
 	var stackdepth = 0
@@ -172,18 +172,18 @@ func generateMemoryInteractingOpsProgram(fork string) []byte {
 	}
 
 	for range 1000 {
-		op := ops.OpCode(usedOps[rand.Intn(len(usedOps))])
+		op := ops.OpCode(usedOps[rng.Intn(len(usedOps))])
 
 		if stackdepth < len(op.Pops()) {
 			for i := 0; i < len(op.Pops()); i++ {
-				idx := rand.Intn(len(integers))
+				idx := rng.Intn(len(integers))
 				a, _ := big.NewInt(0).SetString(integers[idx], 16)
 				p.Push(a)
 				stackdepth++
 			}
 		}
 		// stack depth is sufficient now
-		if stackdepth > 1 && rand.Uint32()%2 == 0 {
+		if stackdepth > 1 && rng.Uint32()%2 == 0 {
 			p.Op(vm.SWAP1)
 		}
 		p.Op(vm.OpCode(op))
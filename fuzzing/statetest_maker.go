@@ -21,6 +21,7 @@ import (
 	"io"
 	"math/big"
 	"os"
+	"slices"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
@@ -44,9 +45,17 @@ type GstMaker struct {
 	pre   *GenesisAlloc
 	env   *stEnv
 	tx    StTransaction
+	seq   []*StTransaction // transactions following tx, in t8n tests only
 	forks []string
-	root  common.Hash
-	logs  common.Hash
+	info  *stInfo
+
+	results map[stResultKey]stPostState // the filled post-states
+}
+
+// stResultKey identifies the post-state of a subtest.
+type stResultKey struct {
+	fork  string
+	index stIndex
 }
 
 func NewGstMaker() *GstMaker {
@@ -116,9 +125,55 @@ func (g *GstMaker) SetCode(address common.Address, code []byte) {
 	alloc[address] = account
 }
 
-func (g *GstMaker) SetResult(root, logs common.Hash) {
-	g.root = root
-	g.logs = logs
+// SetResult sets the state root and logs hash of the post-state of the fork,
+// for the n:th combination of data, gas and value (see Indexes).
+func (g *GstMaker) SetResult(fork string, n int, root, logs common.Hash) {
+	if g.results == nil {
+		g.results = make(map[stResultKey]stPostState)
+	}
+	g.results[stResultKey{fork, g.indexes()[n]}] = stPostState{Root: root, Logs: logs}
+}
+
+// Subtests returns the number of subtests of each fork: one for each
+// combination of data, gas and value of the transaction.
+func (g *GstMaker) Subtests() int {
+	return len(g.indexes())
+}
+
+// indexes returns all combinations of data, gas and value of the transaction,
+// in the order of the post-states of a fork.
+func (g *GstMaker) indexes() []stIndex {
+	var indexes []stIndex
+	for d := range max(len(g.tx.Data), 1) {
+		for gas := range max(len(g.tx.GasLimit), 1) {
+			for v := range max(len(g.tx.Value), 1) {
+				indexes = append(indexes, stIndex{Data: d, Gas: gas, Value: v})
+			}
+		}
+	}
+	return indexes
+}
+
+// AddData adds a calldata variant to the transaction, and returns its index.
+// Any access list of the transaction is shared with the new variant.
+func (g *GstMaker) AddData(data []byte) int {
+	if len(g.tx.AccessLists) > 0 {
+		g.tx.AccessLists = append(g.tx.AccessLists, g.tx.AccessLists[0])
+	}
+	g.tx.Data = append(g.tx.Data, hexutil.Encode(data))
+	return len(g.tx.Data) - 1
+}
+
+// AddGasLimit adds a gas limit variant to the transaction, and returns its index.
+func (g *GstMaker) AddGasLimit(gas uint64) int {
+	g.tx.GasLimit = append(g.tx.GasLimit, gas)
+	return len(g.tx.GasLimit) - 1
+}
+
+// AddValue adds a value variant to the transaction, and returns its index.
+func (g *GstMaker) AddValue(value *big.Int) int {
+	g.tx.Value = append(g.tx.Value, hexutil.EncodeBig(value))
+	return len(g.tx.Value) - 1
 }
 
 func (g *GstMaker) SetTx(tx *StTransaction) {
@@ -137,19 +192,22 @@ func (g *GstMaker) ToSubTest() *stJSON {
 			},
 		},
 	}
+	st.Info = g.info
 	st.Pre = *g.pre
 	st.Env = *g.env
 	st.Tx = g.tx
+	st.Post = make(map[string][]stPostState)
 	for _, fork := range g.forks {
-		postState := make(map[string][]stPostState)
-		postState[fork] = []stPostState{
-			stPostState{
-				Logs:    g.logs,
-				Root:    g.root,
-				Indexes: stIndex{Gas: 0, Value: 0, Data: 0},
-			},
+		var posts []stPostState
+		for _, index := range g.indexes() {
+			result := g.results[stResultKey{fork, index}]
+			posts = append(posts, stPostState{
+				Logs:    result.Logs,
+				Root:    result.Root,
+				Indexes: index,
+			})
 		}
-		st.Post = postState
+		st.Post[fork] = posts
 	}
 	return st
 }
@@ -184,31 +242,57 @@ func (g *GstMaker) ToStateTest() (tests.StateTest, error) {
 	return gethStateTest, nil
 }
 
+// EnableFork adds a post-state for the fork to the test. The same program is
+// then executed under the rules of each enabled fork.
 func (g *GstMaker) EnableFork(fork string) {
-	g.forks = append(g.forks, fork)
+	if !slices.Contains(g.forks, fork) {
+		g.forks = append(g.forks, fork)
+	}
+}
+
+// Forks returns the enabled forks.
+func (g *GstMaker) Forks() []string {
+	return slices.Clone(g.forks)
+}
+
+// ForFork returns a copy of the test, where only the given fork is enabled.
+func (g *GstMaker) ForFork(fork string) *GstMaker {
+	cpy := *g
+	cpy.forks = []string{fork}
+	return &cpy
 }
 
-// Fill uses go-ethereum internally to determine the state root and logs, and optionally
-// outputs the trace to the given writer (if non-nil). The limit caps the size of the
-// trace output; zero means unlimited.
+// Fill uses go-ethereum internally to determine the state root and logs of
+// each subtest, and optionally outputs the trace to the given writer (if
+// non-nil). The forks are executed in the order they were enabled, and the
+// subtests of each fork in the order of the post-states. The limit caps the
+// size of the trace output of each subtest; zero means unlimited.
 func (g *GstMaker) Fill(traceOutput io.Writer, limit int) error {
 
 	test, err := g.ToStateTest()
 	if err != nil {
 		return err
 	}
-	subtest := test.Subtests()[0]
-	cfg := vm.Config{}
-	if traceOutput != nil {
-		cfg.Tracer = logger.NewJSONLogger(&logger.Config{Limit: limit}, traceOutput)
-	}
-	state, root, _, err := test.RunNoVerify(subtest, cfg, false, rawdb.HashScheme)
-	if err != nil {
-		return err
+	subtests := test.Subtests()
+	slices.SortStableFunc(subtests, func(a, b tests.StateSubtest) int {
+		if a.Fork != b.Fork {
+			return slices.Index(g.forks, a.Fork) - slices.Index(g.forks, b.Fork)
+		}
+		return a.Index - b.Index
+	})
+	for _, subtest := range subtests {
+		cfg := vm.Config{}
+		if traceOutput != nil {
+			cfg.Tracer = logger.NewJSONLogger(&logger.Config{Limit: limit}, traceOutput)
+		}
+		state, root, _, err := test.RunNoVerify(subtest, cfg, false, rawdb.HashScheme)
+		if err != nil {
+			return err
+		}
+		logs := rlpHash(state.StateDB.Logs())
+		state.Close()
+		g.SetResult(subtest.Fork, subtest.Index, root, logs)
 	}
-	defer state.Close()
-	logs := rlpHash(state.StateDB.Logs())
-	g.SetResult(root, logs)
 	return nil
 }
 
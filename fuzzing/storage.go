@@ -17,7 +17,6 @@
 package fuzzing
 
 import (
-	crand "crypto/rand"
 	"math/rand"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -28,26 +27,26 @@ import (
 )
 
 // RandStorage sets some slots
-func RandStorage(maxSlots, maxVal int) map[common.Hash]common.Hash {
+func RandStorage(rng *rand.Rand, maxSlots, maxVal int) map[common.Hash]common.Hash {
 	storage := make(map[common.Hash]common.Hash)
-	numSlots := rand.Intn(maxSlots)
+	numSlots := rng.Intn(maxSlots)
 	for range numSlots {
-		v, slot := byte(rand.Intn(maxVal)), byte(rand.Intn(numSlots))
+		v, slot := byte(rng.Intn(maxVal)), byte(rng.Intn(numSlots))
 		storage[common.BytesToHash([]byte{slot})] = common.BytesToHash([]byte{v})
 	}
 	return storage
 }
 
-func RandStorageOps() *program.Program {
+func RandStorageOps(rng *rand.Rand) *program.Program {
 	p := program.New()
 	for {
-		r := rand.Intn(100)
+		r := rng.Intn(100)
 		switch {
 		case r < 40:
-			slot, val := rand.Intn(5), rand.Intn(3)
+			slot, val := rng.Intn(5), rng.Intn(3)
 			p.Sstore(slot, val)
 		case r < 80:
-			slot := rand.Intn(10)
+			slot := rng.Intn(10)
 			p.Push(slot)
 			p.Op(vm.SLOAD)
 			p.Op(vm.POP)
@@ -57,14 +56,14 @@ func RandStorageOps() *program.Program {
 	}
 }
 
-func RandCall2200(addresses []common.Address) []byte {
-	return randCall2200(addresses, 0)
+func RandCall2200(rng *rand.Rand, addresses []common.Address) []byte {
+	return randCall2200(rng, addresses, 0)
 }
-func randCall2200(addresses []common.Address, depth int) []byte {
+func randCall2200(rng *rand.Rand, addresses []common.Address, depth int) []byte {
 	if depth > 10 {
 		return []byte{}
 	}
-	addrGen := addressRandomizer(addresses)
+	addrGen := addressRandomizer(rng, addresses)
 
 	// 10% sstore,
 	// 10% sload,
@@ -75,36 +74,36 @@ func randCall2200(addresses []common.Address, depth int) []byte {
 	// 5% return, 5% revert
 	p := program.New()
 	for {
-		r := rand.Intn(101)
+		r := rng.Intn(101)
 		switch {
 		case r < 10:
-			p.Sstore(rand.Intn(5), rand.Intn(3))
+			p.Sstore(rng.Intn(5), rng.Intn(3))
 		case r < 20:
-			slot := rand.Intn(5)
+			slot := rng.Intn(5)
 			p.Push(slot)
 			p.Op(vm.SLOAD)
 			p.Op(vm.POP)
 		case r < 50: // 30% chance of well-formed opcode
 			b := make([]byte, 10)
-			_, _ = crand.Read(b)
+			_, _ = rng.Read(b)
 			for i := range b {
 				if op := ops.OpCode(b[i]); ops.IsDefined(op) {
 					p.Op(vm.OpCode(op))
 				}
 			}
 		case r < 60: // 10% chance of some random opcode
-			p.Op(vm.OpCode(rand.Uint32()))
+			p.Op(vm.OpCode(rng.Uint32()))
 		case r < 80:
 			// zero value call with no data
-			p2 := RandCall(nil, addrGen, nil, nil, nil)
+			p2 := RandCall(rng, nil, addrGen, nil, nil, nil)
 			p.Append(p2)
 			// pop the ret value
 			p.Op(vm.POP)
 		case r < 90:
-			ctor := RandStorageOps()
-			runtimeCode := randCall2200(addresses, depth+1)
+			ctor := RandStorageOps(rng)
+			runtimeCode := randCall2200(rng, addresses, depth+1)
 			ctor.ReturnData(runtimeCode)
-			program2.CreateAndCall(p, ctor.Bytes(), r%2 == 0, randCallType())
+			program2.CreateAndCall(p, ctor.Bytes(), r%2 == 0, randCallType(rng))
 		case r < 95:
 			p.Push(addrGen())
 			p.Op(vm.SELFDESTRUCT)
@@ -18,10 +18,12 @@ package fuzzing
 
 import (
 	"fmt"
+	"math/rand"
 	"testing"
 )
 
 func TestStorageOps(t *testing.T){
-	p := RandStorageOps()
+	rng := rand.New(rand.NewSource(1))
+	p := RandStorageOps(rng)
 	fmt.Printf("%x \n", p)
 }
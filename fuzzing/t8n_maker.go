@@ -0,0 +1,273 @@
+// Copyright 2026 Martin Holst Swende
+// This file is part of the goevmlab library.
+//
+// The library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the goevmlab library. If not, see <http://www.gnu.org/licenses/>.
+
+package fuzzing
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/tests"
+	"github.com/holiman/uint256"
+)
+
+// The chain id of the transactions in a t8n test, as in the statetests.
+var t8nChainID = big.NewInt(1)
+
+// T8nTest is the input of the state transition tool (t8n) of the clients: a
+// pre-state, a block environment and the transactions to apply, all within one
+// block. The fork is passed to the tool as `--state.fork`.
+type T8nTest struct {
+	Info  *stInfo              `json:"_info,omitempty"`
+	Fork  string               `json:"fork"`
+	Alloc GenesisAlloc         `json:"alloc"`
+	Env   *t8nEnv              `json:"env"`
+	Txs   []*types.Transaction `json:"txs"`
+}
+
+// t8nEnv is the block environment of a t8n test.
+type t8nEnv struct {
+	Coinbase         common.Address                      `json:"currentCoinbase"`
+	Difficulty       *math.HexOrDecimal256               `json:"currentDifficulty,omitempty"`
+	Random           *common.Hash                        `json:"currentRandom,omitempty"`
+	GasLimit         math.HexOrDecimal64                 `json:"currentGasLimit"`
+	Number           math.HexOrDecimal64                 `json:"currentNumber"`
+	Timestamp        math.HexOrDecimal64                 `json:"currentTimestamp"`
+	BaseFee          *math.HexOrDecimal256               `json:"currentBaseFee,omitempty"`
+	ExcessBlobGas    *math.HexOrDecimal64                `json:"currentExcessBlobGas,omitempty"`
+	ParentBeaconRoot *common.Hash                        `json:"parentBeaconBlockRoot,omitempty"`
+	Withdrawals      []*types.Withdrawal                 `json:"withdrawals"`
+	BlockHashes      map[math.HexOrDecimal64]common.Hash `json:"blockHashes"`
+}
+
+// AppendTx adds a transaction to be executed after the transaction of the
+// test (and any previously appended ones), in the same block. A statetest
+// holds a single transaction, so the appended transactions are only part of
+// the t8n test (see ToT8nTest).
+func (g *GstMaker) AppendTx(tx *StTransaction) {
+	g.seq = append(g.seq, tx)
+}
+
+// Txs returns the transaction of the test, followed by the appended ones.
+func (g *GstMaker) Txs() []*StTransaction {
+	return append([]*StTransaction{&g.tx}, g.seq...)
+}
+
+// ToT8nTest returns the test as input for the t8n tool, for the given fork.
+// The transactions are signed with their secret keys, using the first
+// variant of the data, gas and value. The system contracts of the fork are
+// added to the pre-state, since the t8n tool applies the system calls of the
+// block.
+func (g *GstMaker) ToT8nTest(fork string) (*T8nTest, error) {
+	config, ok := tests.Forks[fork]
+	if !ok {
+		return nil, tests.UnsupportedForkError{Name: fork}
+	}
+	var (
+		number = new(big.Int).SetUint64(g.env.Number)
+		time   = g.env.Timestamp
+		env    = &t8nEnv{
+			Coinbase:    g.env.Coinbase,
+			GasLimit:    math.HexOrDecimal64(g.env.GasLimit),
+			Number:      math.HexOrDecimal64(g.env.Number),
+			Timestamp:   math.HexOrDecimal64(g.env.Timestamp),
+			Withdrawals: []*types.Withdrawal{},
+			BlockHashes: make(map[math.HexOrDecimal64]common.Hash),
+		}
+	)
+	if g.env.Number > 0 {
+		env.BlockHashes[math.HexOrDecimal64(g.env.Number-1)] = g.env.PreviousHash
+	}
+	if ttd := config.TerminalTotalDifficulty; ttd != nil && ttd.Sign() == 0 {
+		env.Random = g.env.Random
+	} else {
+		env.Difficulty = (*math.HexOrDecimal256)(g.env.Difficulty)
+	}
+	if config.IsLondon(number) {
+		env.BaseFee = (*math.HexOrDecimal256)(g.env.BaseFee)
+	}
+	if config.IsCancun(number, time) {
+		env.ExcessBlobGas = new(math.HexOrDecimal64)
+		env.ParentBeaconRoot = new(common.Hash)
+	}
+	alloc := make(GenesisAlloc)
+	for addr, a := range systemContracts(fork) {
+		alloc[addr] = a
+	}
+	for addr, a := range *g.pre {
+		alloc[addr] = a
+	}
+	test := &T8nTest{Info: g.info, Fork: fork, Alloc: alloc, Env: env}
+	for i, sttx := range g.Txs() {
+		tx, err := sttx.sign()
+		if err != nil {
+			return nil, fmt.Errorf("tx %d: %w", i, err)
+		}
+		test.Txs = append(test.Txs, tx)
+	}
+	return test, nil
+}
+
+// sign returns the transaction signed with its secret key, using the first
+// variant of the data, gas and value. The type of the transaction follows
+// from the fields which are set.
+func (tx *StTransaction) sign() (*types.Transaction, error) {
+	if len(tx.GasLimit) == 0 {
+		return nil, errors.New("no gas limit")
+	}
+	key, err := crypto.ToECDSA(tx.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		to         *common.Address
+		data       []byte
+		value      = new(big.Int)
+		accessList types.AccessList
+	)
+	if tx.To != "" {
+		addr := common.HexToAddress(tx.To)
+		to = &addr
+	}
+	if len(tx.Data) > 0 {
+		if data, err = hexutil.Decode(tx.Data[0]); err != nil {
+			return nil, fmt.Errorf("invalid data: %w", err)
+		}
+	}
+	if len(tx.Value) > 0 {
+		v, ok := math.ParseBig256(tx.Value[0])
+		if !ok {
+			return nil, fmt.Errorf("invalid value: %v", tx.Value[0])
+		}
+		value = v
+	}
+	if len(tx.AccessLists) > 0 && tx.AccessLists[0] != nil {
+		// The storage keys are required in the json encoding
+		for _, tuple := range *tx.AccessLists[0] {
+			if tuple.StorageKeys == nil {
+				tuple.StorageKeys = []common.Hash{}
+			}
+			accessList = append(accessList, tuple)
+		}
+	}
+	var inner types.TxData
+	switch {
+	case tx.AuthorizationList != nil:
+		if to == nil {
+			return nil, errors.New("setcode tx without recipient")
+		}
+		var auths []types.SetCodeAuthorization
+		for _, a := range tx.AuthorizationList {
+			auths = append(auths, types.SetCodeAuthorization{
+				ChainID: *u256(a.ChainID),
+				Address: a.Address,
+				Nonce:   a.Nonce,
+				V:       a.V,
+				R:       *u256(a.R),
+				S:       *u256(a.S),
+			})
+		}
+		inner = &types.SetCodeTx{
+			ChainID:    u256(t8nChainID),
+			Nonce:      tx.Nonce,
+			GasTipCap:  u256(tx.MaxPriorityFeePerGas),
+			GasFeeCap:  u256(tx.MaxFeePerGas),
+			Gas:        tx.GasLimit[0],
+			To:         *to,
+			Value:      u256(value),
+			Data:       data,
+			AccessList: accessList,
+			AuthList:   auths,
+		}
+	case tx.BlobVersionedHashes != nil:
+		if to == nil {
+			return nil, errors.New("blob tx without recipient")
+		}
+		inner = &types.BlobTx{
+			ChainID:    u256(t8nChainID),
+			Nonce:      tx.Nonce,
+			GasTipCap:  u256(tx.MaxPriorityFeePerGas),
+			GasFeeCap:  u256(tx.MaxFeePerGas),
+			Gas:        tx.GasLimit[0],
+			To:         *to,
+			Value:      u256(value),
+			Data:       data,
+			AccessList: accessList,
+			BlobFeeCap: u256(tx.BlobGasFeeCap),
+			BlobHashes: tx.BlobVersionedHashes,
+		}
+	case tx.MaxFeePerGas != nil:
+		inner = &types.DynamicFeeTx{
+			ChainID:    t8nChainID,
+			Nonce:      tx.Nonce,
+			GasTipCap:  tx.MaxPriorityFeePerGas,
+			GasFeeCap:  tx.MaxFeePerGas,
+			Gas:        tx.GasLimit[0],
+			To:         to,
+			Value:      value,
+			Data:       data,
+			AccessList: accessList,
+		}
+	case accessList != nil:
+		inner = &types.AccessListTx{
+			ChainID:    t8nChainID,
+			Nonce:      tx.Nonce,
+			GasPrice:   tx.GasPrice,
+			Gas:        tx.GasLimit[0],
+			To:         to,
+			Value:      value,
+			Data:       data,
+			AccessList: accessList,
+		}
+	default:
+		inner = &types.LegacyTx{
+			Nonce:    tx.Nonce,
+			GasPrice: tx.GasPrice,
+			Gas:      tx.GasLimit[0],
+			To:       to,
+			Value:    value,
+			Data:     data,
+		}
+	}
+	return types.SignNewTx(key, types.LatestSignerForChainID(t8nChainID), inner)
+}
+
+// u256 converts a field of a transaction, where nil means zero.
+func u256(b *big.Int) *uint256.Int {
+	if b == nil {
+		return new(uint256.Int)
+	}
+	return uint256.MustFromBig(b)
+}
+
+// FromT8nTest reads a t8n test.
+func FromT8nTest(name string) (*T8nTest, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	test := new(T8nTest)
+	err = json.Unmarshal(data, test)
+	return test, err
+}
@@ -0,0 +1,86 @@
+// Copyright 2026 Martin Holst Swende
+// This file is part of the goevmlab library.
+//
+// The library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the goevmlab library. If not, see <http://www.gnu.org/licenses/>.
+
+package fuzzing
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// TestT8nTest checks that the transactions of the t8n tests are signed by
+// their senders, and that the environment matches the fork.
+func TestT8nTest(t *testing.T) {
+	for _, fork := range []string{"London", "Shanghai", "Cancun", "Prague", "Osaka"} {
+		for _, name := range SequenceFactoryNames() {
+			factory := SeededSequenceFactory(name, fork, 1)
+			for i := range 10 {
+				gst := factory()
+				test, err := gst.ToT8nTest(fork)
+				if err != nil {
+					t.Fatalf("%v %v test %d: %v", fork, name, i, err)
+				}
+				txs := gst.Txs()
+				if len(test.Txs) != len(txs) || len(txs) < 2 {
+					t.Fatalf("%v %v test %d: wrong number of txs: %d", fork, name, i, len(test.Txs))
+				}
+				signer := types.LatestSignerForChainID(t8nChainID)
+				for j, tx := range test.Txs {
+					from, err := types.Sender(signer, tx)
+					if err != nil {
+						t.Fatalf("%v %v test %d tx %d: %v", fork, name, i, j, err)
+					}
+					if from != txs[j].Sender || tx.Nonce() != txs[j].Nonce {
+						t.Fatalf("%v %v test %d tx %d: wrong sender %v or nonce %d", fork, name, i, j, from, tx.Nonce())
+					}
+				}
+				cancun := isForkOrLater(fork, "Cancun")
+				if have := test.Env.ParentBeaconRoot != nil; have != cancun {
+					t.Fatalf("%v: parent beacon root set: %v", fork, have)
+				}
+				if _, have := test.Alloc[params.HistoryStorageAddress]; have != isForkOrLater(fork, "Prague") {
+					t.Fatalf("%v: history contract deployed: %v", fork, have)
+				}
+			}
+		}
+	}
+}
+
+// TestSequenceSeeded checks that the tests are determined by the engine and
+// the seed.
+func TestSequenceSeeded(t *testing.T) {
+	gen := func(seed int64) []byte {
+		test, err := GenerateSequence("txseq", "Prague", seed).ToT8nTest("Prague")
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := json.Marshal(test)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+	if a, b := gen(3), gen(3); !bytes.Equal(a, b) {
+		t.Fatal("same seed produced different tests")
+	}
+	if a, b := gen(3), gen(4); bytes.Equal(a, b) {
+		t.Fatal("different seeds produced the same test")
+	}
+}
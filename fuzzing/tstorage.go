@@ -17,7 +17,6 @@
 package fuzzing
 
 import (
-	crand "crypto/rand"
 	"math/rand"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -27,20 +26,20 @@ import (
 	program2 "github.com/holiman/goevmlab/program"
 )
 
-func randTStoreOps() *program.Program {
+func randTStoreOps(rng *rand.Rand) *program.Program {
 	p := program.New()
 	for {
-		r := chance(rand.Intn(101))
+		r := chance(rng.Intn(101))
 		switch {
 		case r.between(0, 20):
-			p.Tstore(rand.Intn(5), rand.Intn(3))
+			p.Tstore(rng.Intn(5), rng.Intn(3))
 		case r.between(20, 40):
-			p.Sstore(rand.Intn(5), rand.Intn(3))
+			p.Sstore(rng.Intn(5), rng.Intn(3))
 		case r.between(40, 60):
-			p.Push(rand.Intn(10))
+			p.Push(rng.Intn(10))
 			p.Op(vm.TLOAD, vm.POP)
 		case r.between(60, 80):
-			p.Push(rand.Intn(10))
+			p.Push(rng.Intn(10))
 			p.Op(vm.SLOAD, vm.POP)
 		default:
 			return p
@@ -48,8 +47,8 @@ func randTStoreOps() *program.Program {
 	}
 }
 
-func RandCallTStore(addresses []common.Address) []byte {
-	return randCallTStore(addresses, 0)
+func RandCallTStore(rng *rand.Rand, addresses []common.Address) []byte {
+	return randCallTStore(rng, addresses, 0)
 }
 
 type chance int
@@ -60,44 +59,44 @@ func (c chance) between(a, b int) bool {
 
 // randCallTStore creates code which does a mix of TSTORE, TLOAD, SSTORE, SLOAD
 // and other (mostly well-formed) opcodes, plys a fair bit of calls to other contracts.
-func randCallTStore(addresses []common.Address, depth int) []byte {
+func randCallTStore(rng *rand.Rand, addresses []common.Address, depth int) []byte {
 	if depth > 10 {
 		return []byte{}
 	}
-	addrGen := addressRandomizer(addresses)
+	addrGen := addressRandomizer(rng, addresses)
 
 	p := program.New()
 	for {
-		r := chance(rand.Intn(101))
+		r := chance(rng.Intn(101))
 		switch {
 		case r.between(0, 10): // TSTORE 10%
-			p.Tstore(rand.Intn(5), rand.Intn(10))
+			p.Tstore(rng.Intn(5), rng.Intn(10))
 		case r.between(10, 20): // SSTORE 10%
-			p.Sstore(rand.Intn(5), rand.Intn(10))
+			p.Sstore(rng.Intn(5), rng.Intn(10))
 		case r.between(20, 35): // TLOAD 15%
-			p.Push(rand.Intn(5))
+			p.Push(rng.Intn(5))
 			p.Op(vm.TLOAD, vm.POP)
 		case r.between(35, 50): // SLOAD 15%
-			p.Push(rand.Intn(5))
+			p.Push(rng.Intn(5))
 			p.Op(vm.SLOAD, vm.POP)
 		case r.between(50, 60): // 10% chance of some well-formed opcodes
 			b := make([]byte, 10)
-			_, _ = crand.Read(b)
+			_, _ = rng.Read(b)
 			for i := range b {
 				if op := ops.OpCode(b[i]); ops.IsDefined(op) {
 					p.Op(vm.OpCode(op))
 				}
 			}
 		case r.between(60, 70): // 10% chance of some random opcode
-			p.Op(vm.OpCode(rand.Uint32()))
+			p.Op(vm.OpCode(rng.Uint32()))
 		case r.between(70, 80): // 10% zero value call with no data
-			p.Append(RandCall(nil, addrGen, nil, nil, nil))
+			p.Append(RandCall(rng, nil, addrGen, nil, nil, nil))
 			p.Op(vm.POP) // pop returnvalue
 		case r.between(80, 90): // 10% create and call
-			ctor := randTStoreOps()
-			runtimeCode := randCallTStore(addresses, depth+1)
+			ctor := randTStoreOps(rng)
+			runtimeCode := randCallTStore(rng, addresses, depth+1)
 			ctor.ReturnData(runtimeCode)
-			program2.CreateAndCall(p, ctor.Bytes(), r%2 == 0, randCallType())
+			program2.CreateAndCall(p, ctor.Bytes(), r%2 == 0, randCallType(rng))
 		case r.between(90, 95):
 			p.Push(addrGen())
 			p.Op(vm.SELFDESTRUCT)
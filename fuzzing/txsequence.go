@@ -0,0 +1,340 @@
+// Copyright 2026 Martin Holst Swende
+// This file is part of the goevmlab library.
+//
+// The library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the goevmlab library. If not, see <http://www.gnu.org/licenses/>.
+
+package fuzzing
+
+import (
+	"math/big"
+	"math/rand"
+	"slices"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/core/vm/program"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// seqFillers is a mapping of names to functions that fill a test with a
+// sequence of transactions (see GstMaker.AppendTx). As with the statetest
+// fillers, all randomness is drawn from the given source.
+var seqFillers = map[string]func(*rand.Rand, *GstMaker, string){
+	"txseq": fillTxSequence,
+}
+
+// GenerateSequence generates a test with a sequence of transactions using the
+// named engine, or returns nil if there is no such engine. The same engine and
+// seed always produce the same test.
+func GenerateSequence(name, fork string, seed int64) *GstMaker {
+	filler, ok := seqFillers[name]
+	if !ok {
+		return nil
+	}
+	gst := BasicStateTest(fork)
+	filler(rand.New(rand.NewSource(seed)), gst, fork)
+	gst.info = &stInfo{Engine: name, Seed: seed}
+	return gst
+}
+
+// SeededSequenceFactory is like SeededFactory, but for tests with a sequence
+// of transactions.
+func SeededSequenceFactory(name, fork string, seed int64) func() *GstMaker {
+	if g := NewSeededSequenceGenerator(name, fork, seed); g != nil {
+		return g.Generate
+	}
+	return nil
+}
+
+// NewSeededSequenceGenerator is like NewSeededGenerator, but for tests with a
+// sequence of transactions.
+func NewSeededSequenceGenerator(name, fork string, seed int64) *SeededGenerator[*GstMaker] {
+	if _, ok := seqFillers[name]; !ok {
+		return nil
+	}
+	return &SeededGenerator[*GstMaker]{
+		generate: func(seed int64) *GstMaker { return GenerateSequence(name, fork, seed) },
+		seed:     seed,
+	}
+}
+
+// SequenceFactoryNames returns the names of the available factories of tests
+// with a sequence of transactions.
+func SequenceFactoryNames() []string {
+	var names []string
+	for k := range seqFillers {
+		names = append(names, k)
+	}
+	slices.Sort(names)
+	return names
+}
+
+var (
+	// The contract which probes the state left behind by earlier txs
+	seqProbe = common.HexToAddress("0x00000000000000000000000000000000000005e0")
+	// Factories, which CREATE2 a contract that selfdestructs: in its
+	// initcode, when called right after creation, or when called in a later tx.
+	seqFactoryInit   = common.HexToAddress("0x00000000000000000000000000000000000005e1")
+	seqFactoryCall   = common.HexToAddress("0x00000000000000000000000000000000000005e2")
+	seqFactoryCreate = common.HexToAddress("0x00000000000000000000000000000000000005e3")
+	// A pre-existing contract which selfdestructs
+	seqDestructible = common.HexToAddress("0x00000000000000000000000000000000000005e4")
+)
+
+// fillTxSequence creates a sequence of transactions in one block, which
+// exercises the state carried over between transactions: transient storage
+// which must be reset, warm accounts and slots which must turn cold again,
+// contracts which are created and selfdestructed in the same or in different
+// transactions, and (from Prague) the nonces of 7702 delegations.
+func fillTxSequence(rng *rand.Rand, gst *GstMaker, fork string) {
+	var (
+		cancun = isForkOrLater(fork, "Cancun")
+		prague = isForkOrLater(fork, "Prague")
+		h      = newHelper()
+		// Beneficiaries of the selfdestructs
+		heirs = []common.Address{seqProbe, common.HexToAddress("0xf1"), common.HexToAddress("0xf2")}
+		// The accounts examined by the probe
+		targets = []common.Address{seqProbe, seqDestructible, sender, gst.env.Coinbase,
+			common.HexToAddress("0xf1"), common.HexToAddress("0x01")}
+		salts = []int{0, 1}
+	)
+	targets = append(targets, h.addrs[1:]...)
+	// The factories, and the addresses of the contracts they create
+	for _, f := range []struct {
+		addr     common.Address
+		initcode []byte
+		call     bool
+	}{
+		{seqFactoryInit, program.New().Selfdestruct(heirs[rng.Intn(len(heirs))]).Bytes(), false},
+		{seqFactoryCall, program.New().ReturnViaCodeCopy(program.New().Selfdestruct(heirs[rng.Intn(len(heirs))]).Bytes()).Bytes(), true},
+		{seqFactoryCreate, program.New().ReturnViaCodeCopy(program.New().Selfdestruct(heirs[rng.Intn(len(heirs))]).Bytes()).Bytes(), false},
+	} {
+		gst.AddAccount(f.addr, GenesisAccount{
+			Code:    seqFactory(f.initcode, f.call),
+			Balance: new(big.Int),
+			Storage: make(map[common.Hash]common.Hash),
+		})
+		for _, salt := range salts {
+			targets = append(targets, crypto.CreateAddress2(f.addr, common.BigToHash(big.NewInt(int64(salt))), crypto.Keccak256(f.initcode)))
+		}
+	}
+	gst.AddAccount(seqDestructible, GenesisAccount{
+		Code:    program.New().Selfdestruct(heirs[rng.Intn(len(heirs))]).Bytes(),
+		Balance: big.NewInt(1000),
+		Storage: make(map[common.Hash]common.Hash),
+	})
+	// The authorities of the delegations need to exist
+	for _, addr := range h.addrs[1:] {
+		gst.AddAccount(addr, GenesisAccount{
+			Balance: big.NewInt(1),
+			Storage: make(map[common.Hash]common.Hash),
+		})
+	}
+	// The contracts created by the sender may also be examined
+	for i := range uint64(8) {
+		targets = append(targets, crypto.CreateAddress(sender, i))
+	}
+	gst.SetCode(seqProbe, randSeqProbe(rng, targets, cancun))
+
+	var (
+		nonce uint64 // the nonce of the sender
+		nTxs  = 2 + rng.Intn(7)
+	)
+	for i := 0; i < nTxs; i++ {
+		tx := &StTransaction{
+			GasLimit:             []uint64{1_000_000},
+			Nonce:                nonce,
+			Value:                []string{"0x0"},
+			Data:                 []string{"0x"},
+			MaxFeePerGas:         big.NewInt(0x20),
+			MaxPriorityFeePerGas: big.NewInt(int64(rng.Intn(3))),
+			Sender:               sender,
+			PrivateKey:           pKey,
+		}
+		switch r := chance(rng.Intn(100)); {
+		case r.between(0, 40):
+			// Probe; the calldata distinguishes the txs
+			tx.To = seqProbe.Hex()
+			tx.Data = []string{hexutil.Encode(common.LeftPadBytes([]byte{byte(i)}, 32))}
+			if rng.Intn(4) == 0 {
+				// Warm up some of the accounts and slots examined by the probe
+				var al types.AccessList
+				for j := 1 + rng.Intn(3); j > 0; j-- {
+					tuple := types.AccessTuple{Address: targets[rng.Intn(len(targets))]}
+					if rng.Intn(2) == 0 {
+						tuple.Address = seqProbe
+						tuple.StorageKeys = []common.Hash{common.BigToHash(big.NewInt(int64(seqSlot(rng.Intn(4)))))}
+					}
+					al = append(al, tuple)
+				}
+				tx.AccessLists = []*types.AccessList{&al}
+			}
+		case r.between(40, 65):
+			// Create (and maybe destroy) a contract through a factory
+			factories := []common.Address{seqFactoryInit, seqFactoryCall, seqFactoryCreate}
+			tx.To = factories[rng.Intn(len(factories))].Hex()
+			tx.Data = []string{hexutil.Encode(common.LeftPadBytes([]byte{byte(salts[rng.Intn(len(salts))])}, 32))}
+			tx.Value = []string{hexutil.EncodeUint64(uint64(rng.Intn(100)))}
+		case r.between(65, 80):
+			// Call an account which may have been created, delegated or
+			// destroyed by an earlier tx
+			tx.To = targets[rng.Intn(len(targets))].Hex()
+			tx.Value = []string{hexutil.EncodeUint64(uint64(rng.Intn(2)))}
+		case r.between(80, 90):
+			// Create a contract from the tx, which either selfdestructs in
+			// the initcode, or deploys code which selfdestructs
+			code := program.New().Selfdestruct(heirs[rng.Intn(len(heirs))]).Bytes()
+			if rng.Intn(2) == 0 {
+				code = program.New().ReturnViaCodeCopy(code).Bytes()
+			}
+			tx.Data = []string{hexutil.Encode(code)}
+			tx.Value = []string{hexutil.EncodeUint64(uint64(rng.Intn(100)))}
+		default:
+			if !prague {
+				tx.To = seqDestructible.Hex()
+				break
+			}
+			// Delegate (or clear the delegation of) some accounts, possibly
+			// including the sender itself
+			tx.To = targets[rng.Intn(len(targets))].Hex()
+			dests := []common.Address{seqProbe, seqDestructible, seqFactoryCall, {}}
+			var auths []*stAuthorization
+			for j := 1 + rng.Intn(2); j > 0; j-- {
+				authority := h.addrs[rng.Intn(len(h.addrs))]
+				var authNonce uint64
+				if authority == sender {
+					// The nonce of the sender is incremented before the
+					// authorizations are processed
+					authNonce = nonce + 1
+				} else {
+					authNonce = h.nonces[authority]
+				}
+				if rng.Intn(8) == 0 {
+					authNonce++ // invalid, the authorization is skipped
+				} else if authority == sender {
+					nonce++
+				} else {
+					h.nonces[authority]++
+				}
+				dest := dests[rng.Intn(len(dests))]
+				if authority == sender && dest == seqFactoryCall {
+					// Calls into the sender would then bump its nonce
+					dest = seqProbe
+				}
+				auth, err := types.SignSetCode(h.keys[authority], types.SetCodeAuthorization{
+					ChainID: *h.chainID,
+					Address: dest,
+					Nonce:   authNonce,
+				})
+				if err != nil {
+					panic(err)
+				}
+				auths = append(auths, &stAuthorization{
+					ChainID: auth.ChainID.ToBig(),
+					Address: auth.Address,
+					Nonce:   auth.Nonce,
+					V:       auth.V,
+					R:       auth.R.ToBig(),
+					S:       auth.S.ToBig(),
+					Signer:  &authority,
+				})
+			}
+			tx.AuthorizationList = auths
+		}
+		nonce++
+		if i == 0 {
+			gst.SetTx(tx)
+		} else {
+			gst.AppendTx(tx)
+		}
+	}
+}
+
+// seqFactory creates code which CREATE2s the initcode, with the value of the
+// call and the salt from the calldata, and stores the address of the created
+// contract. If call is set, the created contract is then called.
+func seqFactory(initcode []byte, call bool) []byte {
+	p := program.New()
+	p.Mstore(initcode, 0)
+	p.Push(0).Op(vm.CALLDATALOAD) // salt
+	p.Push(len(initcode)).Push(0).Op(vm.CALLVALUE, vm.CREATE2)
+	if call {
+		p.Push(0).Push(0).Push(0).Push(0).Push(0)
+		p.Op(vm.DUP6, vm.GAS, vm.CALL, vm.POP)
+	}
+	p.Push(0).Op(vm.CALLDATALOAD, vm.SSTORE)
+	return p.Bytes()
+}
+
+// seqSlot returns the n:th slot used by the probe for its own storage, apart
+// from the slots of the results.
+func seqSlot(n int) int {
+	return 1<<16 + n
+}
+
+// randSeqProbe creates code which examines the state left behind by earlier
+// transactions: transient storage, the warmth of accounts and slots and the
+// accounts of the targets. The results of each tx are stored in separate
+// slots, so they all end up in the post-state.
+func randSeqProbe(rng *rand.Rand, targets []common.Address, cancun bool) []byte {
+	p := program.New()
+	slot := 0
+	store := func() {
+		// key: tx << 8 | slot
+		p.Push(slot).Push(0).Op(vm.CALLDATALOAD).Push(8).Op(vm.SHL, vm.OR)
+		p.Op(vm.SSTORE)
+		slot++
+	}
+	// measure stores the gas spent by the op on the target
+	measure := func(op vm.OpCode, target any) {
+		p.Push(target).Op(vm.GAS, vm.SWAP1, op, vm.POP, vm.GAS, vm.SWAP1, vm.SUB)
+		store()
+	}
+	if cancun {
+		// Transient storage must be empty at the start of each tx
+		p.Push(0).Op(vm.TLOAD)
+		store()
+		p.Tstore(0, 1)
+	}
+	for n := 4 + rng.Intn(12); n > 0; n-- {
+		target := targets[rng.Intn(len(targets))]
+		switch rng.Intn(5) {
+		case 0:
+			ops := []vm.OpCode{vm.BALANCE, vm.EXTCODESIZE, vm.EXTCODEHASH}
+			measure(ops[rng.Intn(len(ops))], target)
+		case 1:
+			// Slots are warm only within a tx
+			measure(vm.SLOAD, seqSlot(rng.Intn(4)))
+		case 2:
+			// The cost of SSTORE depends on the value at the start of the tx
+			p.Push(seqSlot(rng.Intn(4))).Push(rng.Intn(3))
+			p.Op(vm.GAS, vm.SWAP2, vm.SSTORE, vm.GAS, vm.SWAP1, vm.SUB)
+			store()
+		case 3:
+			ops := []vm.OpCode{vm.BALANCE, vm.EXTCODESIZE, vm.EXTCODEHASH}
+			p.Push(target).Op(ops[rng.Intn(len(ops))])
+			store()
+		default:
+			// Delegated accounts and created contracts may be called
+			if target == seqProbe {
+				target = seqDestructible
+			}
+			p.Call(nil, target, 0, 0, 0, 0, 0)
+			store()
+		}
+	}
+	return p.Bytes()
+}
@@ -19,6 +19,8 @@ package ops
 import (
 	"fmt"
 	"math/big"
+	"slices"
+	"strings"
 
 	"github.com/ethereum/go-ethereum/params"
 )
@@ -226,6 +228,28 @@ func ForkNames() []string {
 	return names
 }
 
+// ParseForks parses a comma-separated list of forks, and returns the forks in
+// chronological order. An error is returned if a fork is not defined.
+func ParseForks(list string) ([]string, error) {
+	var names []string
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if LookupFork(name) == nil {
+			return nil, fmt.Errorf("fork %q not defined, available forks: %v", name, ForkNames())
+		}
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	var forks []string
+	for _, f := range ForkNames() {
+		if slices.Contains(names, f) {
+			forks = append(forks, f)
+		}
+	}
+	return forks, nil
+}
+
 // ValidOpcodesInFork returns the set of valid opcodes for the given fork, or
 // error if the fork is not defined.
 func ValidOpcodesInFork(fork string) ([]OpCode, error) {
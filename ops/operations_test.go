@@ -17,6 +17,7 @@
 package ops
 
 import (
+	"slices"
 	"strings"
 	"testing"
 
@@ -131,3 +132,16 @@ func testForkOpcodes(t *testing.T, fork string) {
 		}
 	}
 }
+
+func TestParseForks(t *testing.T) {
+	forks, err := ParseForks("Osaka, Cancun,Prague,Cancun")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"Cancun", "Prague", "Osaka"}; !slices.Equal(forks, want) {
+		t.Errorf("have %v, want %v", forks, want)
+	}
+	if _, err := ParseForks("Cancun,Frontier"); err == nil {
+		t.Error("expected error for undefined fork")
+	}
+}